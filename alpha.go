@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+
+	pdfcolor "github.com/pdfcpu/pdfcpu/pkg/pdfcpu/color"
+)
+
+// Политики обработки прозрачности (-alpha)
+const (
+	// AlphaKeep сохраняет альфа-канал как SMask
+	AlphaKeep = "keep"
+	// AlphaFlatten накладывает изображение на цвет -background
+	AlphaFlatten = "flatten"
+	// AlphaWhite накладывает изображение на белый цвет
+	AlphaWhite = "white"
+)
+
+var alphaPolicies = map[string]bool{
+	AlphaKeep:    true,
+	AlphaFlatten: true,
+	AlphaWhite:   true,
+}
+
+// parseColor разбирает цвет в форматах pdfcpu: имя (white, gray, ...), #RRGGBB или "r g b" от 0 до 1
+func parseColor(s string) (color.NRGBA, error) {
+	sc, err := pdfcolor.ParseColor(s)
+	if err != nil {
+		return color.NRGBA{}, fmt.Errorf("%w: invalid color %q", ErrInvalidInput, s)
+	}
+	return color.NRGBA{
+		R: uint8(sc.R*255 + 0.5),
+		G: uint8(sc.G*255 + 0.5),
+		B: uint8(sc.B*255 + 0.5),
+		A: 0xff,
+	}, nil
+}
+
+// hexColor возвращает цвет в виде #rrggbb
+func hexColor(c color.NRGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// alphaBackground возвращает цвет подложки для политики прозрачности или false,
+// если альфа-канал сохраняется
+func (o Options) alphaBackground() (color.NRGBA, bool, error) {
+	white := color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+
+	switch o.Alpha {
+	case AlphaWhite:
+		return white, true, nil
+	case AlphaFlatten:
+		bg, err := parseColor(o.Background)
+		return bg, err == nil, err
+	}
+
+	// Серые и чёрно-белые страницы не хранят маску, прозрачность заливается белым
+	if o.Color != ColorKeep {
+		return white, true, nil
+	}
+	// PDF/A запрещает прозрачность, маска заменяется подложкой -background
+	if o.PDFA != "" {
+		bg, err := parseColor(o.Background)
+		return bg, err == nil, err
+	}
+	return color.NRGBA{}, false, nil
+}
+
+// flattenAlpha накладывает полупрозрачное изображение на сплошной цвет
+func flattenAlpha(img image.Image, bg color.Color) image.Image {
+	b := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(bg), image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Over)
+	return dst
+}
+
+// toNRGBA приводит изображение к NRGBA, не теряя точность полупрозрачных пикселей.
+// Палитровые изображения (PNG с tRNS) разворачиваются через палитру напрямую.
+func toNRGBA(img image.Image) *image.NRGBA {
+	b := img.Bounds()
+
+	switch src := img.(type) {
+	case *image.NRGBA:
+		return src
+
+	case *image.Paletted:
+		lut := make([]color.NRGBA, len(src.Palette))
+		for i, c := range src.Palette {
+			lut[i] = color.NRGBAModel.Convert(c).(color.NRGBA)
+		}
+		dst := image.NewNRGBA(b)
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				dst.SetNRGBA(x, y, lut[src.ColorIndexAt(x, y)])
+			}
+		}
+		return dst
+	}
+
+	if isOpaque(img) {
+		// Для непрозрачных изображений RGBA совпадает с NRGBA, а отрисовка в RGBA быстрее
+		rgba := image.NewRGBA(b)
+		draw.Draw(rgba, b, img, b.Min, draw.Src)
+		return &image.NRGBA{Pix: rgba.Pix, Stride: rgba.Stride, Rect: rgba.Rect}
+	}
+
+	dst := image.NewNRGBA(b)
+	draw.Draw(dst, b, img, b.Min, draw.Src)
+	return dst
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFlattenAlpha(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "alpha.png")
+	if err := createTestImageWithAlpha(path, 10, 10, "png", 128); err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := loadImage(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if isOpaque(src.Image) {
+		t.Fatal("Test image should be semi-transparent")
+	}
+
+	tests := []struct {
+		name string
+		bg   color.NRGBA
+		want color.RGBA
+	}{
+		// Пиксель (0,0) — это (0, 0, 100) с альфой 128
+		{"white", color.NRGBA{R: 255, G: 255, B: 255, A: 255}, color.RGBA{R: 127, G: 127, B: 177, A: 255}},
+		{"red", color.NRGBA{R: 255, A: 255}, color.RGBA{R: 127, G: 0, B: 50, A: 255}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flat := flattenAlpha(src.Image, tt.bg)
+			if !isOpaque(flat) {
+				t.Fatal("Flattened image should be opaque")
+			}
+			got := color.RGBAModel.Convert(flat.At(0, 0)).(color.RGBA)
+			if absDiff(got.R, tt.want.R) > 1 || absDiff(got.G, tt.want.G) > 1 || absDiff(got.B, tt.want.B) > 1 {
+				t.Errorf("Pixel (0,0) = %v; want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func absDiff(a, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+func TestEncodeFlate_PaletteWithTransparency(t *testing.T) {
+	palette := color.Palette{
+		color.NRGBA{R: 255, A: 255},
+		color.NRGBA{G: 255, A: 0},
+		color.NRGBA{B: 255, A: 64},
+	}
+	img := image.NewPaletted(image.Rect(0, 0, 3, 1), palette)
+	img.Pix = []uint8{0, 1, 2}
+
+	// Прогоняем через PNG, чтобы палитра и tRNS прошли через декодер
+	path := filepath.Join(t.TempDir(), "palette.png")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := png.Encode(file, img); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	src, err := loadImage(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := src.Image.(*image.Paletted); !ok {
+		t.Fatalf("Expected paletted image, got %T", src.Image)
+	}
+
+	encoded := encodeFlate(src.Image)
+	if encoded.SMask == nil {
+		t.Fatal("Transparent palette entries should produce an SMask")
+	}
+	if got := encoded.SMask.Data; got[0] != 255 || got[1] != 0 || got[2] != 64 {
+		t.Errorf("SMask = %v; want [255 0 64]", got)
+	}
+	// Цвет полупрозрачного пикселя не должен умножаться на альфу
+	if got := encoded.Data[6:9]; got[0] != 0 || got[1] != 0 || got[2] != 255 {
+		t.Errorf("Pixel 3 color = %v; want [0 0 255]", got)
+	}
+}
+
+func TestConvert_AlphaPolicies(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "alpha.png")
+	if err := createTestImageWithAlpha(path, 20, 20, "png", 100); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		policy    string
+		wantSMask bool
+		wantAlpha string
+	}{
+		{AlphaKeep, true, "smask"},
+		{AlphaWhite, false, "flattened onto #ffffff"},
+		{AlphaFlatten, false, "flattened onto #336699"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			opts := DefaultOptions()
+			opts.Alpha = tt.policy
+			opts.Background = "#336699"
+
+			output := filepath.Join(tmpDir, tt.policy+".pdf")
+			converter := NewConverterWithOptions(opts)
+			if err := converter.Convert(path, output, "seq"); err != nil {
+				t.Fatalf("Convert failed: %v", err)
+			}
+
+			images := pdfImages(t, output)
+			if len(images) != 1 {
+				t.Fatalf("Expected 1 image, got %d", len(images))
+			}
+			if images[0].HasSMask != tt.wantSMask {
+				t.Errorf("HasSMask = %v; want %v", images[0].HasSMask, tt.wantSMask)
+			}
+			if got := converter.Report().Pages[0].Alpha; got != tt.wantAlpha {
+				t.Errorf("Report alpha = %q; want %q", got, tt.wantAlpha)
+			}
+		})
+	}
+}
+
+func TestOptionsValidate_Background(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Alpha = AlphaFlatten
+	opts.Background = "not-a-color"
+	if err := opts.Validate(); !IsInvalidInput(err) {
+		t.Errorf("Expected invalid input error, got %v", err)
+	}
+}
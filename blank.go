@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+)
+
+// blankInkContrast — насколько яркость пикселя должна отличаться от бумаги, чтобы
+// считаться краской
+const blankInkContrast = 64
+
+// inkCoverage возвращает долю пикселей с краской в изображении без полосы по краям
+// шириной margin от соответствующей стороны. Прозрачные области накладываются на bg,
+// как на странице. Бумагой считается медианная яркость: она занимает большую часть
+// страницы, но может быть и темнее содержимого. Краской считаются пиксели, отличающиеся
+// от бумаги в любую сторону, поэтому сероватая бумага и просвечивающий оборот краской
+// не считаются.
+func inkCoverage(img image.Image, bg color.Color, margin float64) float64 {
+	if !isOpaque(img) {
+		img = flattenAlpha(img, bg)
+	}
+	gray := toGray(img)
+	b := gray.Bounds()
+	mx, my := int(float64(b.Dx())*margin), int(float64(b.Dy())*margin)
+	inner := image.Rect(b.Min.X+mx, b.Min.Y+my, b.Max.X-mx, b.Max.Y-my)
+	if inner.Empty() {
+		return 0
+	}
+
+	var hist [256]int
+	for y := inner.Min.Y; y < inner.Max.Y; y++ {
+		i := gray.PixOffset(inner.Min.X, y)
+		for _, v := range gray.Pix[i : i+inner.Dx()] {
+			hist[v]++
+		}
+	}
+
+	total := inner.Dx() * inner.Dy()
+	paper, seen := 0, 0
+	for v, n := range hist {
+		seen += n
+		if 2*seen >= total {
+			paper = v
+			break
+		}
+	}
+
+	ink := 0
+	for v, n := range hist {
+		if v < paper-blankInkContrast || v > paper+blankInkContrast {
+			ink += n
+		}
+	}
+	return float64(ink) / float64(total)
+}
+
+// removeBlank исключает изображения, покрытие краской которых ниже BlankThreshold,
+// и записывает их в отчёт
+func (c *Converter) removeBlank(images []ImageInfo) ([]ImageInfo, error) {
+	kept := images[:0:0]
+	for _, info := range images {
+		// Пустой рисунок SVG не отличить от пустого скана без растеризации, он остаётся
+		if isSVG(info.Path) {
+			kept = append(kept, info)
+			continue
+		}
+		src, err := c.loadFrame(info)
+		if err != nil {
+			return nil, err
+		}
+		// Прозрачность оценивается на той же подложке, что и при выводе страницы
+		bg, flatten, err := c.opts.alphaBackground()
+		if err != nil {
+			return nil, err
+		}
+		if !flatten {
+			bg = color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+		}
+		coverage := inkCoverage(src.Image, bg, c.opts.BlankMargin/100) * 100
+		if coverage < c.opts.BlankThreshold {
+			c.report.Removed = append(c.report.Removed, RemovedImage{Source: info.source(), Coverage: coverage})
+			continue
+		}
+		kept = append(kept, info)
+	}
+	if len(kept) == 0 {
+		return nil, fmt.Errorf("%w: all %d images are blank", ErrNoImagesFound, len(images))
+	}
+	return kept, nil
+}
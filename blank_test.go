@@ -0,0 +1,111 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInkCoverage(t *testing.T) {
+	page := func(paper uint8, marks ...image.Rectangle) *image.Gray {
+		img := image.NewGray(image.Rect(0, 0, 200, 200))
+		draw.Draw(img, img.Bounds(), image.NewUniform(color.Gray{Y: paper}), image.Point{}, draw.Src)
+		for _, m := range marks {
+			draw.Draw(img, m, image.NewUniform(color.Gray{Y: 20}), image.Point{}, draw.Src)
+		}
+		return img
+	}
+
+	tests := []struct {
+		name     string
+		img      image.Image
+		min, max float64
+	}{
+		{"white", page(255), 0, 0},
+		// Тень от края сканера лежит в полосе 5%
+		{"edge shadow", page(250, image.Rect(0, 0, 6, 200)), 0, 0},
+		{"text", page(240, image.Rect(40, 40, 160, 60)), 0.07, 0.08},
+		// Сероватая бумага сама по себе не краска
+		{"gray paper", page(150), 0, 0},
+	}
+	for _, tt := range tests {
+		if got := inkCoverage(tt.img, color.White, 0.05); got < tt.min || got > tt.max {
+			t.Errorf("%s: inkCoverage = %.4f; want %.2f..%.2f", tt.name, got, tt.min, tt.max)
+		}
+	}
+
+	// Просвечивающий оборот светлее краски на бумаге
+	showThrough := page(245)
+	draw.Draw(showThrough, image.Rect(30, 30, 170, 170), image.NewUniform(color.Gray{Y: 215}), image.Point{}, draw.Src)
+	if got := inkCoverage(showThrough, color.White, 0.05); got != 0 {
+		t.Errorf("Show-through: inkCoverage = %.4f; want 0", got)
+	}
+
+	// Тёмная страница со светлым рисунком
+	dark := page(30)
+	draw.Draw(dark, image.Rect(40, 40, 160, 60), image.NewUniform(color.Gray{Y: 230}), image.Point{}, draw.Src)
+	if got := inkCoverage(dark, color.White, 0.05); got < 0.07 || got > 0.08 {
+		t.Errorf("Dark page: inkCoverage = %.4f; want 0.07..0.08", got)
+	}
+
+	// Чёрный текст на прозрачном фоне лежит на белой подложке
+	logo := image.NewNRGBA(image.Rect(0, 0, 200, 200))
+	draw.Draw(logo, image.Rect(40, 40, 160, 60), image.NewUniform(color.Black), image.Point{}, draw.Src)
+	if got := inkCoverage(logo, color.White, 0.05); got < 0.07 || got > 0.08 {
+		t.Errorf("Transparent background: inkCoverage = %.4f; want 0.07..0.08", got)
+	}
+	if got := inkCoverage(logo, color.Black, 0.05); got != 0 {
+		t.Errorf("Transparent background on black: inkCoverage = %.4f; want 0", got)
+	}
+}
+
+func TestConvert_RemoveBlank(t *testing.T) {
+	tmpDir := t.TempDir()
+	// Двусторонний скан: лицевые стороны, затем обороты в обратном порядке
+	scans := []struct {
+		name    string
+		content image.Rectangle
+	}{
+		{"1.png", image.Rect(30, 40, 170, 260)},
+		{"2.png", image.Rect(30, 40, 170, 100)},
+		{"3.png", image.Rect(0, 0, 6, 300)},
+		{"4.png", image.Rectangle{}},
+	}
+	var paths []string
+	for _, s := range scans {
+		path := filepath.Join(tmpDir, s.name)
+		if err := createScan(path, 200, 300, color.White, s.content); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+
+	opts := DefaultOptions()
+	opts.RemoveBlank = true
+
+	output := filepath.Join(tmpDir, "out.pdf")
+	converter := NewConverterWithOptions(opts)
+	if err := converter.Convert(strings.Join(paths, ","), output, "duplex"); err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+
+	report := converter.Report()
+	if len(report.Pages) != 2 || report.Pages[0].Source != paths[0] || report.Pages[1].Source != paths[1] {
+		t.Fatalf("Expected the two fronts, got %+v", report.Pages)
+	}
+	if len(report.Removed) != 2 || report.Removed[0].Source != paths[3] || report.Removed[1].Source != paths[2] {
+		t.Errorf("Removed = %+v; want both backs in duplex order", report.Removed)
+	}
+	if n, err := countPDFPages(output); err != nil || n != 2 {
+		t.Errorf("Expected 2 pages, got %d (%v)", n, err)
+	}
+
+	// Если пусты все страницы, PDF не создаётся
+	err := NewConverterWithOptions(opts).Convert(paths[3], filepath.Join(tmpDir, "empty.pdf"), "seq")
+	if !IsNoImagesFound(err) {
+		t.Errorf("Expected ErrNoImagesFound, got %v", err)
+	}
+}
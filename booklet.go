@@ -0,0 +1,26 @@
+package main
+
+// bookletGrid — две страницы брошюры рядом на альбомном листе
+var bookletGrid = nupGrid{Cols: 2, Rows: 1}
+
+// imposeBooklet дополняет страницы пустыми до числа, кратного 4, и переставляет их
+// для брошюры со скрепкой. Каждые четыре страницы результата — лицевая и обратная
+// сторона одного листа: слева направо последняя и первая, затем вторая и предпоследняя
+// из ещё не размещённых.
+func imposeBooklet(pages []ImageInfo) []ImageInfo {
+	n := (len(pages) + 3) / 4 * 4
+	padded := make([]ImageInfo, n)
+	copy(padded, pages)
+	for i := len(pages); i < n; i++ {
+		padded[i] = ImageInfo{Blank: true}
+	}
+
+	result := make([]ImageInfo, 0, n)
+	for i := 0; i < n/4; i++ {
+		result = append(result,
+			padded[n-1-2*i], padded[2*i],
+			padded[2*i+1], padded[n-2-2*i],
+		)
+	}
+	return result
+}
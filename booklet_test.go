@@ -0,0 +1,90 @@
+package main
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+)
+
+func TestImposeBooklet(t *testing.T) {
+	var pages []ImageInfo
+	for i := 1; i <= 6; i++ {
+		pages = append(pages, ImageInfo{Path: strconv.Itoa(i)})
+	}
+
+	var got []string
+	for _, p := range imposeBooklet(pages) {
+		if p.Blank {
+			got = append(got, "-")
+		} else {
+			got = append(got, p.Path)
+		}
+	}
+	// Восемь страниц на двух листах: 8|1 и 2|7 на первом, 6|3 и 4|5 на втором
+	if want := "- 1 2 - 6 3 4 5"; strings.Join(got, " ") != want {
+		t.Errorf("imposeBooklet = %v; want %s", got, want)
+	}
+
+	if got := imposeBooklet(pages[:4]); len(got) != 4 || got[0].Path != "4" || got[3].Path != "3" {
+		t.Errorf("Four pages imposed as %+v", got)
+	}
+}
+
+func TestConvert_Booklet(t *testing.T) {
+	tmpDir := t.TempDir()
+	var paths []string
+	for i := 0; i < 5; i++ {
+		path := filepath.Join(tmpDir, "page"+strconv.Itoa(i)+".png")
+		if err := createTestImage(path, 210, 297, "png"); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+
+	opts := DefaultOptions()
+	opts.Booklet = true
+	opts.Gutter = 10
+
+	output := filepath.Join(tmpDir, "zine.pdf")
+	converter := NewConverterWithOptions(opts)
+	if err := converter.Convert(strings.Join(paths, ","), output, "seq"); err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+	if err := api.ValidateFile(output, nil); err != nil {
+		t.Fatalf("Invalid PDF: %v", err)
+	}
+
+	// 5 страниц дополняются до 8 и ложатся на 4 стороны двух альбомных листов A4
+	dims, err := api.PageDimsFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dims) != 4 {
+		t.Fatalf("Expected 4 sheet sides, got %d", len(dims))
+	}
+	for i, d := range dims {
+		if int(d.Width) != 842 || int(d.Height) != 595 {
+			t.Errorf("Sheet side %d: %.0fx%.0f; want A4 landscape", i+1, d.Width, d.Height)
+		}
+	}
+
+	// Первая страница — справа на лицевой стороне первого листа, слева от неё пустая
+	pages := converter.Report().Pages
+	if len(pages) != 8 {
+		t.Fatalf("Expected 8 report entries, got %d", len(pages))
+	}
+	if !pages[0].Blank || pages[0].Cell != 1 {
+		t.Errorf("First entry = %+v; want the blank last page in cell 1", pages[0])
+	}
+	if pages[1].Source != paths[0] || pages[1].Page != 1 || pages[1].Cell != 2 {
+		t.Errorf("Second entry = %+v; want page 1 in cell 2 of sheet side 1", pages[1])
+	}
+
+	opts.Split = SplitSpread
+	if err := NewConverterWithOptions(opts).Convert(paths[0], output, "seq"); !IsInvalidInput(err) {
+		t.Errorf("Expected invalid input error for -booklet with -split, got %v", err)
+	}
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// Источники закладок документа (-bookmarks)
+const (
+	// BookmarksNone не создаёт закладок
+	BookmarksNone = "none"
+	// BookmarksDir создаёт закладку на первую страницу каждого каталога, вложенные
+	// каталоги становятся вложенными закладками
+	BookmarksDir = "dir"
+	// BookmarksFile создаёт закладку на каждый файл с его именем без расширения
+	BookmarksFile = "file"
+	// BookmarksManifest создаёт закладки только из полей bookmark манифеста
+	BookmarksManifest = "manifest"
+)
+
+var bookmarkModes = map[string]bool{
+	BookmarksNone:     true,
+	BookmarksDir:      true,
+	BookmarksFile:     true,
+	BookmarksManifest: true,
+}
+
+// validateBookmarks проверяет источник закладок
+func (o Options) validateBookmarks() error {
+	if !bookmarkModes[o.Bookmarks] {
+		return fmt.Errorf("%w: unknown bookmarks mode %q", ErrInvalidInput, o.Bookmarks)
+	}
+	if o.Bookmarks == BookmarksManifest && o.Manifest == "" {
+		return fmt.Errorf("%w: -bookmarks manifest needs -manifest", ErrInvalidInput)
+	}
+	return nil
+}
+
+// outlineItem — закладка на страницу page (от 1) с вложенными закладками
+type outlineItem struct {
+	Title string
+	Page  int
+	Kids  []*outlineItem
+}
+
+// outline собирает закладки по мере вывода страниц
+type outline struct {
+	mode  string
+	rules []pageRule
+	// root — общий каталог изображений для режима dir, nested — лежат ли
+	// изображения прямо в нём вместе с подкаталогами
+	root   string
+	nested bool
+
+	items []*outlineItem
+	// path и open — заголовки и закладки последней ветви, к которой добавляются
+	// следующие изображения того же каталога
+	path []string
+	open []*outlineItem
+	// file — файл последней закладки: кадры многостраничного файла делят одну закладку
+	file string
+}
+
+// newOutline готовит закладки для изображений images в порядке вывода
+func (c *Converter) newOutline(images []ImageInfo) *outline {
+	o := &outline{mode: c.opts.Bookmarks, rules: c.rules}
+	if o.mode != BookmarksDir {
+		return o
+	}
+
+	var dirs []string
+	for _, img := range images {
+		if !img.Blank {
+			dirs = append(dirs, imageDir(img.Path))
+		}
+	}
+	if len(dirs) == 0 {
+		return o
+	}
+	o.root = dirs[0]
+	for _, dir := range dirs[1:] {
+		for !within(o.root, dir) {
+			o.root = filepath.Dir(o.root)
+		}
+	}
+	for _, dir := range dirs {
+		if dir == o.root {
+			o.nested = true
+		}
+	}
+	return o
+}
+
+// imageDir возвращает абсолютный путь каталога изображения
+func imageDir(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return filepath.Dir(path)
+}
+
+// within сообщает, лежит ли dir внутри root или совпадает с ним
+func within(root, dir string) bool {
+	rel, err := filepath.Rel(root, dir)
+	if err != nil {
+		// Каталоги на разных томах: общим остаётся только корень
+		return filepath.Dir(root) == root
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// titles возвращает ветвь закладок для изображения с номером index (от 1)
+// или nil, если изображению закладка не нужна
+func (o *outline) titles(img ImageInfo, index int) []string {
+	custom := ruleFor(o.rules, img.Path, index).Bookmark
+	switch o.mode {
+	case BookmarksDir:
+		dir := imageDir(img.Path)
+		var path []string
+		if dir == o.root || o.nested {
+			path = append(path, filepath.Base(o.root))
+		}
+		if rel, err := filepath.Rel(o.root, dir); err == nil && rel != "." {
+			path = append(path, strings.Split(rel, string(filepath.Separator))...)
+		}
+		return path
+	case BookmarksFile:
+		if custom != "" {
+			return []string{custom}
+		}
+		name := filepath.Base(img.Path)
+		return []string{strings.TrimSuffix(name, filepath.Ext(name))}
+	case BookmarksManifest:
+		// Через / задаётся вложенность: "Part 1/Chapter 2"
+		var path []string
+		for _, t := range strings.Split(custom, "/") {
+			if t = strings.TrimSpace(t); t != "" {
+				path = append(path, t)
+			}
+		}
+		return path
+	}
+	return nil
+}
+
+// add отмечает, что изображение с номером index начинается на странице page
+func (o *outline) add(img ImageInfo, index, page int) {
+	if o.mode == BookmarksNone || img.Blank {
+		return
+	}
+	path := o.titles(img, index)
+	if len(path) == 0 {
+		return
+	}
+
+	if o.mode == BookmarksFile && img.Frame > 0 && img.Path == o.file {
+		return
+	}
+	o.file = img.Path
+
+	// Для каждого файла закладка своя, даже если имена совпадают
+	common := 0
+	if o.mode != BookmarksFile {
+		for common < len(path) && common < len(o.path) && path[common] == o.path[common] {
+			common++
+		}
+	}
+	o.open = o.open[:common]
+	for _, title := range path[common:] {
+		item := &outlineItem{Title: title, Page: page}
+		if len(o.open) == 0 {
+			o.items = append(o.items, item)
+		} else {
+			parent := o.open[len(o.open)-1]
+			parent.Kids = append(parent.Kids, item)
+		}
+		o.open = append(o.open, item)
+	}
+	o.path = path
+}
+
+// setOutline записывает закладки в каталог документа. Все ветви раскрыты.
+func (w *pdfWriter) setOutline(items []*outlineItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+	root := types.Dict(map[string]types.Object{"Type": types.Name("Outlines")})
+	rootRef, err := w.ctx.IndRefForNewObject(root)
+	if err != nil {
+		return err
+	}
+	count, err := w.outlineItems(root, *rootRef, items)
+	if err != nil {
+		return err
+	}
+	root.InsertInt("Count", count)
+	w.ctx.RootDict.Insert("Outlines", *rootRef)
+	w.ctx.RootDict.Insert("PageMode", types.Name("UseOutlines"))
+	return nil
+}
+
+// outlineItems добавляет закладки items в parent и возвращает их число вместе с вложенными
+func (w *pdfWriter) outlineItems(parent types.Dict, parentRef types.IndirectRef, items []*outlineItem) (int, error) {
+	count := 0
+	var prev types.Dict
+	var prevRef *types.IndirectRef
+	for _, item := range items {
+		page, err := w.pageRef(item.Page)
+		if err != nil {
+			return 0, err
+		}
+		d := types.Dict(map[string]types.Object{
+			"Title":  pdfString(item.Title),
+			"Parent": parentRef,
+			"Dest":   types.Array{page, types.Name("Fit")},
+		})
+		ref, err := w.ctx.IndRefForNewObject(d)
+		if err != nil {
+			return 0, err
+		}
+		if len(item.Kids) > 0 {
+			kids, err := w.outlineItems(d, *ref, item.Kids)
+			if err != nil {
+				return 0, err
+			}
+			d.InsertInt("Count", kids)
+			count += kids
+		}
+
+		if prev == nil {
+			parent.Insert("First", *ref)
+		} else {
+			prev.Insert("Next", *ref)
+			d.Insert("Prev", *prevRef)
+		}
+		prev, prevRef = d, ref
+		count++
+	}
+	parent.Insert("Last", *prevRef)
+	return count, nil
+}
+
+// pageRef возвращает ссылку на страницу с номером n (от 1)
+func (w *pdfWriter) pageRef(n int) (types.IndirectRef, error) {
+	_, ref, _, err := w.ctx.PageDict(n, false)
+	if err != nil {
+		return types.IndirectRef{}, err
+	}
+	if ref == nil {
+		return types.IndirectRef{}, fmt.Errorf("page %d not found", n)
+	}
+	return *ref, nil
+}
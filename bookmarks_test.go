@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+)
+
+// outlineString записывает закладки PDF как "title@page[kids]" для сравнения
+func outlineString(t *testing.T, path string) string {
+	t.Helper()
+	ctx, err := api.ReadContextFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bms, err := pdfcpu.Bookmarks(ctx)
+	if err != nil {
+		t.Fatalf("Reading bookmarks failed: %v", err)
+	}
+	var format func(bms []pdfcpu.Bookmark) string
+	format = func(bms []pdfcpu.Bookmark) string {
+		var parts []string
+		for _, bm := range bms {
+			s := fmt.Sprintf("%s@%d", bm.Title, bm.PageFrom)
+			if len(bm.Kids) > 0 {
+				s += "[" + format(bm.Kids) + "]"
+			}
+			parts = append(parts, s)
+		}
+		return strings.Join(parts, " ")
+	}
+	return format(bms)
+}
+
+// createTree создаёт JPG-файлы по относительным путям внутри dir
+func createTree(t *testing.T, dir string, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := createTestJPG(path, 10, 10); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestConvert_BookmarksDir(t *testing.T) {
+	tmpDir := t.TempDir()
+	chapters := filepath.Join(tmpDir, "chapters")
+	createTree(t, chapters, "01/a.jpg", "01/b.jpg", "02/c.jpg", "02/notes/d.jpg")
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"entries", filepath.Join(chapters, "01") + "," + filepath.Join(chapters, "02"), "01@1 02@3[notes@4]"},
+		{"one directory", filepath.Join(chapters, "01"), "01@1"},
+	}
+	for _, tt := range tests {
+		opts := DefaultOptions()
+		opts.Bookmarks = BookmarksDir
+		output := filepath.Join(tmpDir, "out.pdf")
+		if err := NewConverterWithOptions(opts).Convert(tt.input, output, "seq"); err != nil {
+			t.Fatalf("%s: Convert failed: %v", tt.name, err)
+		}
+		if got := outlineString(t, output); got != tt.want {
+			t.Errorf("%s: outline = %q; want %q", tt.name, got, tt.want)
+		}
+	}
+
+	// Изображения рядом с подкаталогами вкладывают всё в закладку общего каталога
+	createTree(t, chapters, "cover.jpg")
+	opts := DefaultOptions()
+	opts.Bookmarks = BookmarksDir
+	output := filepath.Join(tmpDir, "nested.pdf")
+	input := filepath.Join(chapters, "cover.jpg") + "," + chapters
+	if err := NewConverterWithOptions(opts).Convert(input, output, "seq"); err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+	if got, want := outlineString(t, output), "chapters@1[01@2 02@4[notes@5]]"; got != want {
+		t.Errorf("Nested outline = %q; want %q", got, want)
+	}
+	if err := api.ValidateFile(output, nil); err != nil {
+		t.Errorf("Output is not a valid PDF: %v", err)
+	}
+}
+
+func TestConvert_BookmarksFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	createTree(t, tmpDir, "a.jpg", "b.jpg", "c.jpg")
+	manifest := filepath.Join(tmpDir, "manifest.json")
+	if err := os.WriteFile(manifest, []byte(`{"pages": [{"match": "b.jpg", "bookmark": "Введение"}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := DefaultOptions()
+	opts.Bookmarks = BookmarksFile
+	opts.Manifest = manifest
+	opts.Nup = "2x1"
+	// Два изображения на листе: закладки первых двух ведут на одну страницу
+	output := filepath.Join(tmpDir, "out.pdf")
+	if err := NewConverterWithOptions(opts).Convert(tmpDir, output, "nam"); err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+	if got, want := outlineString(t, output), "a@1 Введение@1 c@2"; got != want {
+		t.Errorf("Outline = %q; want %q", got, want)
+	}
+}
+
+func TestConvert_BookmarksManifest(t *testing.T) {
+	tmpDir := t.TempDir()
+	createTree(t, tmpDir, "cover.jpg", "ch1_1.jpg", "ch1_2.jpg", "ch2_1.jpg", "index.jpg")
+	manifest := filepath.Join(tmpDir, "manifest.json")
+	err := os.WriteFile(manifest, []byte(`{"pages": [
+		{"match": "cover.jpg", "bookmark": "Cover"},
+		{"match": "ch1_*.jpg", "bookmark": "Part 1/Chapter 1"},
+		{"match": "ch2_*.jpg", "bookmark": "Part 1 / Chapter 2"}
+	]}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var paths []string
+	for _, name := range []string{"cover.jpg", "ch1_1.jpg", "ch1_2.jpg", "ch2_1.jpg", "index.jpg"} {
+		paths = append(paths, filepath.Join(tmpDir, name))
+	}
+	opts := DefaultOptions()
+	opts.Bookmarks = BookmarksManifest
+	opts.Manifest = manifest
+	output := filepath.Join(tmpDir, "out.pdf")
+	if err := NewConverterWithOptions(opts).Convert(strings.Join(paths, ","), output, "seq"); err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+	if got, want := outlineString(t, output), "Cover@1 Part 1@2[Chapter 1@2 Chapter 2@4]"; got != want {
+		t.Errorf("Outline = %q; want %q", got, want)
+	}
+}
+
+func TestValidateBookmarks(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Bookmarks = "toc"
+	if err := opts.Validate(); !IsInvalidInput(err) {
+		t.Errorf("Unknown mode: expected ErrInvalidInput, got %v", err)
+	}
+	opts.Bookmarks = BookmarksManifest
+	if err := opts.Validate(); !IsInvalidInput(err) {
+		t.Errorf("Manifest mode without -manifest: expected ErrInvalidInput, got %v", err)
+	}
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"image"
+	"image/draw"
+
+	"github.com/pdfcpu/pdfcpu/pkg/filter"
+)
+
+// Цветовые режимы страниц (-color)
+const (
+	// ColorKeep сохраняет цвета изображения
+	ColorKeep = "color"
+	// ColorGray переводит страницы в оттенки серого
+	ColorGray = "gray"
+	// ColorBW бинаризует страницы и сжимает их CCITT G4
+	ColorBW = "bw"
+)
+
+// EncodingCCITT — однобитная страница в CCITT Group 4
+const EncodingCCITT = "ccitt-g4"
+
+var colorModes = map[string]bool{
+	ColorKeep: true,
+	ColorGray: true,
+	ColorBW:   true,
+}
+
+// toGray переводит изображение в 8-битные оттенки серого
+func toGray(img image.Image) *image.Gray {
+	if g, ok := img.(*image.Gray); ok {
+		return g
+	}
+	b := img.Bounds()
+	gray := image.NewGray(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(gray, gray.Bounds(), img, b.Min, draw.Src)
+	return gray
+}
+
+// otsuThreshold подбирает порог бинаризации, максимизирующий межклассовую дисперсию
+func otsuThreshold(img *image.Gray) uint8 {
+	var hist [256]int
+	b := img.Bounds()
+	for y := 0; y < b.Dy(); y++ {
+		for _, v := range img.Pix[y*img.Stride : y*img.Stride+b.Dx()] {
+			hist[v]++
+		}
+	}
+
+	total := b.Dx() * b.Dy()
+	var sum float64
+	for i, n := range hist {
+		sum += float64(i * n)
+	}
+
+	var (
+		sumBack float64
+		wBack   int
+		best    float64
+		level   uint8
+	)
+	for t := 0; t < 256; t++ {
+		wBack += hist[t]
+		if wBack == 0 {
+			continue
+		}
+		wFore := total - wBack
+		if wFore == 0 {
+			break
+		}
+		sumBack += float64(t * hist[t])
+		mBack := sumBack / float64(wBack)
+		mFore := (sum - sumBack) / float64(wFore)
+		between := float64(wBack) * float64(wFore) * (mBack - mFore) * (mBack - mFore)
+		if between > best {
+			best = between
+			level = uint8(t)
+		}
+	}
+
+	// Пиксели со значением выше порога — белые
+	return level
+}
+
+// binarize превращает серое изображение в чёрно-белое (0 и 255).
+// Пиксели не светлее threshold становятся чёрными. С dither ошибка квантования
+// рассеивается по Флойду — Штейнбергу.
+func binarize(img *image.Gray, threshold uint8, dither bool) *image.Gray {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	out := image.NewGray(image.Rect(0, 0, w, h))
+
+	if !dither {
+		for y := 0; y < h; y++ {
+			src := img.Pix[y*img.Stride : y*img.Stride+w]
+			dst := out.Pix[y*out.Stride : y*out.Stride+w]
+			for x, v := range src {
+				if v > threshold {
+					dst[x] = 0xff
+				}
+			}
+		}
+		return out
+	}
+
+	// Ошибка текущей и следующей строки, со сдвигом на 1 для соседей слева и справа
+	cur := make([]int, w+2)
+	next := make([]int, w+2)
+	for y := 0; y < h; y++ {
+		src := img.Pix[y*img.Stride : y*img.Stride+w]
+		dst := out.Pix[y*out.Stride : y*out.Stride+w]
+		for x, v := range src {
+			val := int(v) + cur[x+1]/16
+			var q int
+			if val > int(threshold) {
+				q = 0xff
+				dst[x] = 0xff
+			}
+			e := val - q
+			cur[x+2] += e * 7
+			next[x] += e * 3
+			next[x+1] += e * 5
+			next[x+2] += e
+		}
+		cur, next = next, cur
+		clear(next)
+	}
+	return out
+}
+
+// applyColorMode приводит изображение к выбранному цветовому режиму.
+// Второе значение сообщает, изменилось ли изображение.
+func (o Options) applyColorMode(img image.Image) (image.Image, bool) {
+	switch o.Color {
+	case ColorGray:
+		if _, ok := img.(*image.Gray); ok {
+			return img, false
+		}
+		return toGray(img), true
+
+	case ColorBW:
+		gray := toGray(img)
+		level := uint8(o.Threshold)
+		if level == 0 {
+			level = otsuThreshold(gray)
+		}
+		return binarize(gray, level, o.Dither), true
+	}
+	return img, false
+}
+
+// encodeBilevel упаковывает чёрно-белое изображение в CCITT G4
+func encodeBilevel(img *image.Gray) *pdfImage {
+	b := img.Bounds()
+	return &pdfImage{
+		Width:            b.Dx(),
+		Height:           b.Dy(),
+		BitsPerComponent: 1,
+		ColorSpace:       "DeviceGray",
+		Filter:           filter.CCITTFax,
+		Data:             encodeG4(img),
+		DecodeParms: map[string]int{
+			"K":       -1,
+			"Columns": b.Dx(),
+			"Rows":    b.Dy(),
+		},
+	}
+}
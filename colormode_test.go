@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"math/rand"
+	"path/filepath"
+	"testing"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"golang.org/x/image/ccitt"
+)
+
+func TestEncodeG4_RoundTrip(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+
+	tests := []struct {
+		name string
+		w, h int
+		fill func(x, y int) bool
+	}{
+		{"white", 17, 5, func(x, y int) bool { return false }},
+		{"black", 17, 5, func(x, y int) bool { return true }},
+		{"stripes", 64, 16, func(x, y int) bool { return (x/3+y)%2 == 0 }},
+		{"checker", 33, 33, func(x, y int) bool { return (x/4+y/4)%2 == 0 }},
+		{"noise", 101, 37, func(x, y int) bool { return rnd.Intn(2) == 0 }},
+		// Серии длиннее 2560 пикселей кодируются несколькими дополнительными кодами
+		{"long runs", 6000, 3, func(x, y int) bool { return x > 2700 && x < 5900 && y != 1 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img := image.NewGray(image.Rect(0, 0, tt.w, tt.h))
+			for y := 0; y < tt.h; y++ {
+				for x := 0; x < tt.w; x++ {
+					if !tt.fill(x, y) {
+						img.SetGray(x, y, color.Gray{Y: 0xff})
+					}
+				}
+			}
+
+			got := image.NewGray(img.Bounds())
+			data := encodeG4(img)
+			if err := ccitt.DecodeIntoGray(got, bytes.NewReader(data), ccitt.MSB, ccitt.Group4, nil); err != nil {
+				t.Fatalf("Decode failed: %v", err)
+			}
+			if !bytes.Equal(got.Pix, img.Pix) {
+				t.Error("Decoded image differs from the original")
+			}
+		})
+	}
+}
+
+func TestOtsuThreshold(t *testing.T) {
+	// Тёмный текст около 40 на светлой бумаге около 210
+	img := image.NewGray(image.Rect(0, 0, 100, 100))
+	for i := range img.Pix {
+		if i%7 == 0 {
+			img.Pix[i] = uint8(30 + i%20)
+		} else {
+			img.Pix[i] = uint8(200 + i%20)
+		}
+	}
+
+	level := otsuThreshold(img)
+	if level < 49 || level >= 200 {
+		t.Errorf("otsuThreshold = %d; want between the two classes", level)
+	}
+}
+
+func TestBinarize(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 64, 8))
+	for x := 0; x < 64; x++ {
+		for y := 0; y < 8; y++ {
+			img.SetGray(x, y, color.Gray{Y: uint8(x * 4)})
+		}
+	}
+
+	for _, dither := range []bool{false, true} {
+		out := binarize(img, 127, dither)
+		black := 0
+		for _, v := range out.Pix {
+			switch v {
+			case 0:
+				black++
+			case 0xff:
+			default:
+				t.Fatalf("dither=%v: unexpected pixel value %d", dither, v)
+			}
+		}
+		// Градиент от чёрного к белому — примерно половина пикселей чёрные
+		if black < len(out.Pix)*2/5 || black > len(out.Pix)*3/5 {
+			t.Errorf("dither=%v: %d of %d pixels are black", dither, black, len(out.Pix))
+		}
+	}
+
+	// Без дизеринга граница проходит ровно по порогу
+	out := binarize(img, 127, false)
+	if out.GrayAt(31, 0).Y != 0 || out.GrayAt(32, 0).Y != 0xff {
+		t.Errorf("Threshold boundary: got %d and %d", out.GrayAt(31, 0).Y, out.GrayAt(32, 0).Y)
+	}
+}
+
+func TestConvert_ColorModes(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "scan.png")
+	if err := createTestImageWithAlpha(path, 40, 30, "png", 200); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		mode         string
+		wantCS       string
+		wantBPC      int
+		wantFilter   string
+		wantEncoding string
+	}{
+		{ColorKeep, model.DeviceRGBCS, 8, "FlateDecode", EncodingFlate},
+		{ColorGray, model.DeviceGrayCS, 8, "FlateDecode", EncodingFlate},
+		{ColorBW, model.DeviceGrayCS, 1, "CCITTFaxDecode", EncodingCCITT},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			opts := DefaultOptions()
+			opts.Color = tt.mode
+
+			output := filepath.Join(tmpDir, tt.mode+".pdf")
+			converter := NewConverterWithOptions(opts)
+			if err := converter.Convert(path, output, "seq"); err != nil {
+				t.Fatalf("Convert failed: %v", err)
+			}
+			if err := api.ValidateFile(output, nil); err != nil {
+				t.Fatalf("Invalid PDF: %v", err)
+			}
+
+			images := pdfImages(t, output)
+			if len(images) != 1 {
+				t.Fatalf("Expected 1 image, got %d", len(images))
+			}
+			img := images[0]
+			if img.Cs != tt.wantCS || img.Bpc != tt.wantBPC || img.Filter != tt.wantFilter {
+				t.Errorf("Image = %s/%d bpc/%s; want %s/%d bpc/%s",
+					img.Cs, img.Bpc, img.Filter, tt.wantCS, tt.wantBPC, tt.wantFilter)
+			}
+			// В сером и чёрно-белом режимах маска не сохраняется
+			if wantSMask := tt.mode == ColorKeep; img.HasSMask != wantSMask {
+				t.Errorf("HasSMask = %v; want %v", img.HasSMask, wantSMask)
+			}
+			if got := converter.Report().Pages[0].Encoding; got != tt.wantEncoding {
+				t.Errorf("Report encoding = %q; want %q", got, tt.wantEncoding)
+			}
+		})
+	}
+}
+
+func TestOptionsValidate_ColorMode(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Options)
+	}{
+		{"unknown mode", func(o *Options) { o.Color = "sepia" }},
+		{"threshold too high", func(o *Options) { o.Color = ColorBW; o.Threshold = 256 }},
+		{"negative threshold", func(o *Options) { o.Color = ColorBW; o.Threshold = -1 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			tt.modify(&opts)
+			if err := opts.Validate(); !IsInvalidInput(err) {
+				t.Errorf("Expected invalid input error, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+)
+
+// Политики сжатия изображений (-compression)
+const (
+	// CompressionKeep вставляет JPEG как есть, остальное сжимает без потерь
+	CompressionKeep = "keep"
+	// CompressionFlate сжимает без потерь всё, что нельзя вставить как есть
+	CompressionFlate = "flate"
+	// CompressionJPEG перекодирует в JPEG всё, что нельзя вставить как есть
+	CompressionJPEG = "jpeg"
+	// CompressionAuto выбирает JPEG для фотографий и Flate для графики
+	CompressionAuto = "auto"
+)
+
+// Решения по сжатию, попадающие в отчёт
+const (
+	EncodingPassthrough = "jpeg-passthrough"
+	EncodingJPEG        = "jpeg"
+	EncodingFlate       = "flate"
+	// EncodingVector — рисунок SVG, записанный операторами PDF без растеризации
+	EncodingVector = "vector"
+)
+
+// photoColorThreshold — число различных цветов в выборке, начиная с которого
+// изображение считается фотографией
+const photoColorThreshold = 1024
+
+// defaultQuality — качество JPEG, когда Quality не задано
+const defaultQuality = 90
+
+// photoSampleLimit — сколько пикселей просматривается при оценке содержимого
+const photoSampleLimit = 1 << 16
+
+var compressionPolicies = map[string]bool{
+	CompressionKeep:  true,
+	CompressionFlate: true,
+	CompressionJPEG:  true,
+	CompressionAuto:  true,
+}
+
+// encodingDecision описывает, как изображение было записано в PDF
+type encodingDecision struct {
+	Encoding string
+	Quality  int
+	Reason   string
+}
+
+// chooseEncoding выбирает способ записи изображения по политике сжатия
+func (o Options) chooseEncoding(src *sourceImage, img image.Image, modified bool) encodingDecision {
+	if o.Color == ColorBW {
+		return encodingDecision{Encoding: EncodingCCITT, Reason: "bilevel page"}
+	}
+	// Факс остаётся однобитным, пока поворот или обрезка не добавили полутонов
+	if src.Bilevel && pureBilevel(img) {
+		return encodingDecision{Encoding: EncodingCCITT, Reason: "bilevel source"}
+	}
+
+	if src.Format == "jpeg" && !modified {
+		return encodingDecision{Encoding: EncodingPassthrough, Reason: "original JPEG bytes"}
+	}
+
+	switch o.Compression {
+	case CompressionFlate:
+		return encodingDecision{Encoding: EncodingFlate, Reason: "lossless policy"}
+	case CompressionJPEG:
+		return encodingDecision{Encoding: EncodingJPEG, Quality: o.jpegQuality(), Reason: "lossy policy"}
+	case CompressionAuto:
+		n := countColors(img, photoColorThreshold)
+		if n >= photoColorThreshold {
+			return encodingDecision{Encoding: EncodingJPEG, Quality: o.jpegQuality(), Reason: "photographic content"}
+		}
+		return encodingDecision{Encoding: EncodingFlate, Reason: fmt.Sprintf("graphics, %d colors", n)}
+	}
+
+	if src.Format == "jpeg" {
+		return encodingDecision{Encoding: EncodingJPEG, Quality: o.jpegQuality(), Reason: "modified JPEG"}
+	}
+	return encodingDecision{Encoding: EncodingFlate, Reason: "lossless source"}
+}
+
+// encodeImage записывает изображение согласно принятому решению
+func (o Options) encodeImage(src *sourceImage, img image.Image, d encodingDecision) (*pdfImage, error) {
+	switch d.Encoding {
+	case EncodingCCITT:
+		return encodeBilevel(toGray(img)), nil
+	case EncodingPassthrough:
+		return jpegPassthrough(src.Raw)
+	case EncodingJPEG:
+		return encodeJPEG(img, d.Quality)
+	}
+
+	// Flate хранит и 16 бит на канал, уменьшает их только политика auto
+	if o.Compression == CompressionAuto {
+		img = reduceTo8Bit(img)
+	}
+	return encodeFlate(img), nil
+}
+
+// jpegQuality возвращает качество JPEG, нулевое значение означает качество по умолчанию
+func (o Options) jpegQuality() int {
+	if o.Quality == 0 {
+		return defaultQuality
+	}
+	return o.Quality
+}
+
+// pureBilevel сообщает, что изображение в оттенках серого содержит только чёрный и белый
+func pureBilevel(img image.Image) bool {
+	g, ok := img.(*image.Gray)
+	if !ok {
+		return false
+	}
+	b := g.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for _, v := range g.Pix[g.PixOffset(b.Min.X, y):g.PixOffset(b.Max.X, y)] {
+			if v != 0 && v != 0xff {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// countColors считает различные цвета на равномерной выборке пикселей, но не больше limit
+func countColors(img image.Image, limit int) int {
+	b := img.Bounds()
+	step := 1
+	for (b.Dx()/step)*(b.Dy()/step) > photoSampleLimit {
+		step++
+	}
+
+	seen := make(map[color.NRGBA]struct{}, limit)
+	for y := b.Min.Y; y < b.Max.Y; y += step {
+		for x := b.Min.X; x < b.Max.X; x += step {
+			seen[color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)] = struct{}{}
+			if len(seen) >= limit {
+				return len(seen)
+			}
+		}
+	}
+	return len(seen)
+}
+
+// reduceTo8Bit переводит 16-битные изображения в 8 бит на канал
+func reduceTo8Bit(img image.Image) image.Image {
+	switch img.(type) {
+	case *image.Gray16:
+		b := img.Bounds()
+		gray := image.NewGray(b)
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				gray.Set(x, y, img.At(x, y))
+			}
+		}
+		return gray
+	case *image.RGBA64, *image.NRGBA64:
+		b := img.Bounds()
+		nrgba := image.NewNRGBA(b)
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				nrgba.Set(x, y, img.At(x, y))
+			}
+		}
+		return nrgba
+	}
+	return img
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// createFlatPNG создаёт PNG с двумя цветами, как у скриншота с текстом
+func createFlatPNG(path string, width, height int) error {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			c := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+			if (x/10+y/10)%2 == 0 {
+				c = color.RGBA{R: 20, G: 20, B: 20, A: 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return png.Encode(file, img)
+}
+
+func TestChooseEncoding(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	photoJPG := filepath.Join(tmpDir, "photo.jpg")
+	photoPNG := filepath.Join(tmpDir, "photo.png")
+	flatPNG := filepath.Join(tmpDir, "flat.png")
+	if err := createTestJPG(photoJPG, 100, 100); err != nil {
+		t.Fatal(err)
+	}
+	if err := createTestImage(photoPNG, 100, 100, "png"); err != nil {
+		t.Fatal(err)
+	}
+	if err := createFlatPNG(flatPNG, 100, 100); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		policy   string
+		modified bool
+		want     string
+	}{
+		{"jpeg untouched", photoJPG, CompressionFlate, false, EncodingPassthrough},
+		{"jpeg resampled keep", photoJPG, CompressionKeep, true, EncodingJPEG},
+		{"jpeg resampled flate", photoJPG, CompressionFlate, true, EncodingFlate},
+		{"png keep", photoPNG, CompressionKeep, false, EncodingFlate},
+		{"png jpeg", photoPNG, CompressionJPEG, false, EncodingJPEG},
+		{"photo png auto", photoPNG, CompressionAuto, false, EncodingJPEG},
+		{"flat png auto", flatPNG, CompressionAuto, false, EncodingFlate},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src, err := loadImage(tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			opts := DefaultOptions()
+			opts.Compression = tt.policy
+
+			d := opts.chooseEncoding(src, src.Image, tt.modified)
+			if d.Encoding != tt.want {
+				t.Errorf("chooseEncoding = %s (%s); want %s", d.Encoding, d.Reason, tt.want)
+			}
+			if d.Reason == "" {
+				t.Error("Decision should carry a reason")
+			}
+		})
+	}
+}
+
+func TestEncodeImage_ReducesSixteenBit(t *testing.T) {
+	img := image.NewGray16(image.Rect(0, 0, 8, 8))
+	src := &sourceImage{Image: img, Format: "png"}
+
+	opts := DefaultOptions()
+	opts.Compression = CompressionKeep
+	kept, err := opts.encodeImage(src, img, encodingDecision{Encoding: EncodingFlate})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kept.BitsPerComponent != 16 {
+		t.Errorf("keep policy: expected 16 bits, got %d", kept.BitsPerComponent)
+	}
+
+	opts.Compression = CompressionFlate
+	lossless, err := opts.encodeImage(src, img, encodingDecision{Encoding: EncodingFlate})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lossless.BitsPerComponent != 16 {
+		t.Errorf("flate policy: expected 16 bits, got %d", lossless.BitsPerComponent)
+	}
+
+	opts.Compression = CompressionAuto
+	reduced, err := opts.encodeImage(src, img, encodingDecision{Encoding: EncodingFlate})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reduced.BitsPerComponent != 8 {
+		t.Errorf("auto policy: expected 8 bits, got %d", reduced.BitsPerComponent)
+	}
+}
+
+func TestOptions_ZeroCompressionDefaults(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Compression = ""
+	opts.Quality = 0
+	if err := opts.Validate(); err != nil {
+		t.Fatalf("zero compression options should be valid: %v", err)
+	}
+
+	src := &sourceImage{Format: "jpeg"}
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	d := opts.chooseEncoding(src, img, true)
+	if d.Encoding != EncodingJPEG || d.Quality != defaultQuality {
+		t.Errorf("expected keep policy with quality %d, got %+v", defaultQuality, d)
+	}
+}
+
+func TestConvert_DryRunWritesReportOnly(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	photo := filepath.Join(tmpDir, "photo.jpg")
+	flat := filepath.Join(tmpDir, "flat.png")
+	if err := createTestJPG(photo, 60, 40); err != nil {
+		t.Fatal(err)
+	}
+	if err := createFlatPNG(flat, 60, 40); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := DefaultOptions()
+	opts.Compression = CompressionAuto
+	opts.DryRun = true
+	opts.ReportPath = filepath.Join(tmpDir, "report.json")
+
+	output := filepath.Join(tmpDir, "out.pdf")
+	converter := NewConverterWithOptions(opts)
+	if err := converter.Convert(photo+","+flat, output, "seq"); err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+
+	if _, err := os.Stat(output); err == nil {
+		t.Error("PDF should not be written in dry-run mode")
+	}
+
+	data, err := os.ReadFile(opts.ReportPath)
+	if err != nil {
+		t.Fatalf("Report not written: %v", err)
+	}
+
+	var report Report
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatalf("Invalid JSON report: %v", err)
+	}
+
+	if !report.DryRun || len(report.Pages) != 2 {
+		t.Fatalf("Unexpected report: %+v", report)
+	}
+	if report.Pages[0].Encoding != EncodingPassthrough {
+		t.Errorf("Page 1: expected %s, got %s", EncodingPassthrough, report.Pages[0].Encoding)
+	}
+	if report.Pages[1].Encoding != EncodingFlate {
+		t.Errorf("Page 2: expected %s, got %s", EncodingFlate, report.Pages[1].Encoding)
+	}
+	for _, p := range report.Pages {
+		if p.Bytes <= 0 {
+			t.Errorf("Page %d: expected positive size, got %d", p.Page, p.Bytes)
+		}
+	}
+
+	if converter.Report() == nil || len(converter.Report().Pages) != 2 {
+		t.Error("Converter should keep the last report")
+	}
+}
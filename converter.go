@@ -2,25 +2,54 @@ package main
 
 import (
 	"fmt"
+	"image"
+	"image/color"
 	"io/fs"
+	"math"
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
-	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
 )
 
 type ImageInfo struct {
 	Path    string
 	ModTime time.Time
+	// Blank — страница без изображения: добивка брошюры или разделитель
+	Blank bool
+	// Color — заливка страницы Blank, nil — страница остаётся пустой
+	Color *color.NRGBA
+	// Group — входная группа: элемент -i или каталог с изображениями
+	Group string
+	// Frame — номер кадра многостраничного файла, начиная с 1; 0 — файл из одного кадра
+	Frame int
 }
 
-type Converter struct{}
+type Converter struct {
+	opts       Options
+	rules      []pageRule
+	separators *separatorPlan
+	labels     pageLabels
+	grid       nupGrid
+	// lastPage — размер последней страницы, по нему строятся пустые страницы в режиме auto
+	lastPage *types.Dim
+	// gif — последний декодированный анимированный GIF, кадры которого идут подряд
+	gif *gifAnimation
+	// firstTaken и lastTaken — диапазон времени изображений для дат документа
+	firstTaken, lastTaken time.Time
+	report                *Report
+}
 
 func NewConverter() *Converter {
-	return &Converter{}
+	return NewConverterWithOptions(DefaultOptions())
+}
+
+func NewConverterWithOptions(opts Options) *Converter {
+	return &Converter{opts: opts}
 }
 
 func (c *Converter) Convert(input, output, order string) error {
@@ -28,6 +57,34 @@ func (c *Converter) Convert(input, output, order string) error {
 		return ErrInvalidInput
 	}
 
+	if err := c.opts.Validate(); err != nil {
+		return err
+	}
+	// Кадры GIF нужны только на время конвертации, а файл может измениться к следующей
+	defer func() { c.gif = nil }()
+
+	manifest, err := c.opts.loadManifest()
+	if err != nil {
+		return err
+	}
+	if c.rules, err = c.opts.pageRules(manifest); err != nil {
+		return err
+	}
+	if c.separators, err = c.separatorPlan(manifest.Separators); err != nil {
+		return err
+	}
+
+	if c.labels, err = c.opts.pageLabels(manifest); err != nil {
+		return err
+	}
+
+	if c.grid, err = parseNup(c.opts.Nup); err != nil {
+		return err
+	}
+	if c.opts.Booklet {
+		c.grid = bookletGrid
+	}
+
 	images := c.collectImages(input)
 
 	if len(images) == 0 {
@@ -37,11 +94,17 @@ func (c *Converter) Convert(input, output, order string) error {
 	return c.createPDF(images, output, order)
 }
 
+// Report возвращает отчёт о последней конвертации
+func (c *Converter) Report() *Report {
+	return c.report
+}
+
 func (c *Converter) collectImages(input string) []ImageInfo {
 	var images []ImageInfo
 
 	files := strings.SplitSeq(input, ",")
 
+	entry := 0
 	for file := range files {
 		file = strings.TrimSpace(file)
 
@@ -49,13 +112,19 @@ func (c *Converter) collectImages(input string) []ImageInfo {
 		if file == "" {
 			continue
 		}
+		entry++
 
+		file, frames := splitFrameSpec(file)
 		if isDirectory(file) {
 			imagesFromDir, err := c.collectFromDirectory(file)
 			if err != nil {
 				fmt.Printf("Warning: skipping %s: %v\n", file, err)
 				continue
 			} else {
+				// Каждый каталог внутри элемента -i — отдельная группа
+				for i := range imagesFromDir {
+					imagesFromDir[i].Group = fmt.Sprintf("%d:%s", entry, filepath.Dir(imagesFromDir[i].Path))
+				}
 				images = append(images, imagesFromDir...)
 			}
 			continue
@@ -79,7 +148,13 @@ func (c *Converter) collectImages(input string) []ImageInfo {
 			}
 			continue
 		}
-		images = append(images, info)
+		info.Group = strconv.Itoa(entry)
+		expanded, err := c.expandFrames(info, frames)
+		if err != nil {
+			fmt.Printf("Warning: skipping %s: %v\n", file, err)
+			continue
+		}
+		images = append(images, expanded...)
 	}
 
 	return images
@@ -105,8 +180,13 @@ func (c *Converter) collectFromDirectory(dir string) ([]ImageInfo, error) {
 			fmt.Printf("Warning: skipping %s: %v\n", path, err)
 			return nil
 		}
+		expanded, err := c.expandFrames(info, "")
+		if err != nil {
+			fmt.Printf("Warning: skipping %s: %v\n", path, err)
+			return nil
+		}
 
-		images = append(images, info)
+		images = append(images, expanded...)
 		return nil
 	})
 
@@ -126,31 +206,517 @@ func (c *Converter) getImageInfo(path string) (ImageInfo, error) {
 }
 
 func (c *Converter) createPDF(images []ImageInfo, output string, order string) error {
+	c.report = &Report{Output: output, DryRun: c.opts.DryRun}
+	c.lastPage = nil
+	c.firstTaken, c.lastTaken = time.Time{}, time.Time{}
+
 	switch order {
 	case "mod":
-		sort.Slice(images, func(i, j int) bool {
+		// Кадры одного файла сохраняют свой порядок
+		sort.SliceStable(images, func(i, j int) bool {
 			return images[i].ModTime.Before(images[j].ModTime)
 		})
 	case "nam":
-		sort.Slice(images, func(i, j int) bool {
-			return filepath.Base(images[i].Path) < filepath.Base(images[j].Path)
+		sort.SliceStable(images, func(i, j int) bool {
+			a, b := filepath.Base(images[i].Path), filepath.Base(images[j].Path)
+			if a == b {
+				return images[i].Frame < images[j].Frame
+			}
+			return a < b
 		})
+	case "duplex":
+		interleaved, err := interleaveDuplex(images)
+		if err != nil {
+			return err
+		}
+		images = interleaved
+	}
+
+	// Пустые обороты убираются после восстановления порядка duplex, которому нужны все сканы
+	if c.opts.RemoveBlank {
+		kept, err := c.removeBlank(images)
+		if err != nil {
+			if IsNoImagesFound(err) {
+				return err
+			}
+			return &ConversionError{Output: output, Reason: err.Error()}
+		}
+		images = kept
+	}
+
+	plan := c.separators
+	if c.opts.Booklet {
+		// Брошюре нужен окончательный порядок страниц до вывода
+		images = imposeBooklet(plan.apply(images))
+		plan = &separatorPlan{}
+	}
+
+	w, err := newPDFWriter()
+	if err != nil {
+		return &ConversionError{Output: output, Reason: err.Error()}
+	}
+
+	marks := c.newOutline(images)
+
+	var sheet *nupSheet
+	if c.grid.Cols > 0 {
+		if sheet, err = c.newSheet(); err != nil {
+			return &ConversionError{Output: output, Reason: err.Error()}
+		}
+	}
+
+	// Разделитель занимает отдельную страницу или ячейку сетки
+	addBlank := func(info ImageInfo) error {
+		plan.place(false)
+		if sheet != nil {
+			return c.addBlankCell(w, sheet, info)
+		}
+		return c.addBlankPage(w, info)
 	}
 
-	imagePaths := make([]string, len(images))
 	for i, img := range images {
-		imagePaths[i] = img.Path
+		if img.Blank {
+			if err := addBlank(img); err != nil {
+				return &ConversionError{Output: output, Reason: err.Error()}
+			}
+			continue
+		}
+		parts, err := c.imageParts(img, i+1)
+		if err != nil {
+			return &ConversionError{Output: output, Reason: err.Error()}
+		}
+		for j, pp := range parts {
+			for _, sep := range plan.before(img, i+1, j == 0) {
+				if err := addBlank(sep); err != nil {
+					return &ConversionError{Output: output, Reason: err.Error()}
+				}
+			}
+			if sheet != nil {
+				err = c.addCell(w, sheet, pp)
+			} else {
+				err = c.addPartPage(w, pp)
+			}
+			if err != nil {
+				return &ConversionError{Output: output, Reason: err.Error()}
+			}
+			if j == 0 {
+				// Лист сетки дописывается позже, номер страницы берётся из отчёта
+				marks.add(img, i+1, c.report.Pages[len(c.report.Pages)-1].Page)
+			}
+			plan.place(true)
+		}
+	}
+	for _, sep := range plan.end(len(images)) {
+		if err := addBlank(sep); err != nil {
+			return &ConversionError{Output: output, Reason: err.Error()}
+		}
+	}
+	if sheet != nil {
+		if err := c.flushSheet(w, sheet); err != nil {
+			return &ConversionError{Output: output, Reason: err.Error()}
+		}
+	}
+
+	if len(c.labels) > 0 {
+		w.setPageLabels(c.labels)
+		for i := range c.report.Pages {
+			c.report.Pages[i].Label = c.labels.label(c.report.Pages[i].Page)
+		}
+	}
+	if err := c.watermarkPages(w); err != nil {
+		return &ConversionError{Output: output, Reason: err.Error()}
+	}
+	if err := c.stampPages(w); err != nil {
+		return &ConversionError{Output: output, Reason: err.Error()}
+	}
+	if err := w.setOutline(marks.items); err != nil {
+		return &ConversionError{Output: output, Reason: err.Error()}
 	}
 
-	if err := api.ImportImagesFile(imagePaths, output, nil, nil); err != nil {
-		return &ConversionError{
-			Output: output,
-			Reason: err.Error(),
+	if c.opts.hasMetadata() {
+		meta, err := c.opts.docInfo(c.firstTaken, c.lastTaken)
+		if err != nil {
+			return err
+		}
+		if err := w.setMetadata(meta); err != nil {
+			return &ConversionError{Output: output, Reason: err.Error()}
+		}
+		if c.opts.PDFA != "" {
+			if err := w.setOutputIntent(); err != nil {
+				return &ConversionError{Output: output, Reason: err.Error()}
+			}
+			w.setFileID(output, meta)
+		}
+	}
+
+	if w.encrypt, err = c.opts.encryption(); err != nil {
+		return err
+	}
+
+	if !c.opts.DryRun {
+		if err := w.save(output); err != nil {
+			return &ConversionError{
+				Output: output,
+				Reason: err.Error(),
+			}
+		}
+	}
+	if c.opts.PDFA != "" {
+		if err := c.checkPDFA(w, output); err != nil {
+			return &ConversionError{Output: output, Reason: err.Error()}
+		}
+	}
+
+	if c.opts.ReportPath != "" {
+		if err := c.report.WriteJSON(c.opts.ReportPath); err != nil {
+			return &ConversionError{Output: output, Reason: err.Error()}
 		}
 	}
 	return nil
 }
 
+// interleaveDuplex восстанавливает порядок страниц двустороннего скана: сначала идут
+// все лицевые стороны, затем все обратные в обратном порядке
+func interleaveDuplex(images []ImageInfo) ([]ImageInfo, error) {
+	if len(images)%2 != 0 {
+		return nil, fmt.Errorf("%w: duplex order needs as many backs as fronts, got %d images", ErrInvalidInput, len(images))
+	}
+
+	half := len(images) / 2
+	result := make([]ImageInfo, 0, len(images))
+	for i := 0; i < half; i++ {
+		result = append(result, images[i], images[len(images)-1-i])
+	}
+	return result, nil
+}
+
+// pageSource — изображение, подготовленное к раскладке на одну или несколько страниц
+type pageSource struct {
+	info  ImageInfo
+	index int
+	src   *sourceImage
+	// base — изображение после поворота пикселей, в координатах исходного файла
+	base    image.Image
+	exif    orientation
+	orient  orientation
+	auto    bool
+	skew    float64
+	rotated bool
+	// taken — время съёмки из EXIF или время изменения файла
+	taken time.Time
+	// shared — встроенный целиком JPEG, общий для всех страниц из этого изображения
+	shared *types.IndirectRef
+	// vector — рисунок SVG, который встраивается векторами вместо пикселей
+	vector *svgDocument
+}
+
+// preparedPart — часть изображения после обрезки, готовая к размещению
+type preparedPart struct {
+	*pageSource
+	part spreadPart
+	crop image.Rectangle
+	// orient уточняется при размещении, если поворот задан как auto
+	orient orientation
+}
+
+// imageParts декодирует изображение, поворачивает его, при необходимости делит разворот
+// и обрезает части. index — номер изображения во входном списке, начиная с 1.
+func (c *Converter) imageParts(info ImageInfo, index int) ([]preparedPart, error) {
+	if isSVG(info.Path) {
+		return c.vectorParts(info, index)
+	}
+
+	src, err := c.loadFrame(info)
+	if err != nil {
+		return nil, err
+	}
+
+	rule := ruleFor(c.rules, info.Path, index)
+	exif := exifOrientations[exifOrientation(exifData(src.Raw, src.Format))]
+	orient, angle, auto, err := pageOrientation(exif, rule)
+	if err != nil {
+		return nil, &ImageError{Path: info.source(), Reason: err.Error()}
+	}
+
+	// Наклон и поворот на произвольный угол выполняются над пикселями до обрезки,
+	// чтобы поля скана стали прямыми. Наклон оценивается на правильно повёрнутой странице.
+	base := src.Image
+	skew := 0.0
+	if c.opts.Deskew {
+		skew = estimateSkew(orientGray(toGray(base), orient), c.opts.MaxSkew)
+		if math.Abs(skew) < deskewMinAngle {
+			skew = 0
+		}
+	}
+	turn := skew - angle
+	if turn != 0 {
+		if orient.Flip {
+			// Пиксели поворачиваются до отражения, поэтому направление меняется
+			turn = -turn
+		}
+		// Явный поворот не обрезает углы изображения, исправление наклона сохраняет размер скана
+		base = rotateImage(base, turn, c.opts.Resample, angle != 0)
+	}
+
+	ps := &pageSource{
+		info:    info,
+		index:   index,
+		src:     src,
+		base:    base,
+		exif:    exif,
+		orient:  orient,
+		auto:    auto,
+		skew:    skew,
+		rotated: turn != 0,
+		taken:   info.ModTime,
+	}
+	if date, ok := exifDate(exifData(src.Raw, src.Format)); ok {
+		ps.taken = date
+	}
+	c.noteTaken(ps.taken)
+
+	parts := []spreadPart{{Rect: base.Bounds()}}
+	if c.opts.Split == SplitSpread {
+		parts = splitSpread(base, orient, c.opts.RTL)
+	}
+
+	prepared := make([]preparedPart, 0, len(parts))
+	for _, part := range parts {
+		crop, err := c.opts.cropRect(cropImage(base, part.Rect), index, exif)
+		if err != nil {
+			return nil, &ImageError{Path: info.source(), Reason: err.Error()}
+		}
+		prepared = append(prepared, preparedPart{pageSource: ps, part: part, crop: crop, orient: orient})
+	}
+	return prepared, nil
+}
+
+// noteTaken расширяет диапазон дат съёмки документа
+func (c *Converter) noteTaken(t time.Time) {
+	if c.firstTaken.IsZero() || t.Before(c.firstTaken) {
+		c.firstTaken = t
+	}
+	if t.After(c.lastTaken) {
+		c.lastTaken = t
+	}
+}
+
+// fitTo доворачивает часть с поворотом auto под ориентацию области dim
+func (pp *preparedPart) fitTo(dim *types.Dim) {
+	if pp.auto {
+		pp.orient = pp.orient.fitOrientation(pp.crop.Dx(), pp.crop.Dy(), dim)
+	}
+}
+
+// displaySize возвращает размер части после поворота
+func (pp *preparedPart) displaySize() (int, int) {
+	if pp.orient.swapsSides() {
+		return pp.crop.Dy(), pp.crop.Dx()
+	}
+	return pp.crop.Dx(), pp.crop.Dy()
+}
+
+// cropInfo описывает обрезку для отчёта
+func (pp *preparedPart) cropInfo() string {
+	b := pp.base.Bounds()
+	if pp.crop == b {
+		return ""
+	}
+	return fmt.Sprintf("%dx%d+%d+%d", pp.crop.Dx(), pp.crop.Dy(), pp.crop.Min.X-b.Min.X, pp.crop.Min.Y-b.Min.Y)
+}
+
+// renderedImage — изображение части, встроенное в документ
+type renderedImage struct {
+	ref       types.IndirectRef
+	encoded   *pdfImage
+	decision  encodingDecision
+	alpha     string
+	resampled bool
+	// size — размер сжатых данных, 0 для повторно использованного изображения
+	size int
+}
+
+// renderPart обрезает, уменьшает до tw×th и кодирует часть и добавляет её в документ.
+// С lossless JPEG встраивается целиком, а обрезку выполняет CropBox страницы.
+func (c *Converter) renderPart(w *pdfWriter, pp *preparedPart, tw, th int, lossless bool) (*renderedImage, error) {
+	if pp.vector != nil {
+		return c.renderVector(w, pp)
+	}
+
+	cropped := pp.crop != pp.base.Bounds()
+	resampled := tw != pp.crop.Dx() || th != pp.crop.Dy()
+
+	img := pp.base
+	if cropped && !lossless {
+		img = cropImage(img, pp.crop)
+	}
+	if resampled {
+		img = resampleImage(img, tw, th, c.opts.Resample)
+	}
+	modified := pp.rotated || resampled || (cropped && !lossless)
+
+	alpha := ""
+	if !isOpaque(img) {
+		bg, flatten, err := c.opts.alphaBackground()
+		if err != nil {
+			return nil, err
+		}
+		if flatten {
+			img = flattenAlpha(img, bg)
+			modified = true
+			alpha = "flattened onto " + hexColor(bg)
+		}
+	}
+
+	img, colorChanged := c.opts.applyColorMode(img)
+	modified = modified || colorChanged
+
+	decision := c.opts.chooseEncoding(pp.src, img, modified)
+	encoded, err := c.opts.encodeImage(pp.src, img, decision)
+	if err != nil {
+		return nil, &ImageError{Path: pp.info.source(), Reason: err.Error()}
+	}
+
+	encoded.ICC = pp.src.ICC
+
+	if alpha == "" && encoded.SMask != nil {
+		alpha = "smask"
+	}
+
+	r := &renderedImage{encoded: encoded, decision: decision, alpha: alpha, resampled: resampled}
+
+	// Половины разворота, обрезанные через CropBox, ссылаются на один и тот же JPEG
+	if lossless && pp.shared != nil {
+		r.ref = *pp.shared
+		return r, nil
+	}
+	if r.ref, err = w.addImage(encoded); err != nil {
+		return nil, &ImageError{Path: pp.info.source(), Reason: err.Error()}
+	}
+	if lossless {
+		pp.shared = &r.ref
+	}
+	r.size = w.imageSize(r.ref)
+	return r, nil
+}
+
+// pageReport собирает запись отчёта о части, размещённой на странице page
+func (pp *preparedPart) pageReport(page int, r *renderedImage) PageReport {
+	return PageReport{
+		Page:      page,
+		Source:    pp.info.source(),
+		Part:      pp.part.Name,
+		Width:     r.encoded.Width,
+		Height:    r.encoded.Height,
+		Rotate:    pp.orient.Rotate,
+		Flip:      pp.orient.Flip,
+		Crop:      pp.cropInfo(),
+		Deskew:    pp.skew,
+		Resampled: r.resampled,
+		Encoding:  r.decision.Encoding,
+		Quality:   r.decision.Quality,
+		Reason:    r.decision.Reason,
+		Alpha:     r.alpha,
+		Bytes:     r.size,
+		taken:     pp.taken,
+	}
+}
+
+// addPartPage размещает часть изображения на отдельной странице
+func (c *Converter) addPartPage(w *pdfWriter, pp preparedPart) error {
+	dim, err := c.opts.pageDim()
+	if err != nil {
+		return err
+	}
+	pp.fitTo(dim)
+	orient, crop := pp.orient, pp.crop
+	cw, ch := crop.Dx(), crop.Dy()
+
+	// Страница хранится без поворота, /Rotate разворачивает её при показе
+	pageW, pageH, err := c.opts.pageSizeFor(pp.displaySize())
+	if err != nil {
+		return err
+	}
+	if orient.swapsSides() {
+		pageW, pageH = pageH, pageW
+	}
+	place := fitRect(c.opts.contentBox(pageW, pageH), float64(cw), float64(ch))
+
+	tw, th := targetSize(cw, ch, place.W, c.opts.DPI, c.opts.MaxDimension)
+	resampled := tw != cw || th != ch
+	cropped := crop != pp.base.Bounds()
+
+	// JPEG, который больше ничем не меняется, обрезается через CropBox без перекодирования
+	lossless := cropped && !resampled && !pp.rotated && pp.src.Format == "jpeg" && c.opts.Color == ColorKeep
+
+	r, err := c.renderPart(w, &pp, tw, th, lossless)
+	if err != nil {
+		return err
+	}
+
+	page := pdfPage{
+		Width:    pageW,
+		Height:   pageH,
+		Rotate:   orient.Rotate,
+		Content:  []byte(drawImageOp("Im0", place, orient.Flip)),
+		XObjects: map[string]types.IndirectRef{"Im0": r.ref},
+	}
+
+	entry := pp.pageReport(w.ctx.PageCount+1, r)
+	if lossless {
+		entry.Crop += " (cropbox)"
+		full := uncroppedRect(place, pp.base.Bounds(), crop)
+		if orient.Flip {
+			full = full.mirror(place)
+		}
+		pageBox := rect{W: pageW, H: pageH}
+		if place != pageBox {
+			// Поля страницы не должны перекрываться срезанной частью изображения
+			page.Content = []byte(clipImageOp("Im0", full, place, orient.Flip))
+		}
+		mediaBox := pageBox.union(full)
+		page.MediaBox = &mediaBox
+	}
+	c.report.Pages = append(c.report.Pages, entry)
+
+	if orient.swapsSides() {
+		c.lastPage = &types.Dim{Width: pageH, Height: pageW}
+	} else {
+		c.lastPage = &types.Dim{Width: pageW, Height: pageH}
+	}
+	return w.addPage(page)
+}
+
+// addBlankPage добавляет страницу-разделитель. В режиме auto она повторяет размер
+// предыдущей страницы, а в начале документа — A4.
+func (c *Converter) addBlankPage(w *pdfWriter, info ImageInfo) error {
+	dim, err := c.opts.pageDim()
+	if err != nil {
+		return err
+	}
+	if dim == nil {
+		dim = c.lastPage
+	}
+	if dim == nil {
+		dim = types.PaperSize[sheetFallback]
+	}
+
+	page := pdfPage{Width: dim.Width, Height: dim.Height}
+	if info.Color != nil {
+		page.Content = []byte(fillRectOp(*info.Color, rect{W: dim.Width, H: dim.Height}))
+	}
+	c.report.Pages = append(c.report.Pages, PageReport{Page: w.ctx.PageCount + 1, Blank: true, Reason: blankReason(info)})
+	return w.addPage(page)
+}
+
+// blankReason описывает страницу без изображения для отчёта
+func blankReason(info ImageInfo) string {
+	if info.Color != nil {
+		return "separator " + hexColor(*info.Color)
+	}
+	return "blank page"
+}
+
 // TODO: А что если дадут dir и обычные файлы? как тогда?
 func isDirectory(path string) bool {
 	stat, err := os.Stat(path)
@@ -162,5 +728,6 @@ func isDirectory(path string) bool {
 
 func hasImageExtension(path string) bool {
 	ext := strings.ToLower(filepath.Ext(path))
-	return ext == ".jpg" || ext == ".jpeg" || ext == ".png" || ext == ".webp" || ext == ".tif" || ext == ".tiff"
+	return ext == ".jpg" || ext == ".jpeg" || ext == ".png" || ext == ".webp" || ext == ".tif" || ext == ".tiff" ||
+		ext == ".gif" || ext == ".bmp" || ext == ".svg"
 }
@@ -11,6 +11,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"sort"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -49,16 +50,21 @@ func createTestJPG(path string, width, height int) error {
 
 // createTestImage создает тестовое изображение в указанном формате
 func createTestImage(path string, width, height int, format string) error {
-	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	return createTestImageWithAlpha(path, width, height, format, 255)
+}
+
+// createTestImageWithAlpha создает тестовое изображение с одинаковой прозрачностью всех пикселей
+func createTestImageWithAlpha(path string, width, height int, format string, alpha uint8) error {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
 
 	// Заполняем изображение градиентом
 	for x := 0; x < width; x++ {
 		for y := 0; y < height; y++ {
-			c := color.RGBA{
+			c := color.NRGBA{
 				R: uint8((x * 255) / width),
 				G: uint8((y * 255) / height),
 				B: 100,
-				A: 255,
+				A: alpha,
 			}
 			img.Set(x, y, c)
 		}
@@ -78,9 +84,9 @@ func createTestImage(path string, width, height int, format string) error {
 	case "tiff", "tif":
 		return tiff.Encode(file, img, nil)
 	case "webp":
-		// Для webp можно использовать базовое кодирование
-		// В реальном проекте нужна библиотека для webp
-		return fmt.Errorf("webp encoding not implemented in test")
+		// WebP без потерь, см. encodeWebP
+		_, err := file.Write(encodeWebP(img))
+		return err
 	default:
 		return fmt.Errorf("unsupported format: %s", format)
 	}
@@ -135,8 +141,9 @@ func TestHasImageExtension(t *testing.T) {
 		{"image.tiff", true},
 		{"image.tif", true},
 		{"image.TIFF", true},
-		{"image.gif", false},
-		{"image.bmp", false},
+		{"image.gif", true},
+		{"image.BMP", true},
+		{"diagram.svg", true},
 		{"image.txt", false},
 		{"image", false},
 		{"", false},
@@ -348,6 +355,69 @@ func TestCreatePDF_OrderSequential(t *testing.T) {
 	}
 }
 
+func TestInterleaveDuplex(t *testing.T) {
+	var images []ImageInfo
+	for _, name := range []string{"1", "3", "5", "6", "4", "2"} {
+		images = append(images, ImageInfo{Path: name})
+	}
+
+	result, err := interleaveDuplex(images)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, img := range result {
+		if want := strconv.Itoa(i + 1); img.Path != want {
+			t.Errorf("Expected %s at position %d, got %s", want, i, img.Path)
+		}
+	}
+
+	if _, err := interleaveDuplex(images[:5]); !IsInvalidInput(err) {
+		t.Errorf("Expected invalid input error for an odd count, got %v", err)
+	}
+}
+
+func TestCreatePDF_OrderDuplex(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// Сканер выдаёт лицевые стороны, затем обратные в обратном порядке
+	names := []string{"scan1.jpg", "scan2.jpg", "scan3.jpg", "scan4.jpg"}
+	var paths []string
+	for _, name := range names {
+		path := filepath.Join(tmpDir, name)
+		if err := createTestJPG(path, 10, 10); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+
+	converter := NewConverter()
+	output := filepath.Join(tmpDir, "output_duplex.pdf")
+	if err := converter.Convert(tmpDir, output, "duplex"); err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+
+	expectedOrder := []string{"scan1.jpg", "scan4.jpg", "scan2.jpg", "scan3.jpg"}
+	pages := converter.Report().Pages
+	if len(pages) != len(expectedOrder) {
+		t.Fatalf("Pages = %d; want %d", len(pages), len(expectedOrder))
+	}
+	for i, page := range pages {
+		if filepath.Base(page.Source) != expectedOrder[i] {
+			t.Errorf("Expected %s at position %d, got %s", expectedOrder[i], i, filepath.Base(page.Source))
+		}
+	}
+
+	// Непарное число сканов — ошибка, PDF не создаётся
+	output = filepath.Join(tmpDir, "odd.pdf")
+	err := converter.Convert(strings.Join(paths[:3], ","), output, "duplex")
+	if !IsInvalidInput(err) {
+		t.Errorf("Expected invalid input error, got %v", err)
+	}
+	if _, err := os.Stat(output); !os.IsNotExist(err) {
+		t.Error("Output should not be created for mismatched duplex scans")
+	}
+}
+
 func TestCollectImagesWithGlob(t *testing.T) {
 	tmpDir, _ := createTestDirectory(t)
 	defer os.RemoveAll(tmpDir)
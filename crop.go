@@ -0,0 +1,238 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// cropNoise — доля пикселей строки, которые могут отличаться от фона (пыль, шум сканера).
+// Одиночный пиксель допускается всегда.
+const cropNoise = 0.002
+
+// cropSide — срезаемая с одной стороны величина в пикселях или процентах
+type cropSide struct {
+	Value   float64
+	Percent bool
+}
+
+// pixels переводит величину в пиксели для стороны длиной size
+func (s cropSide) pixels(size int) int {
+	if s.Percent {
+		return int(math.Round(s.Value * float64(size) / 100))
+	}
+	return int(s.Value)
+}
+
+// cropSpec — ручная обрезка для диапазона страниц First..Last (0 — для всех страниц).
+// Sides перечислены в порядке left, top, right, bottom.
+type cropSpec struct {
+	First, Last int
+	Sides       [4]cropSide
+}
+
+// parseCrop разбирает значение -crop: записи через ";" вида [pages:]left,top,right,bottom,
+// где pages — номер страницы или диапазон 2-5, а величины заданы в пикселях или процентах (10%).
+// Одна величина срезается со всех сторон.
+func parseCrop(s string) ([]cropSpec, error) {
+	var specs []cropSpec
+	for _, entry := range strings.Split(s, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		var spec cropSpec
+		if pages, sides, ok := strings.Cut(entry, ":"); ok {
+			first, last, err := parsePageRange(pages)
+			if err != nil {
+				return nil, fmt.Errorf("%w: invalid crop %q: %v", ErrInvalidInput, entry, err)
+			}
+			spec.First, spec.Last = first, last
+			entry = sides
+		}
+
+		values := strings.Split(entry, ",")
+		if len(values) != 1 && len(values) != 4 {
+			return nil, fmt.Errorf("%w: invalid crop %q: expected 1 or 4 values", ErrInvalidInput, entry)
+		}
+		for i := range spec.Sides {
+			v := strings.TrimSpace(values[i%len(values)])
+			side := cropSide{Percent: strings.HasSuffix(v, "%")}
+			n, err := strconv.ParseFloat(strings.TrimSuffix(v, "%"), 64)
+			if err != nil || n < 0 || (side.Percent && n >= 100) {
+				return nil, fmt.Errorf("%w: invalid crop value %q", ErrInvalidInput, v)
+			}
+			side.Value = n
+			spec.Sides[i] = side
+		}
+		specs = append(specs, spec)
+	}
+	return specs, nil
+}
+
+// parsePageRange разбирает номер страницы или диапазон вида 2-5
+func parsePageRange(s string) (int, int, error) {
+	from, to, isRange := strings.Cut(strings.TrimSpace(s), "-")
+	first, err := strconv.Atoi(strings.TrimSpace(from))
+	if err != nil || first < 1 {
+		return 0, 0, fmt.Errorf("invalid page %q", from)
+	}
+	if !isRange {
+		return first, first, nil
+	}
+	last, err := strconv.Atoi(strings.TrimSpace(to))
+	if err != nil || last < first {
+		return 0, 0, fmt.Errorf("invalid page range %q", s)
+	}
+	return first, last, nil
+}
+
+// cropRect возвращает область изображения, оставшуюся после ручной обрезки и -autocrop.
+// page — номер исходного изображения, начиная с 1. Стороны обрезки относятся к изображению
+// после преобразования orient (так его показывают просмотрщики с учётом EXIF).
+func (o Options) cropRect(img image.Image, page int, orient orientation) (image.Rectangle, error) {
+	r := img.Bounds()
+
+	specs, err := parseCrop(o.Crop)
+	if err != nil {
+		return r, err
+	}
+	// Более поздние записи переопределяют ранние, поэтому общая обрезка указывается первой
+	var spec *cropSpec
+	for i := range specs {
+		if specs[i].First == 0 || (page >= specs[i].First && page <= specs[i].Last) {
+			spec = &specs[i]
+		}
+	}
+	if spec != nil {
+		w, h := r.Dx(), r.Dy()
+		var sides [4]int
+		for i := range sides {
+			size := w
+			if i%2 == 1 {
+				size = h
+			}
+			sides[i] = spec.Sides[orient.displaySide(i)].pixels(size)
+		}
+		// image.Rect переставляет перепутанные координаты, поэтому пустую область проверяем заранее
+		cut := image.Rectangle{
+			Min: image.Pt(r.Min.X+sides[0], r.Min.Y+sides[1]),
+			Max: image.Pt(r.Max.X-sides[2], r.Max.Y-sides[3]),
+		}
+		if cut.Empty() {
+			return r, fmt.Errorf("crop leaves no pixels")
+		}
+		r = cut
+	}
+
+	if o.AutoCrop {
+		sub := cropImage(img, r)
+		r = autoCropRect(sub, o.CropTolerance, o.CropPadding).Sub(sub.Bounds().Min).Add(r.Min)
+	}
+	return r, nil
+}
+
+// cropImage вырезает область r без копирования, если тип изображения это позволяет
+func cropImage(img image.Image, r image.Rectangle) image.Image {
+	if r == img.Bounds() {
+		return img
+	}
+	if sub, ok := img.(interface {
+		SubImage(image.Rectangle) image.Image
+	}); ok {
+		return sub.SubImage(r)
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, r.Dx(), r.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, r.Min, draw.Src)
+	return dst
+}
+
+// autoCropRect находит содержимое внутри белых или чёрных полей скана.
+// Строка или столбец считаются полем, если почти все пиксели отличаются от цвета края
+// не больше чем на tolerance. Найденная область расширяется на padding пикселей.
+func autoCropRect(img image.Image, tolerance, padding int) image.Rectangle {
+	b := img.Bounds()
+	gray := toGray(img)
+	w, h := b.Dx(), b.Dy()
+
+	row := func(y int) []uint8 {
+		return gray.Pix[y*gray.Stride : y*gray.Stride+w]
+	}
+	col := func(x, top, bottom int) []uint8 {
+		vals := make([]uint8, 0, bottom-top)
+		for y := top; y < bottom; y++ {
+			vals = append(vals, gray.Pix[y*gray.Stride+x])
+		}
+		return vals
+	}
+
+	top, bottom := 0, h
+	if ref, ok := borderLevel(row(0), tolerance); ok {
+		for top < h && isBackground(row(top), ref, tolerance) {
+			top++
+		}
+	}
+	if top == h {
+		// Изображение целиком совпадает с фоном — обрезать нечего
+		return b
+	}
+	if ref, ok := borderLevel(row(h-1), tolerance); ok {
+		for bottom > top && isBackground(row(bottom-1), ref, tolerance) {
+			bottom--
+		}
+	}
+
+	left, right := 0, w
+	if ref, ok := borderLevel(col(0, top, bottom), tolerance); ok {
+		for left < w && isBackground(col(left, top, bottom), ref, tolerance) {
+			left++
+		}
+	}
+	if ref, ok := borderLevel(col(w-1, top, bottom), tolerance); ok {
+		for right > left && isBackground(col(right-1, top, bottom), ref, tolerance) {
+			right--
+		}
+	}
+	if left == right {
+		return b
+	}
+
+	r := image.Rect(left-padding, top-padding, right+padding, bottom+padding)
+	return r.Add(b.Min).Intersect(b)
+}
+
+// borderLevel возвращает медианную яркость крайней линии, если она близка к белому или чёрному
+func borderLevel(line []uint8, tolerance int) (int, bool) {
+	var hist [256]int
+	for _, v := range line {
+		hist[v]++
+	}
+	median, seen := 0, 0
+	for v, n := range hist {
+		seen += n
+		if seen*2 >= len(line) {
+			median = v
+			break
+		}
+	}
+	return median, median <= tolerance || median >= 255-tolerance
+}
+
+// isBackground сообщает, что линия почти целиком совпадает с цветом поля
+func isBackground(line []uint8, ref, tolerance int) bool {
+	limit := max(int(float64(len(line))*cropNoise), 1)
+	outliers := 0
+	for _, v := range line {
+		if d := int(v) - ref; d > tolerance || d < -tolerance {
+			outliers++
+			if outliers > limit {
+				return false
+			}
+		}
+	}
+	return true
+}
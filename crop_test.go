@@ -0,0 +1,222 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+)
+
+// createScan рисует тёмное содержимое content на странице цвета paper
+func createScan(path string, width, height int, paper color.Color, content image.Rectangle) error {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(paper), image.Point{}, draw.Src)
+	draw.Draw(img, content, image.NewUniform(color.RGBA{R: 90, G: 60, B: 40, A: 255}), image.Point{}, draw.Src)
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return png.Encode(file, img)
+}
+
+func TestParseCrop(t *testing.T) {
+	specs, err := parseCrop("10; 2-3: 5%,0,5%,10 ;4:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(specs) != 3 {
+		t.Fatalf("Expected 3 specs, got %d", len(specs))
+	}
+	if specs[0].First != 0 || specs[0].Sides[3] != (cropSide{Value: 10}) {
+		t.Errorf("Batch spec = %+v", specs[0])
+	}
+	if specs[1].First != 2 || specs[1].Last != 3 || specs[1].Sides[0] != (cropSide{Value: 5, Percent: true}) {
+		t.Errorf("Range spec = %+v", specs[1])
+	}
+
+	for _, bad := range []string{"1,2", "x", "-5", "100%", "0:10", "3-2:10", "a:10"} {
+		if _, err := parseCrop(bad); !IsInvalidInput(err) {
+			t.Errorf("parseCrop(%q) = %v; want invalid input error", bad, err)
+		}
+	}
+}
+
+func TestOptionsValidate_Crop(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Options)
+	}{
+		{"invalid crop", func(o *Options) { o.Crop = "1,2,3" }},
+		{"negative crop padding", func(o *Options) { o.CropPadding = -1 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			tt.modify(&opts)
+			if err := opts.Validate(); !IsInvalidInput(err) {
+				t.Errorf("Expected invalid input error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestCropRect_PerPage(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 200, 100))
+	opts := DefaultOptions()
+	opts.Crop = "10%;2:0,0,0,50"
+
+	tests := []struct {
+		page int
+		want image.Rectangle
+	}{
+		{1, image.Rect(20, 10, 180, 90)},
+		{2, image.Rect(0, 0, 200, 50)},
+		{3, image.Rect(20, 10, 180, 90)},
+	}
+	for _, tt := range tests {
+		got, err := opts.cropRect(img, tt.page, orientation{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("Page %d: cropRect = %v; want %v", tt.page, got, tt.want)
+		}
+	}
+
+	opts.Crop = "60%"
+	if _, err := opts.cropRect(img, 1, orientation{}); err == nil {
+		t.Error("Expected an error for a crop that leaves no pixels")
+	}
+}
+
+func TestAutoCropRect(t *testing.T) {
+	content := image.Rect(30, 20, 170, 110)
+
+	tests := []struct {
+		name    string
+		paper   color.Color
+		padding int
+		want    image.Rectangle
+	}{
+		{"white border", color.RGBA{R: 245, G: 245, B: 240, A: 255}, 0, content},
+		{"black border", color.RGBA{R: 10, G: 10, B: 10, A: 255}, 0, content},
+		{"padding", color.White, 5, content.Inset(-5)},
+		{"padding clamped", color.White, 50, image.Rect(0, 0, 200, 130)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img := image.NewRGBA(image.Rect(0, 0, 200, 130))
+			draw.Draw(img, img.Bounds(), image.NewUniform(tt.paper), image.Point{}, draw.Src)
+			draw.Draw(img, content, image.NewUniform(color.RGBA{R: 120, G: 100, B: 80, A: 255}), image.Point{}, draw.Src)
+			// Пылинка на поле не должна останавливать обрезку
+			img.Set(5, 5, color.Black)
+
+			if got := autoCropRect(img, 32, tt.padding); got != tt.want {
+				t.Errorf("autoCropRect = %v; want %v", got, tt.want)
+			}
+		})
+	}
+
+	// Фотография без полей не обрезается
+	photo := image.NewRGBA(image.Rect(0, 0, 50, 50))
+	draw.Draw(photo, photo.Bounds(), image.NewUniform(color.RGBA{R: 90, G: 140, B: 200, A: 255}), image.Point{}, draw.Src)
+	if got := autoCropRect(photo, 32, 0); got != photo.Bounds() {
+		t.Errorf("autoCropRect on a borderless image = %v", got)
+	}
+}
+
+func TestConvert_AutoCrop(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "scan.png")
+	if err := createScan(path, 300, 400, color.White, image.Rect(50, 60, 250, 340)); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := DefaultOptions()
+	opts.AutoCrop = true
+
+	output := filepath.Join(tmpDir, "out.pdf")
+	converter := NewConverterWithOptions(opts)
+	if err := converter.Convert(path, output, "seq"); err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+
+	images := pdfImages(t, output)
+	if len(images) != 1 || images[0].Width != 200 || images[0].Height != 280 {
+		t.Fatalf("Expected one 200x280 image, got %+v", images)
+	}
+
+	dims, err := api.PageDimsFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dims[0].Width != 200 || dims[0].Height != 280 {
+		t.Errorf("Expected 200x280 page, got %.0fx%.0f", dims[0].Width, dims[0].Height)
+	}
+	if got := converter.Report().Pages[0].Crop; got != "200x280+50+60" {
+		t.Errorf("Report crop = %q", got)
+	}
+}
+
+func TestConvert_CropJPEGUsesCropBox(t *testing.T) {
+	tmpDir := t.TempDir()
+	photo := filepath.Join(tmpDir, "photo.jpg")
+	if err := createTestJPG(photo, 400, 300); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name   string
+		margin float64
+	}{
+		{"no margin", 0},
+		{"margin", 20},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			opts.Crop = "100,50,0,50"
+			opts.Margin = tt.margin
+
+			output := filepath.Join(tmpDir, tt.name+".pdf")
+			converter := NewConverterWithOptions(opts)
+			if err := converter.Convert(photo, output, "seq"); err != nil {
+				t.Fatalf("Convert failed: %v", err)
+			}
+			if err := api.ValidateFile(output, nil); err != nil {
+				t.Fatalf("Invalid PDF: %v", err)
+			}
+
+			// JPEG встраивается целиком, без перекодирования
+			images := pdfImages(t, output)
+			if len(images) != 1 || images[0].Width != 400 || images[0].Height != 300 {
+				t.Fatalf("Expected the full 400x300 image, got %+v", images)
+			}
+			if got := converter.Report().Pages[0].Encoding; got != EncodingPassthrough {
+				t.Errorf("Encoding = %q; want %q", got, EncodingPassthrough)
+			}
+
+			ctx, err := api.ReadContextFile(output)
+			if err != nil {
+				t.Fatal(err)
+			}
+			boundaries, err := ctx.PageBoundaries(nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			crop := boundaries[0].CropBox()
+			wantW, wantH := 300+2*tt.margin, 200+2*tt.margin
+			if crop.Width() != wantW || crop.Height() != wantH {
+				t.Errorf("CropBox = %v; want %.0fx%.0f", crop, wantW, wantH)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"strings"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/math/f64"
+)
+
+const (
+	// deskewSampleWidth — ширина уменьшенной копии, по которой оценивается наклон
+	deskewSampleWidth = 1000
+	// deskewMinAngle — наклон меньше этого значения (в градусах) не исправляется
+	deskewMinAngle = 0.05
+	// deskewMinPoints — минимальное число тёмных пикселей для оценки наклона
+	deskewMinPoints = 100
+)
+
+// estimateSkew оценивает наклон строк текста в градусах методом проекционного профиля:
+// ищется угол, при котором гистограмма тёмных пикселей по строкам наиболее контрастна.
+// Положительный угол — строки опускаются слева направо (поворот по часовой стрелке).
+func estimateSkew(img image.Image, maxAngle float64) float64 {
+	b := img.Bounds()
+	if b.Dx() > deskewSampleWidth {
+		h := max(1, b.Dy()*deskewSampleWidth/b.Dx())
+		img = resampleImage(img, deskewSampleWidth, h, "bilinear")
+	}
+	gray := toGray(img)
+	w, h := gray.Bounds().Dx(), gray.Bounds().Dy()
+	level := otsuThreshold(gray)
+
+	var xs, ys []float64
+	for y := 0; y < h; y++ {
+		for x, v := range gray.Pix[y*gray.Stride : y*gray.Stride+w] {
+			if v <= level {
+				xs = append(xs, float64(x))
+				ys = append(ys, float64(y))
+			}
+		}
+	}
+	// Пустая или почти сплошь тёмная страница не даёт строк для оценки
+	if len(xs) < deskewMinPoints || len(xs) > w*h/2 {
+		return 0
+	}
+
+	bins := make([]float64, h+2*w+2)
+	score := func(angle float64) float64 {
+		clear(bins)
+		t := math.Tan(angle * math.Pi / 180)
+		for i := range xs {
+			// Сдвиг на w удерживает индекс в пределах при |t| <= 1
+			k := int(math.Round(ys[i]-xs[i]*t)) + w
+			bins[k]++
+		}
+		var sum float64
+		for _, n := range bins {
+			sum += n * n
+		}
+		return sum
+	}
+
+	search := func(from, to, step float64) float64 {
+		best, bestScore := 0.0, -1.0
+		for a := from; a <= to+step/2; a += step {
+			if s := score(a); s > bestScore {
+				best, bestScore = a, s
+			}
+		}
+		return best
+	}
+
+	coarse := search(-maxAngle, maxAngle, 0.5)
+	fine := search(math.Max(coarse-0.5, -maxAngle), math.Min(coarse+0.5, maxAngle), 0.05)
+	return math.Round(fine*100) / 100
+}
+
+// rotateImage поворачивает изображение вокруг центра против часовой стрелки на angle градусов.
+// С grow холст увеличивается до описанного прямоугольника, иначе размер сохраняется
+// и углы обрезаются, что незаметно при исправлении небольшого наклона. Открывшиеся
+// углы заливаются белым.
+func rotateImage(img image.Image, angle float64, filter string, grow bool) image.Image {
+	interp, ok := resampleFilters[strings.ToLower(filter)]
+	if !ok {
+		interp = draw.CatmullRom
+	}
+
+	b := img.Bounds()
+	sin, cos := math.Sincos(angle * math.Pi / 180)
+	w, h := b.Dx(), b.Dy()
+	if grow {
+		// Погрешность вычислений не должна добавлять лишний ряд пикселей
+		fw, fh := float64(w), float64(h)
+		w = int(math.Ceil(fw*math.Abs(cos) + fh*math.Abs(sin) - 1e-9))
+		h = int(math.Ceil(fw*math.Abs(sin) + fh*math.Abs(cos) - 1e-9))
+	}
+	dst := newImageLike(img, w, h)
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+
+	// Ось Y изображения направлена вниз, поэтому поворот против часовой стрелки
+	// записывается матрицей [cos sin; -sin cos]
+	cx := float64(b.Min.X) + float64(b.Dx())/2
+	cy := float64(b.Min.Y) + float64(b.Dy())/2
+	dx, dy := float64(w)/2, float64(h)/2
+	m := f64.Aff3{
+		cos, sin, dx - cos*cx - sin*cy,
+		-sin, cos, dy + sin*cx - cos*cy,
+	}
+
+	interp.Transform(dst, m, img, b, draw.Src, nil)
+	return dst
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// createSkewedScan рисует страницу со строками «текста», повёрнутую по часовой стрелке на angle градусов
+func createSkewedScan(path string, width, height int, angle float64) error {
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	sin, cos := math.Sincos(angle * math.Pi / 180)
+	cx, cy := float64(width)/2, float64(height)/2
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			// Координаты точки на ровной странице
+			px, py := float64(x)-cx, float64(y)-cy
+			qx := cos*px + sin*py
+			qy := -sin*px + cos*py
+
+			ink := math.Abs(qx) < float64(width)*0.35 && math.Abs(qy) < float64(height)*0.35 &&
+				math.Mod(qy+1000, 24) < 6 && math.Mod(qx+1000, 50) < 38
+			if !ink {
+				img.SetGray(x, y, color.Gray{Y: 0xff})
+			}
+		}
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return png.Encode(file, img)
+}
+
+func TestEstimateSkew(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	for _, angle := range []float64{-3, -1.2, 0, 0.7, 2.5} {
+		path := filepath.Join(tmpDir, "skewed.png")
+		if err := createSkewedScan(path, 600, 800, angle); err != nil {
+			t.Fatal(err)
+		}
+		src, err := loadImage(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := estimateSkew(src.Image, 5); math.Abs(got-angle) > 0.15 {
+			t.Errorf("estimateSkew for %.2f° = %.2f°", angle, got)
+		}
+	}
+}
+
+func TestEstimateSkew_MaxAngle(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "skewed.png")
+	if err := createSkewedScan(path, 600, 800, 8); err != nil {
+		t.Fatal(err)
+	}
+	src, err := loadImage(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := estimateSkew(src.Image, 3); math.Abs(got) > 3 {
+		t.Errorf("estimateSkew = %.2f°; want at most 3°", got)
+	}
+}
+
+func TestEstimateSkew_BlankPage(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 100, 100))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	if got := estimateSkew(img, 5); got != 0 {
+		t.Errorf("estimateSkew on a blank page = %.2f°", got)
+	}
+}
+
+func TestConvert_Deskew(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "skewed.png")
+	if err := createSkewedScan(path, 600, 800, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := DefaultOptions()
+	opts.Deskew = true
+
+	output := filepath.Join(tmpDir, "out.pdf")
+	converter := NewConverterWithOptions(opts)
+	if err := converter.Convert(path, output, "seq"); err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+
+	page := converter.Report().Pages[0]
+	if math.Abs(page.Deskew-2) > 0.15 {
+		t.Errorf("Report deskew = %.2f°; want about 2°", page.Deskew)
+	}
+	if page.Width != 600 || page.Height != 800 {
+		t.Errorf("Deskewed image is %dx%d; want 600x800", page.Width, page.Height)
+	}
+
+	// После поворота страница должна стать ровной
+	src, err := loadImage(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	straight := rotateImage(src.Image, page.Deskew, opts.Resample, false)
+	if got := estimateSkew(straight, 5); math.Abs(got) > 0.15 {
+		t.Errorf("Skew after rotation = %.2f°", got)
+	}
+}
+
+func TestRotateImage_KeepsDepth(t *testing.T) {
+	src := image.NewGray16(image.Rect(0, 0, 60, 40))
+	for i := 0; i < len(src.Pix); i += 2 {
+		// 0x1234 не представимо в 8 битах
+		src.Pix[i], src.Pix[i+1] = 0x12, 0x34
+	}
+	rotated := rotateImage(src, 1, "bilinear", false)
+	got, ok := rotated.(*image.Gray16)
+	if !ok {
+		t.Fatalf("Gray16 rotated to %T; want *image.Gray16", rotated)
+	}
+	// Открывшиеся углы белые, центр сохраняет 16-битное значение
+	if y := got.Gray16At(0, 0).Y; y != 0xffff {
+		t.Errorf("Corner = %#x; want white", y)
+	}
+	if y := got.Gray16At(30, 20).Y; y != 0x1234 {
+		t.Errorf("Center = %#x; want 0x1234", y)
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"crypto/rand"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+)
+
+// Алгоритмы шифрования (-encryption)
+const (
+	EncryptAES128 = "aes128"
+	EncryptAES256 = "aes256"
+)
+
+var encryptionKeyLengths = map[string]int{
+	EncryptAES128: 128,
+	EncryptAES256: 256,
+}
+
+// Права читателя, открывшего документ паролем пользователя (-permissions)
+var permissionFlags = map[string]model.PermissionFlags{
+	"print":    model.PermissionPrintRev2 | model.PermissionPrintRev3,
+	"copy":     model.PermissionExtract | model.PermissionExtractRev3,
+	"modify":   model.PermissionModify | model.PermissionAssembleRev3,
+	"annotate": model.PermissionModAnnFillForm | model.PermissionFillRev3,
+}
+
+// encryption — параметры шифрования документа с уже прочитанными паролями
+type encryption struct {
+	userPW, ownerPW string
+	keyLength       int
+	permissions     model.PermissionFlags
+}
+
+// readPassword возвращает пароль из значения флага: env:NAME — из переменной окружения,
+// file:PATH — из первой строки файла, pass:TEXT или просто TEXT — сам пароль.
+// Пароль, который сам начинается с env:, file: или pass:, записывается как pass:TEXT.
+func readPassword(spec string) (string, error) {
+	kind, value, ok := strings.Cut(spec, ":")
+	if !ok {
+		return spec, nil
+	}
+	switch kind {
+	case "env":
+		pw, found := os.LookupEnv(value)
+		if !found {
+			return "", fmt.Errorf("%w: password variable %s is not set", ErrInvalidInput, value)
+		}
+		return pw, nil
+	case "file":
+		data, err := os.ReadFile(value)
+		if err != nil {
+			return "", fmt.Errorf("%w: password file: %v", ErrInvalidInput, err)
+		}
+		line, _, _ := strings.Cut(string(data), "\n")
+		return strings.TrimSuffix(line, "\r"), nil
+	case "pass":
+		return value, nil
+	}
+	return spec, nil
+}
+
+// parsePermissions разбирает права через запятую: print, copy, modify, annotate,
+// all или none
+func parsePermissions(spec string) (model.PermissionFlags, error) {
+	flags := model.PermissionsNone
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch name {
+		case "", "none":
+			continue
+		case "all":
+			return model.PermissionsAll, nil
+		}
+		f, ok := permissionFlags[name]
+		if !ok {
+			return 0, fmt.Errorf("%w: unknown permission %q, expected print, copy, modify, annotate, all or none", ErrInvalidInput, name)
+		}
+		flags |= f
+	}
+	return flags, nil
+}
+
+// checkPasswordSpec проверяет запись пароля, не читая переменных окружения и файлов
+func checkPasswordSpec(spec string) error {
+	kind, value, _ := strings.Cut(spec, ":")
+	if (kind == "env" || kind == "file") && value == "" {
+		return fmt.Errorf("%w: password source %q needs a name, write pass:%s for a literal password", ErrInvalidInput, spec, spec)
+	}
+	return nil
+}
+
+// validateEncryption проверяет алгоритм, права и запись паролей. Сами пароли
+// читаются один раз, при записи документа.
+func (o Options) validateEncryption() error {
+	if _, ok := encryptionKeyLengths[strings.ToLower(o.Encryption)]; !ok {
+		return fmt.Errorf("%w: unknown encryption %q, expected aes128 or aes256", ErrInvalidInput, o.Encryption)
+	}
+	if _, err := parsePermissions(o.Permissions); err != nil {
+		return err
+	}
+	for _, spec := range []string{o.UserPassword, o.OwnerPassword} {
+		if err := checkPasswordSpec(spec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encryption читает пароли и собирает параметры шифрования. Без паролей возвращает nil.
+func (o Options) encryption() (*encryption, error) {
+	keyLength, ok := encryptionKeyLengths[strings.ToLower(o.Encryption)]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown encryption %q, expected aes128 or aes256", ErrInvalidInput, o.Encryption)
+	}
+	permissions, err := parsePermissions(o.Permissions)
+	if err != nil {
+		return nil, err
+	}
+	if o.UserPassword == "" && o.OwnerPassword == "" {
+		return nil, nil
+	}
+
+	e := &encryption{keyLength: keyLength, permissions: permissions}
+	if o.UserPassword != "" {
+		if e.userPW, err = readPassword(o.UserPassword); err != nil {
+			return nil, err
+		}
+	}
+	if o.OwnerPassword != "" {
+		if e.ownerPW, err = readPassword(o.OwnerPassword); err != nil {
+			return nil, err
+		}
+	}
+	if e.userPW == "" && e.ownerPW == "" {
+		return nil, fmt.Errorf("%w: empty password", ErrInvalidInput)
+	}
+	// С пустым паролем владельца любой читатель получил бы все права, а с паролем
+	// пользователя — его владелец. Случайный пароль не даёт снять ограничения никому.
+	if e.ownerPW == "" {
+		e.ownerPW = rand.Text()
+	}
+	return e, nil
+}
+
+// apply включает шифрование документа ctx при его записи
+func (e *encryption) apply(ctx *model.Context) {
+	ctx.Cmd = model.ENCRYPT
+	ctx.UserPW = e.userPW
+	ctx.OwnerPW = e.ownerPW
+	ctx.EncryptUsingAES = true
+	ctx.EncryptKeyLength = e.keyLength
+	ctx.Permissions = e.permissions
+	// AES-256 (/V 5 /R 6) определён только в PDF 2.0, в документе 1.7 его отвергает
+	// строгая проверка
+	if e.keyLength == 256 {
+		v := model.V20
+		ctx.HeaderVersion = &v
+	}
+	// pdfcpu рассчитывает на контексты чтения и оптимизации прочитанного файла,
+	// а документ собран в памяти
+	if ctx.Read == nil {
+		ctx.Read = &model.ReadContext{}
+		ctx.Optimize = &model.OptimizationContext{}
+	}
+}
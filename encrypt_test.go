@@ -0,0 +1,190 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+)
+
+func TestReadPassword(t *testing.T) {
+	tmpDir := t.TempDir()
+	file := filepath.Join(tmpDir, "pw.txt")
+	if err := os.WriteFile(file, []byte("from-file\r\nsecond line\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("IMG2PDF_TEST_PW", "from-env")
+
+	tests := []struct{ spec, want string }{
+		{"plain", "plain"},
+		{"pass:env:literal", "env:literal"},
+		{"env:IMG2PDF_TEST_PW", "from-env"},
+		{"file:" + file, "from-file"},
+		{"other:value", "other:value"},
+	}
+	for _, tt := range tests {
+		got, err := readPassword(tt.spec)
+		if err != nil || got != tt.want {
+			t.Errorf("readPassword(%q) = %q, %v; want %q", tt.spec, got, err, tt.want)
+		}
+	}
+
+	for _, spec := range []string{"env:IMG2PDF_TEST_MISSING", "file:" + filepath.Join(tmpDir, "missing")} {
+		if _, err := readPassword(spec); !IsInvalidInput(err) {
+			t.Errorf("readPassword(%q): expected ErrInvalidInput, got %v", spec, err)
+		}
+	}
+}
+
+func TestParsePermissions(t *testing.T) {
+	got, err := parsePermissions("print, copy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := model.PermissionsNone | model.PermissionPrintRev2 | model.PermissionPrintRev3 |
+		model.PermissionExtract | model.PermissionExtractRev3
+	if got != want {
+		t.Errorf("print,copy = %#x; want %#x", got, want)
+	}
+	if got, _ := parsePermissions("none"); got != model.PermissionsNone {
+		t.Errorf("none = %#x; want %#x", got, model.PermissionsNone)
+	}
+	if got, _ := parsePermissions("all"); got != model.PermissionsAll {
+		t.Errorf("all = %#x; want %#x", got, model.PermissionsAll)
+	}
+	if _, err := parsePermissions("print,delete"); !IsInvalidInput(err) {
+		t.Errorf("Unknown permission: expected ErrInvalidInput, got %v", err)
+	}
+}
+
+func TestConvert_Encrypted(t *testing.T) {
+	tmpDir := t.TempDir()
+	createTree(t, tmpDir, "1.jpg", "2.jpg")
+	ownerFile := filepath.Join(tmpDir, "owner.txt")
+	if err := os.WriteFile(ownerFile, []byte("owner-secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("IMG2PDF_TEST_USER_PW", "user-secret")
+
+	for _, alg := range []string{EncryptAES128, EncryptAES256} {
+		opts := DefaultOptions()
+		opts.UserPassword = "env:IMG2PDF_TEST_USER_PW"
+		opts.OwnerPassword = "file:" + ownerFile
+		opts.Encryption = alg
+		opts.Permissions = "print,copy"
+		// Даты метаданных записываются вместе с шифрованием, за одну запись
+		opts.Title = "Scans"
+		opts.Created = "2024-06-01"
+
+		output := filepath.Join(tmpDir, alg+".pdf")
+		if err := NewConverterWithOptions(opts).Convert(tmpDir, output, "nam"); err != nil {
+			t.Fatalf("%s: Convert failed: %v", alg, err)
+		}
+
+		if _, err := api.ReadContextFile(output); err == nil {
+			t.Errorf("%s: document opens without a password", alg)
+		}
+		// AES-256 определён в PDF 2.0
+		header := map[string]string{EncryptAES128: "%PDF-1.7", EncryptAES256: "%PDF-2.0"}[alg]
+		data, err := os.ReadFile(output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(string(data), header) {
+			t.Errorf("%s: header = %q; want %s", alg, data[:8], header)
+		}
+		for _, conf := range []*model.Configuration{
+			model.NewAESConfiguration("user-secret", "", 0),
+			model.NewAESConfiguration("", "owner-secret", 0),
+		} {
+			conf.ValidationMode = model.ValidationStrict
+			if err := api.ValidateFile(output, conf); err != nil {
+				t.Errorf("%s: validation with password failed: %v", alg, err)
+			}
+		}
+
+		f, err := os.Open(output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		conf := model.NewAESConfiguration("user-secret", "", 0)
+		info, err := api.PDFInfo(f, output, nil, false, conf)
+		f.Close()
+		if err != nil {
+			t.Fatalf("%s: PDFInfo failed: %v", alg, err)
+		}
+		if !info.Encrypted || info.PageCount != 2 || info.Title != "Scans" {
+			t.Errorf("%s: encrypted=%v pages=%d title=%q; want an encrypted 2-page PDF titled Scans",
+				alg, info.Encrypted, info.PageCount, info.Title)
+		}
+		if !strings.HasPrefix(info.CreationDate, "D:20240601") {
+			t.Errorf("%s: CreationDate = %q; want 2024-06-01", alg, info.CreationDate)
+		}
+		if info.Permissions&int(model.PermissionModify) != 0 || info.Permissions&int(model.PermissionPrintRev3) == 0 {
+			t.Errorf("%s: permissions = %#x; want print without modify", alg, info.Permissions)
+		}
+	}
+}
+
+func TestConvert_EncryptedUserPasswordOnly(t *testing.T) {
+	tmpDir := t.TempDir()
+	createTree(t, tmpDir, "1.jpg")
+
+	opts := DefaultOptions()
+	opts.UserPassword = "user-secret"
+	opts.Permissions = "print"
+	output := filepath.Join(tmpDir, "out.pdf")
+	if err := NewConverterWithOptions(opts).Convert(tmpDir, output, "nam"); err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+	strict := model.NewAESConfiguration("user-secret", "", 0)
+	strict.ValidationMode = model.ValidationStrict
+	if err := api.ValidateFile(output, strict); err != nil {
+		t.Errorf("Strict validation failed: %v", err)
+	}
+
+	// Просмотрщики сначала пробуют введённый пароль как пароль владельца
+	conf := model.NewAESConfiguration("user-secret", "user-secret", 0)
+	conf.Cmd = model.EXTRACTIMAGES
+	f, err := os.Open(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := api.ReadContext(f, conf); err == nil {
+		t.Error("The user password grants extraction, which -permissions print does not allow")
+	}
+
+	if _, err := f.Seek(0, 0); err != nil {
+		t.Fatal(err)
+	}
+	conf = model.NewAESConfiguration("user-secret", "", 0)
+	conf.Cmd = model.LISTINFO
+	if _, err := api.ReadContext(f, conf); err != nil {
+		t.Errorf("Document does not open with the user password: %v", err)
+	}
+}
+
+func TestOptionsValidate_PasswordSyntaxOnly(t *testing.T) {
+	opts := DefaultOptions()
+	opts.UserPassword = "env:IMG2PDF_TEST_UNSET"
+	opts.OwnerPassword = "file:" + filepath.Join(t.TempDir(), "missing")
+	// Validate не читает пароли: это делается один раз при записи
+	if err := opts.Validate(); err != nil {
+		t.Errorf("Validate read the password sources: %v", err)
+	}
+	if _, err := opts.encryption(); !IsInvalidInput(err) {
+		t.Errorf("encryption: expected ErrInvalidInput, got %v", err)
+	}
+
+	for _, spec := range []string{"env:", "file:"} {
+		opts := DefaultOptions()
+		opts.UserPassword = spec
+		if err := opts.Validate(); !IsInvalidInput(err) {
+			t.Errorf("%q: expected ErrInvalidInput, got %v", spec, err)
+		}
+	}
+}
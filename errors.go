@@ -65,6 +65,10 @@ func IsNoImagesFound(err error) bool {
 	return errors.Is(err, ErrNoImagesFound)
 }
 
+func IsInvalidInput(err error) bool {
+	return errors.Is(err, ErrInvalidInput)
+}
+
 func IsInvalidExtension(err error) bool {
 	var ie *InvalidExtensionError
 	return errors.As(err, &ie)
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"time"
+)
+
+// Теги EXIF, которые читает конвертер
+const (
+	exifTagOrientation      = 0x0112
+	exifTagDateTime         = 0x0132
+	exifTagExifIFD          = 0x8769
+	exifTagDateTimeOriginal = 0x9003
+)
+
+// exifHeader предшествует TIFF-структуре в JPEG APP1 и иногда в WebP
+var exifHeader = []byte("Exif\x00\x00")
+
+// exifData находит TIFF-структуру с метаданными EXIF в файле изображения
+func exifData(raw []byte, format string) []byte {
+	switch format {
+	case "jpeg":
+		return jpegExif(raw)
+	case "png":
+		return pngExif(raw)
+	case "webp":
+		return webpExif(raw)
+	case "tiff":
+		return raw
+	}
+	return nil
+}
+
+// jpegExif ищет сегмент APP1 с EXIF до начала сжатых данных
+func jpegExif(raw []byte) []byte {
+	var exif []byte
+	jpegSegments(raw, func(marker byte, data []byte) bool {
+		if marker == 0xe1 && bytes.HasPrefix(data, exifHeader) {
+			exif = data[len(exifHeader):]
+			return false
+		}
+		return true
+	})
+	return exif
+}
+
+// jpegSegments передаёт fn маркеры и содержимое сегментов JPEG до начала сжатых
+// данных. Обход прекращается, когда fn возвращает false.
+func jpegSegments(raw []byte, fn func(marker byte, data []byte) bool) {
+	if len(raw) < 4 || raw[0] != 0xff || raw[1] != 0xd8 {
+		return
+	}
+	for i := 2; i+4 <= len(raw); {
+		if raw[i] != 0xff {
+			return
+		}
+		marker := raw[i+1]
+		if marker == 0xd8 || (marker >= 0xd0 && marker <= 0xd7) || marker == 0x01 || marker == 0xff {
+			i++
+			continue
+		}
+		// После SOS и EOI метаданных не бывает
+		if marker == 0xda || marker == 0xd9 {
+			return
+		}
+		size := int(binary.BigEndian.Uint16(raw[i+2:]))
+		end := i + 2 + size
+		if size < 2 || end > len(raw) {
+			return
+		}
+		if !fn(marker, raw[i+4:end]) {
+			return
+		}
+		i = end
+	}
+}
+
+// pngExif возвращает содержимое чанка eXIf
+func pngExif(raw []byte) []byte {
+	const signature = 8
+	for i := signature; i+8 <= len(raw); {
+		size := int(binary.BigEndian.Uint32(raw[i:]))
+		kind := string(raw[i+4 : i+8])
+		end := i + 8 + size
+		if size < 0 || end > len(raw) {
+			return nil
+		}
+		switch kind {
+		case "eXIf":
+			return raw[i+8 : end]
+		case "IDAT", "IEND":
+			// eXIf обязан стоять до данных изображения
+			return nil
+		}
+		i = end + 4 // CRC
+	}
+	return nil
+}
+
+// webpExif возвращает содержимое чанка EXIF контейнера RIFF
+func webpExif(raw []byte) []byte {
+	var exif []byte
+	webpChunks(raw, func(id string, data []byte) bool {
+		if id == "EXIF" {
+			exif = bytes.TrimPrefix(data, exifHeader)
+			return false
+		}
+		return true
+	})
+	return exif
+}
+
+// exifOrientation возвращает значение тега Orientation (1..8) или 1, если его нет
+func exifOrientation(tiff []byte) int {
+	v, ok := tiffShortTag(tiff, exifTagOrientation)
+	if !ok || v < 1 || v > 8 {
+		return 1
+	}
+	return v
+}
+
+// tiffShortTag читает тег типа SHORT из первого IFD TIFF-структуры
+func tiffShortTag(tiff []byte, tag uint16) (int, bool) {
+	order, ifd, ok := tiffHeader(tiff)
+	if !ok {
+		return 0, false
+	}
+	// Тип 3 — SHORT, значение хранится прямо в записи
+	entry, ok := tiffEntry(tiff, order, ifd, tag, 3)
+	if !ok {
+		return 0, false
+	}
+	return int(order.Uint16(tiff[entry+8:])), true
+}
+
+// exifDate возвращает время съёмки: DateTimeOriginal из Exif IFD, а если его нет —
+// DateTime из первого IFD
+func exifDate(tiff []byte) (time.Time, bool) {
+	order, ifd, ok := tiffHeader(tiff)
+	if !ok {
+		return time.Time{}, false
+	}
+	// Тип 4 — LONG, смещение вложенного Exif IFD
+	if entry, ok := tiffEntry(tiff, order, ifd, exifTagExifIFD, 4); ok {
+		if t, ok := tiffDate(tiff, order, int(order.Uint32(tiff[entry+8:])), exifTagDateTimeOriginal); ok {
+			return t, true
+		}
+	}
+	return tiffDate(tiff, order, ifd, exifTagDateTime)
+}
+
+// tiffDate читает дату в формате EXIF "2006:01:02 15:04:05" из тега типа ASCII
+func tiffDate(tiff []byte, order binary.ByteOrder, ifd int, tag uint16) (time.Time, bool) {
+	entry, ok := tiffEntry(tiff, order, ifd, tag, 2)
+	if !ok {
+		return time.Time{}, false
+	}
+	// Строка длиннее 4 байт хранится по смещению
+	count := int(order.Uint32(tiff[entry+4:]))
+	offset := int(order.Uint32(tiff[entry+8:]))
+	if count < 19 || offset < 0 || offset+19 > len(tiff) {
+		return time.Time{}, false
+	}
+	t, err := time.Parse("2006:01:02 15:04:05", string(tiff[offset:offset+19]))
+	return t, err == nil
+}
+
+// tiffHeader определяет порядок байт TIFF-структуры и смещение первого IFD
+func tiffHeader(tiff []byte) (binary.ByteOrder, int, bool) {
+	if len(tiff) < 8 {
+		return nil, 0, false
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:4]) {
+	case "II*\x00":
+		order = binary.LittleEndian
+	case "MM\x00*":
+		order = binary.BigEndian
+	default:
+		return nil, 0, false
+	}
+	return order, int(order.Uint32(tiff[4:])), true
+}
+
+// tiffEntry ищет в IFD запись тега tag с типом kind и возвращает её смещение
+func tiffEntry(tiff []byte, order binary.ByteOrder, ifd int, tag, kind uint16) (int, bool) {
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 0, false
+	}
+	count := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < count; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 0, false
+		}
+		if order.Uint16(tiff[entry:]) == tag && order.Uint16(tiff[entry+2:]) == kind {
+			return entry, true
+		}
+	}
+	return 0, false
+}
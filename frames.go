@@ -0,0 +1,336 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// tiffTagBitsPerSample — тег TIFF с глубиной отсчёта, у факсов она равна 1
+const tiffTagBitsPerSample = 258
+
+// maxFrames ограничивает число кадров одного файла, чтобы испорченная цепочка IFD
+// не зациклила чтение
+const maxFrames = 10000
+
+// Кадры анимированных изображений (-animation); кроме них принимается диапазон кадров N-M
+const (
+	// AnimationFirst оставляет только первый кадр
+	AnimationFirst = "first"
+	// AnimationAll выводит каждый кадр отдельной страницей
+	AnimationAll = "all"
+)
+
+// animatedExtensions — форматы, кадры которых выбираются через -animation.
+// Многостраничные TIFF всегда выводятся целиком.
+var animatedExtensions = map[string]bool{
+	".gif":  true,
+	".webp": true,
+}
+
+// validateAnimation проверяет выбор кадров анимации
+func (o Options) validateAnimation() error {
+	if o.Animation == AnimationFirst || o.Animation == AnimationAll {
+		return nil
+	}
+	if _, _, err := frameRange(o.Animation); err != nil {
+		return fmt.Errorf("%w: animation must be first, all or a frame range: %v", ErrInvalidInput, err)
+	}
+	return nil
+}
+
+// source возвращает путь изображения для отчёта и сообщений, с номером кадра
+// для многостраничных файлов: scan.tif#2
+func (i ImageInfo) source() string {
+	if i.Frame > 0 {
+		return i.Path + "#" + strconv.Itoa(i.Frame)
+	}
+	return i.Path
+}
+
+// splitFrameSpec отделяет выбор кадров от пути: "fax.tif#2-5" → "fax.tif", "2-5".
+// Существующий файл с # в имени остаётся как есть.
+func splitFrameSpec(arg string) (string, string) {
+	if _, err := os.Stat(arg); err == nil {
+		return arg, ""
+	}
+	i := strings.LastIndexByte(arg, '#')
+	if i < 0 {
+		return arg, ""
+	}
+	return arg[:i], arg[i+1:]
+}
+
+// frameRange разбирает выбор кадров N, N-M или N-. Для открытого диапазона last равен 0.
+func frameRange(spec string) (int, int, error) {
+	if open, ok := strings.CutSuffix(spec, "-"); ok {
+		first, _, err := parsePageRange(open)
+		return first, 0, err
+	}
+	return parsePageRange(spec)
+}
+
+// parseFrames разбирает выбор кадров для файла из count кадров.
+// Пустой выбор означает все кадры.
+func parseFrames(spec string, count int) (int, int, error) {
+	if spec == "" {
+		return 1, count, nil
+	}
+	first, last, err := frameRange(spec)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%w: frames %q: %v", ErrInvalidInput, spec, err)
+	}
+	if last == 0 {
+		last = count
+	}
+	if first > count {
+		return 0, 0, fmt.Errorf("%w: frame %d requested, the file has %d", ErrInvalidInput, first, count)
+	}
+	if last > count {
+		fmt.Printf("Warning: frames %s requested, the file has %d\n", spec, count)
+		last = count
+	}
+	return first, last, nil
+}
+
+// expandFrames заменяет многостраничный файл его кадрами по выбору spec. Без явного
+// выбора кадры анимации берутся согласно -animation. Файл из одного кадра остаётся
+// одним изображением без номера кадра.
+func (c *Converter) expandFrames(info ImageInfo, spec string) ([]ImageInfo, error) {
+	explicit := spec != ""
+	if !explicit && animatedExtensions[strings.ToLower(filepath.Ext(info.Path))] {
+		switch c.opts.Animation {
+		case AnimationFirst:
+			return []ImageInfo{info}, nil
+		case AnimationAll:
+		default:
+			spec = c.opts.Animation
+		}
+	}
+
+	count, err := c.frameCount(info.Path)
+	if err != nil {
+		return nil, err
+	}
+	if count == 1 && !explicit {
+		return []ImageInfo{info}, nil
+	}
+	first, last, err := parseFrames(spec, count)
+	if err != nil {
+		return nil, err
+	}
+	if count == 1 {
+		return []ImageInfo{info}, nil
+	}
+	frames := make([]ImageInfo, 0, last-first+1)
+	for n := first; n <= last; n++ {
+		frame := info
+		frame.Frame = n
+		frames = append(frames, frame)
+	}
+	return frames, nil
+}
+
+// frameCount возвращает число кадров файла изображения
+func (c *Converter) frameCount(path string) (int, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".tif", ".tiff":
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return 0, err
+		}
+		if n := len(tiffIFDs(raw)); n > 0 {
+			return n, nil
+		}
+	case ".gif":
+		a, err := c.animation(path)
+		if err != nil {
+			return 0, err
+		}
+		return len(a.g.Image), nil
+	case ".webp":
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return 0, err
+		}
+		if webpAnimated(raw) {
+			_, frames := webpAnimation(raw)
+			return max(len(frames), 1), nil
+		}
+	}
+	return 1, nil
+}
+
+// tiffIFDs возвращает смещения всех IFD TIFF-файла по порядку
+func tiffIFDs(raw []byte) []int {
+	order, ifd, ok := tiffHeader(raw)
+	if !ok {
+		return nil
+	}
+	var ifds []int
+	seen := map[int]bool{}
+	for ifd >= 8 && ifd+2 <= len(raw) && !seen[ifd] && len(ifds) < maxFrames {
+		seen[ifd] = true
+		ifds = append(ifds, ifd)
+		next := ifd + 2 + 12*int(order.Uint16(raw[ifd:]))
+		if next+4 > len(raw) {
+			break
+		}
+		ifd = int(order.Uint32(raw[next:]))
+	}
+	return ifds
+}
+
+// decodeFrame декодирует кадр frame (от 1) многостраничного файла и возвращает вместе
+// с ним байты, по которым читаются EXIF и ICC-профиль этого кадра
+func decodeFrame(raw []byte, frame int) (image.Image, string, []byte, error) {
+	if bytes.HasPrefix(raw, []byte("GIF8")) {
+		img, err := gifFrame(raw, frame)
+		return img, "gif", raw, err
+	}
+	if webpAnimated(raw) {
+		img, err := webpFrame(raw, frame)
+		return img, "webp", raw, err
+	}
+	raw, err := selectFrame(raw, frame)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	img, format, err := image.Decode(bytes.NewReader(raw))
+	return img, format, raw, err
+}
+
+// selectFrame возвращает файл, первым кадром которого стал кадр frame (от 1).
+// В TIFF достаточно направить заголовок на нужный IFD: смещения в файле абсолютные.
+func selectFrame(raw []byte, frame int) ([]byte, error) {
+	ifds := tiffIFDs(raw)
+	if frame < 1 || frame > len(ifds) {
+		return nil, fmt.Errorf("frame %d not found, the file has %d", frame, max(len(ifds), 1))
+	}
+	order, _, _ := tiffHeader(raw)
+	out := bytes.Clone(raw)
+	order.PutUint32(out[4:], uint32(ifds[frame-1]))
+	return out, nil
+}
+
+// gifFrame собирает кадр frame (от 1) анимированного GIF
+func gifFrame(raw []byte, frame int) (image.Image, error) {
+	a, err := decodeGIF(raw)
+	if err != nil {
+		return nil, err
+	}
+	return a.frame(frame)
+}
+
+// gifAnimation — декодированный анимированный GIF. Кадры хранят только изменившуюся
+// область, поэтому они накладываются друг на друга с учётом способа очистки после
+// показа. Холст сохраняется между вызовами: кадры запрашиваются по порядку, и каждый
+// следующий накладывается на предыдущий, а не собирается заново с первого.
+type gifAnimation struct {
+	path   string
+	raw    []byte
+	g      *gif.GIF
+	canvas *image.NRGBA
+	// next — индекс кадра, который будет наложен на холст следующим
+	next int
+}
+
+// decodeGIF декодирует все кадры GIF
+func decodeGIF(raw []byte) (*gifAnimation, error) {
+	g, err := gif.DecodeAll(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+	return &gifAnimation{raw: raw, g: g}, nil
+}
+
+// frame возвращает кадр n (от 1). Запрос кадра, уже пройденного холстом, собирает
+// анимацию заново.
+func (a *gifAnimation) frame(n int) (image.Image, error) {
+	g := a.g
+	if n < 1 || n > len(g.Image) {
+		return nil, fmt.Errorf("frame %d not found, the file has %d", n, len(g.Image))
+	}
+	if a.canvas == nil || n <= a.next {
+		a.canvas = image.NewNRGBA(image.Rect(0, 0, g.Config.Width, g.Config.Height))
+		a.next = 0
+	}
+
+	var out *image.NRGBA
+	for ; a.next < n; a.next++ {
+		img := g.Image[a.next]
+		disposal := byte(0)
+		if a.next < len(g.Disposal) {
+			disposal = g.Disposal[a.next]
+		}
+		var saved *image.NRGBA
+		if disposal == gif.DisposalPrevious {
+			saved = cloneNRGBA(a.canvas)
+		}
+		draw.Draw(a.canvas, img.Bounds(), img, img.Bounds().Min, draw.Over)
+		if a.next == n-1 {
+			out = cloneNRGBA(a.canvas)
+		}
+		switch disposal {
+		case gif.DisposalBackground:
+			draw.Draw(a.canvas, img.Bounds(), image.Transparent, image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			a.canvas = saved
+		}
+	}
+	return out, nil
+}
+
+// cloneNRGBA возвращает копию изображения
+func cloneNRGBA(img *image.NRGBA) *image.NRGBA {
+	out := image.NewNRGBA(img.Rect)
+	copy(out.Pix, img.Pix)
+	return out
+}
+
+// animation возвращает декодированный GIF path. Кадры одного файла идут подряд,
+// поэтому хранится только последний декодированный файл.
+func (c *Converter) animation(path string) (*gifAnimation, error) {
+	if c.gif != nil && c.gif.path == path {
+		return c.gif, nil
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	a, err := decodeGIF(raw)
+	if err != nil {
+		return nil, err
+	}
+	a.path = path
+	c.gif = a
+	return a, nil
+}
+
+// loadFrame загружает кадр info. Кадры GIF берутся из общего для файла декодирования.
+func (c *Converter) loadFrame(info ImageInfo) (*sourceImage, error) {
+	if info.Frame == 0 || !strings.EqualFold(filepath.Ext(info.Path), ".gif") {
+		return loadFrame(info.Path, info.Frame)
+	}
+	a, err := c.animation(info.Path)
+	if err != nil {
+		return nil, &ImageError{Path: info.source(), Reason: err.Error()}
+	}
+	img, err := a.frame(info.Frame)
+	if err != nil {
+		return nil, &ImageError{Path: info.source(), Reason: err.Error()}
+	}
+	return &sourceImage{Image: img, Format: "gif", Raw: a.raw}, nil
+}
+
+// tiffBilevel сообщает, что первый кадр TIFF однобитный, как у факсов G3/G4
+func tiffBilevel(raw []byte) bool {
+	bits, ok := tiffShortTag(raw, tiffTagBitsPerSample)
+	return ok && bits == 1
+}
@@ -0,0 +1,328 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/gif"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"golang.org/x/image/bmp"
+)
+
+// tiffFrame — кадр тестового TIFF: восьмибитный, однобитный или сжатый в G4
+type tiffFrame struct {
+	img  *image.Gray
+	bits int
+	g4   bool
+}
+
+// multiPageTIFF собирает TIFF с несколькими IFD. Данные кадра идут перед его IFD.
+func multiPageTIFF(frames []tiffFrame) []byte {
+	out := []byte("II*\x00\x00\x00\x00\x00")
+	next := 4
+	for _, f := range frames {
+		b := f.img.Bounds()
+		var data []byte
+		compression, photometric := 1, 1
+		switch {
+		case f.g4:
+			// В факсах единица — чёрный, поэтому WhiteIsZero
+			data, compression, photometric = encodeG4(f.img), 4, 0
+		case f.bits == 1:
+			stride := (b.Dx() + 7) / 8
+			data = make([]byte, stride*b.Dy())
+			for y := range b.Dy() {
+				for x := range b.Dx() {
+					if f.img.GrayAt(x, y).Y >= 128 {
+						data[y*stride+x/8] |= 0x80 >> (x % 8)
+					}
+				}
+			}
+		default:
+			data = f.img.Pix
+		}
+		offset := len(out)
+		out = append(out, data...)
+		if len(out)%2 != 0 {
+			out = append(out, 0)
+		}
+
+		binary.LittleEndian.PutUint32(out[next:], uint32(len(out)))
+		entries := [][3]int{
+			{256, 4, b.Dx()},
+			{257, 4, b.Dy()},
+			{258, 3, f.bits},
+			{259, 3, compression},
+			{262, 3, photometric},
+			{273, 4, offset},
+			{278, 4, b.Dy()},
+			{279, 4, len(data)},
+		}
+		out = binary.LittleEndian.AppendUint16(out, uint16(len(entries)))
+		for _, e := range entries {
+			out = binary.LittleEndian.AppendUint16(out, uint16(e[0]))
+			out = binary.LittleEndian.AppendUint16(out, uint16(e[1]))
+			out = binary.LittleEndian.AppendUint32(out, 1)
+			out = binary.LittleEndian.AppendUint32(out, uint32(e[2]))
+		}
+		next = len(out)
+		out = append(out, 0, 0, 0, 0)
+	}
+	return out
+}
+
+// faxPage возвращает чёрно-белую страницу с чёрной полосой слева
+func faxPage(w, h int) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	for i := range img.Pix {
+		if i%w >= w/4 {
+			img.Pix[i] = 0xff
+		}
+	}
+	return img
+}
+
+func TestParseFrames(t *testing.T) {
+	tests := []struct {
+		spec        string
+		first, last int
+		wantErr     bool
+	}{
+		{"", 1, 5, false},
+		{"2", 2, 2, false},
+		{"2-4", 2, 4, false},
+		{"3-", 3, 5, false},
+		{"4-9", 4, 5, false},
+		{"6", 0, 0, true},
+		{"0", 0, 0, true},
+		{"4-2", 0, 0, true},
+		{"a", 0, 0, true},
+	}
+	for _, tt := range tests {
+		first, last, err := parseFrames(tt.spec, 5)
+		if tt.wantErr {
+			if !IsInvalidInput(err) {
+				t.Errorf("parseFrames(%q): expected ErrInvalidInput, got %v", tt.spec, err)
+			}
+			continue
+		}
+		if err != nil || first != tt.first || last != tt.last {
+			t.Errorf("parseFrames(%q) = %d, %d, %v; want %d, %d", tt.spec, first, last, err, tt.first, tt.last)
+		}
+	}
+}
+
+func TestSplitFrameSpec(t *testing.T) {
+	tmpDir := t.TempDir()
+	hashed := filepath.Join(tmpDir, "scan#1.tif")
+	if err := os.WriteFile(hashed, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if path, spec := splitFrameSpec(hashed); path != hashed || spec != "" {
+		t.Errorf("Existing file: got %q, %q", path, spec)
+	}
+	if path, spec := splitFrameSpec("fax.tif#2-5"); path != "fax.tif" || spec != "2-5" {
+		t.Errorf("Frame range: got %q, %q", path, spec)
+	}
+}
+
+func TestConvert_MultiPageTIFF(t *testing.T) {
+	tmpDir := t.TempDir()
+	gray := image.NewGray(image.Rect(0, 0, 30, 20))
+	for i := range gray.Pix {
+		gray.Pix[i] = uint8(i)
+	}
+	fax := multiPageTIFF([]tiffFrame{
+		{img: gray, bits: 8},
+		{img: faxPage(64, 40), bits: 1, g4: true},
+		{img: faxPage(48, 32), bits: 1},
+	})
+	path := filepath.Join(tmpDir, "fax.tif")
+	if err := os.WriteFile(path, fax, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(tiffIFDs(fax)); n != 3 {
+		t.Fatalf("IFD count = %d; want 3", n)
+	}
+
+	src, err := loadFrame(path, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !src.Bilevel || src.Image.Bounds().Dx() != 64 {
+		t.Fatalf("Frame 2: bilevel=%v, bounds %v", src.Bilevel, src.Image.Bounds())
+	}
+	if g := src.Image.(*image.Gray); g.GrayAt(0, 0).Y != 0 || g.GrayAt(40, 0).Y != 0xff {
+		t.Errorf("G4 frame decoded with wrong polarity: %d, %d", g.GrayAt(0, 0).Y, g.GrayAt(40, 0).Y)
+	}
+
+	output := filepath.Join(tmpDir, "out.pdf")
+	c := NewConverterWithOptions(DefaultOptions())
+	if err := c.Convert(tmpDir, output, "nam"); err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+	pages := c.Report().Pages
+	if len(pages) != 3 {
+		t.Fatalf("Pages = %d; want 3", len(pages))
+	}
+	want := []struct {
+		source   string
+		width    int
+		encoding string
+	}{
+		{path + "#1", 30, EncodingFlate},
+		{path + "#2", 64, EncodingCCITT},
+		{path + "#3", 48, EncodingCCITT},
+	}
+	for i, w := range want {
+		p := pages[i]
+		if p.Source != w.source || p.Width != w.width || p.Encoding != w.encoding {
+			t.Errorf("Page %d: %s %dpx %s; want %s %dpx %s", i+1, p.Source, p.Width, p.Encoding, w.source, w.width, w.encoding)
+		}
+	}
+
+	if err := c.Convert(path+"#2-", output, "seq"); err != nil {
+		t.Fatalf("Convert with frame range failed: %v", err)
+	}
+	pages = c.Report().Pages
+	if len(pages) != 2 || pages[0].Source != path+"#2" || pages[1].Source != path+"#3" {
+		t.Errorf("Frame range pages: %+v", pages)
+	}
+	if n, err := countPDFPages(output); err != nil || n != 2 {
+		t.Errorf("PDF pages = %d, %v; want 2", n, err)
+	}
+
+	if err := c.Convert(path+"#5", output, "seq"); !IsNoImagesFound(err) {
+		t.Errorf("Missing frame: expected ErrNoImagesFound, got %v", err)
+	}
+}
+
+// animatedGIF собирает GIF 20×20 из трёх кадров: заливка красным, синий квадрат
+// в углу, который убирается после показа, и зелёный квадрат в другом углу
+func animatedGIF(t *testing.T) []byte {
+	palette := color.Palette{color.Transparent, color.RGBA{255, 0, 0, 255}, color.RGBA{0, 0, 255, 255}, color.RGBA{0, 255, 0, 255}}
+	frame := func(r image.Rectangle, idx uint8) *image.Paletted {
+		img := image.NewPaletted(r, palette)
+		for i := range img.Pix {
+			img.Pix[i] = idx
+		}
+		return img
+	}
+	g := &gif.GIF{
+		Image:    []*image.Paletted{frame(image.Rect(0, 0, 20, 20), 1), frame(image.Rect(0, 0, 5, 5), 2), frame(image.Rect(10, 10, 20, 20), 3)},
+		Delay:    []int{10, 10, 10},
+		Disposal: []byte{gif.DisposalNone, gif.DisposalPrevious, gif.DisposalNone},
+	}
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, g); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestGIFFrame(t *testing.T) {
+	raw := animatedGIF(t)
+	red, blue, green := color.NRGBA{255, 0, 0, 255}, color.NRGBA{0, 0, 255, 255}, color.NRGBA{0, 255, 0, 255}
+	tests := []struct {
+		frame              int
+		corner, mid, inner color.NRGBA
+	}{
+		{1, red, red, red},
+		{2, blue, red, red},
+		// Второй кадр убран, третий лёг поверх первого
+		{3, red, red, green},
+	}
+	for _, tt := range tests {
+		img, err := gifFrame(raw, tt.frame)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b := img.Bounds(); b != image.Rect(0, 0, 20, 20) {
+			t.Errorf("Frame %d bounds = %v", tt.frame, b)
+		}
+		at := func(x, y int) color.NRGBA { return color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA) }
+		if at(1, 1) != tt.corner || at(7, 7) != tt.mid || at(15, 15) != tt.inner {
+			t.Errorf("Frame %d: %v %v %v; want %v %v %v", tt.frame, at(1, 1), at(7, 7), at(15, 15), tt.corner, tt.mid, tt.inner)
+		}
+	}
+	if _, err := gifFrame(raw, 4); err == nil {
+		t.Error("Expected an error for a missing frame")
+	}
+
+	// Одно декодирование отдаёт кадры в любом порядке так же, как сборка с нуля
+	a, err := decodeGIF(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, frame := range []int{1, 2, 3, 2, 3, 1} {
+		got, err := a.frame(frame)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, _ := gifFrame(raw, frame)
+		if !bytes.Equal(got.(*image.NRGBA).Pix, want.(*image.NRGBA).Pix) {
+			t.Errorf("Cached frame %d differs from a fresh decode", frame)
+		}
+	}
+}
+
+func TestConvert_GIFAndBMP(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "1.gif"), animatedGIF(t), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Create(filepath.Join(tmpDir, "2.bmp"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := bmp.Encode(f, image.NewRGBA(image.Rect(0, 0, 12, 8))); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	tests := []struct {
+		animation string
+		sources   []string
+	}{
+		{AnimationFirst, []string{"1.gif", "2.bmp"}},
+		{AnimationAll, []string{"1.gif#1", "1.gif#2", "1.gif#3", "2.bmp"}},
+		{"2-", []string{"1.gif#2", "1.gif#3", "2.bmp"}},
+	}
+	output := filepath.Join(tmpDir, "out.pdf")
+	for _, tt := range tests {
+		opts := DefaultOptions()
+		opts.Animation = tt.animation
+		c := NewConverterWithOptions(opts)
+		if err := c.Convert(tmpDir, output, "nam"); err != nil {
+			t.Fatalf("%s: Convert failed: %v", tt.animation, err)
+		}
+		var sources []string
+		for _, p := range c.Report().Pages {
+			sources = append(sources, filepath.Base(p.Source))
+		}
+		if !slices.Equal(sources, tt.sources) {
+			t.Errorf("%s: pages %v; want %v", tt.animation, sources, tt.sources)
+		}
+	}
+
+	// Явный выбор кадров сильнее -animation
+	c := NewConverterWithOptions(DefaultOptions())
+	if err := c.Convert(filepath.Join(tmpDir, "1.gif")+"#3", output, "seq"); err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+	if pages := c.Report().Pages; len(pages) != 1 || !strings.HasSuffix(pages[0].Source, "1.gif#3") {
+		t.Errorf("Frame selection pages: %+v", pages)
+	}
+
+	opts := DefaultOptions()
+	opts.Animation = "every"
+	if err := opts.Validate(); !IsInvalidInput(err) {
+		t.Errorf("Expected ErrInvalidInput for -animation every, got %v", err)
+	}
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"io"
+	"math"
+	"sync"
+)
+
+// tiffTagICCProfile — тег TIFF со встроенным ICC-профилем (InterColorProfile)
+const tiffTagICCProfile = 34675
+
+// jpegICCHeader предшествует части профиля в каждом сегменте APP2 JPEG
+var jpegICCHeader = []byte("ICC_PROFILE\x00")
+
+// iccSpaces — число компонентов цветовых пространств профиля
+var iccSpaces = map[string]int{
+	"GRAY": 1,
+	"RGB ": 3,
+	"CMYK": 4,
+}
+
+// deviceComponents — число компонентов цветовых пространств изображений PDF
+var deviceComponents = map[string]int{
+	"DeviceGray": 1,
+	"DeviceRGB":  3,
+	"DeviceCMYK": 4,
+}
+
+// iccProfile извлекает встроенный ICC-профиль из файла изображения. Повреждённый
+// профиль отбрасывается.
+func iccProfile(raw []byte, format string) []byte {
+	var profile []byte
+	switch format {
+	case "jpeg":
+		profile = jpegICC(raw)
+	case "png":
+		profile = pngICC(raw)
+	case "tiff":
+		profile = tiffICC(raw)
+	case "webp":
+		profile = webpICC(raw)
+	}
+	if iccComponents(profile) == 0 {
+		return nil
+	}
+	return profile
+}
+
+// iccComponents возвращает число цветовых компонентов профиля или 0, если это
+// не ICC-профиль
+func iccComponents(profile []byte) int {
+	if len(profile) < 132 || string(profile[36:40]) != "acsp" ||
+		int(binary.BigEndian.Uint32(profile)) > len(profile) {
+		return 0
+	}
+	return iccSpaces[string(profile[16:20])]
+}
+
+// jpegICC собирает профиль из сегментов APP2. Большой профиль делится на части
+// с порядковым номером и общим числом частей.
+func jpegICC(raw []byte) []byte {
+	var chunks [][]byte
+	broken := false
+	jpegSegments(raw, func(marker byte, data []byte) bool {
+		if marker != 0xe2 || !bytes.HasPrefix(data, jpegICCHeader) || len(data) < len(jpegICCHeader)+2 {
+			return true
+		}
+		seq, count := int(data[len(jpegICCHeader)]), int(data[len(jpegICCHeader)+1])
+		if seq < 1 || seq > count || (chunks != nil && count != len(chunks)) {
+			broken = true
+			return false
+		}
+		if chunks == nil {
+			chunks = make([][]byte, count)
+		}
+		chunks[seq-1] = data[len(jpegICCHeader)+2:]
+		return true
+	})
+	if broken {
+		return nil
+	}
+	var profile []byte
+	for _, c := range chunks {
+		if c == nil {
+			return nil
+		}
+		profile = append(profile, c...)
+	}
+	return profile
+}
+
+// jpegAdobe сообщает, что в JPEG есть сегмент APP14 Adobe. Такие файлы хранят CMYK
+// в инвертированном виде.
+func jpegAdobe(raw []byte) bool {
+	found := false
+	jpegSegments(raw, func(marker byte, data []byte) bool {
+		found = marker == 0xee && bytes.HasPrefix(data, []byte("Adobe"))
+		return !found
+	})
+	return found
+}
+
+// pngICC распаковывает профиль из чанка iCCP: имя, нулевой байт, метод сжатия
+// и поток zlib
+func pngICC(raw []byte) []byte {
+	const signature = 8
+	for i := signature; i+8 <= len(raw); {
+		size := int(binary.BigEndian.Uint32(raw[i:]))
+		kind := string(raw[i+4 : i+8])
+		end := i + 8 + size
+		if size < 0 || end > len(raw) {
+			return nil
+		}
+		switch kind {
+		case "iCCP":
+			_, rest, ok := bytes.Cut(raw[i+8:end], []byte{0})
+			if !ok || len(rest) < 1 || rest[0] != 0 {
+				return nil
+			}
+			zr, err := zlib.NewReader(bytes.NewReader(rest[1:]))
+			if err != nil {
+				return nil
+			}
+			defer zr.Close()
+			profile, err := io.ReadAll(zr)
+			if err != nil {
+				return nil
+			}
+			return profile
+		case "IDAT", "IEND":
+			// iCCP обязан стоять до данных изображения
+			return nil
+		}
+		i = end + 4 // CRC
+	}
+	return nil
+}
+
+// tiffICC читает профиль из тега InterColorProfile первого IFD
+func tiffICC(raw []byte) []byte {
+	order, ifd, ok := tiffHeader(raw)
+	if !ok {
+		return nil
+	}
+	// Тип 7 — UNDEFINED, профиль всегда длиннее 4 байт и хранится по смещению
+	entry, ok := tiffEntry(raw, order, ifd, tiffTagICCProfile, 7)
+	if !ok {
+		return nil
+	}
+	count := int(order.Uint32(raw[entry+4:]))
+	offset := int(order.Uint32(raw[entry+8:]))
+	if count <= 4 || offset < 0 || offset+count > len(raw) {
+		return nil
+	}
+	return raw[offset : offset+count]
+}
+
+// webpICC возвращает содержимое чанка ICCP расширенного формата WebP
+func webpICC(raw []byte) []byte {
+	var profile []byte
+	webpChunks(raw, func(id string, data []byte) bool {
+		if id == "ICCP" {
+			profile = data
+			return false
+		}
+		return true
+	})
+	return profile
+}
+
+// srgbDescription — имя профиля sRGB в ICC и в OutputIntent
+const srgbDescription = "sRGB IEC61966-2.1"
+
+// srgbProfile возвращает компактный ICC-профиль sRGB версии 2: основные цвета,
+// приведённые к D50, и таблица кривой передачи sRGB
+var srgbProfile = sync.OnceValue(func() []byte {
+	s15 := func(v float64) uint32 { return uint32(int32(math.Round(v * 65536))) }
+	xyz := func(x, y, z float64) []byte {
+		b := []byte("XYZ \x00\x00\x00\x00")
+		return binary.BigEndian.AppendUint32(binary.BigEndian.AppendUint32(binary.BigEndian.AppendUint32(b, s15(x)), s15(y)), s15(z))
+	}
+
+	desc := []byte("desc\x00\x00\x00\x00")
+	desc = binary.BigEndian.AppendUint32(desc, uint32(len(srgbDescription)+1))
+	desc = append(desc, srgbDescription...)
+	// Пустые описания Unicode и ScriptCode
+	desc = append(desc, make([]byte, 1+4+4+2+1+67)...)
+
+	curve := []byte("curv\x00\x00\x00\x00")
+	const points = 1024
+	curve = binary.BigEndian.AppendUint32(curve, points)
+	for i := range points {
+		v := float64(i) / (points - 1)
+		if v <= 0.04045 {
+			v /= 12.92
+		} else {
+			v = math.Pow((v+0.055)/1.055, 2.4)
+		}
+		curve = binary.BigEndian.AppendUint16(curve, uint16(math.Round(v*65535)))
+	}
+
+	tags := []struct {
+		sig  string
+		data []byte
+	}{
+		{"desc", desc},
+		{"cprt", []byte("text\x00\x00\x00\x00No copyright, use freely\x00")},
+		{"wtpt", xyz(0.9642, 1.0, 0.8249)},
+		{"rXYZ", xyz(0.4361, 0.2225, 0.0139)},
+		{"gXYZ", xyz(0.3851, 0.7169, 0.0971)},
+		{"bXYZ", xyz(0.1431, 0.0606, 0.7141)},
+		{"rTRC", curve},
+		{"gTRC", curve},
+		{"bTRC", curve},
+	}
+
+	// Данные тегов идут за заголовком и таблицей тегов, выровненные по 4 байта.
+	// Одинаковые кривые каналов хранятся один раз.
+	var data bytes.Buffer
+	table := binary.BigEndian.AppendUint32(nil, uint32(len(tags)))
+	base := 128 + 4 + 12*len(tags)
+	offsets := map[*byte]int{}
+	for _, t := range tags {
+		off, ok := offsets[&t.data[0]]
+		if !ok {
+			off = base + data.Len()
+			offsets[&t.data[0]] = off
+			data.Write(t.data)
+			for data.Len()%4 != 0 {
+				data.WriteByte(0)
+			}
+		}
+		table = append(table, t.sig...)
+		table = binary.BigEndian.AppendUint32(table, uint32(off))
+		table = binary.BigEndian.AppendUint32(table, uint32(len(t.data)))
+	}
+
+	header := make([]byte, 128)
+	binary.BigEndian.PutUint32(header[0:], uint32(base+data.Len()))
+	binary.BigEndian.PutUint32(header[8:], 0x02100000)
+	copy(header[12:], "mntr")
+	copy(header[16:], "RGB ")
+	copy(header[20:], "XYZ ")
+	for i, v := range []uint16{2024, 1, 1, 0, 0, 0} {
+		binary.BigEndian.PutUint16(header[24+2*i:], v)
+	}
+	copy(header[36:], "acsp")
+	// Источник освещения PCS — D50
+	binary.BigEndian.PutUint32(header[68:], s15(0.9642))
+	binary.BigEndian.PutUint32(header[72:], s15(1.0))
+	binary.BigEndian.PutUint32(header[76:], s15(0.8249))
+
+	profile := append(header, table...)
+	return append(profile, data.Bytes()...)
+})
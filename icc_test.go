@@ -0,0 +1,255 @@
+package main
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// withJPEGICC вставляет профиль в JPEG сегментами APP2 по chunk байт
+func withJPEGICC(data, profile []byte, chunk int) []byte {
+	var segments []byte
+	count := (len(profile) + chunk - 1) / chunk
+	for i := range count {
+		part := profile[i*chunk : min(len(profile), (i+1)*chunk)]
+		seg := append([]byte{0xff, 0xe2, 0, 0}, jpegICCHeader...)
+		seg = append(append(seg, byte(i+1), byte(count)), part...)
+		binary.BigEndian.PutUint16(seg[2:], uint16(len(seg)-2))
+		segments = append(segments, seg...)
+	}
+	return append(append(append([]byte{}, data[:2]...), segments...), data[2:]...)
+}
+
+// withPNGICC вставляет чанк iCCP после IHDR
+func withPNGICC(data, profile []byte) []byte {
+	var z bytes.Buffer
+	zw := zlib.NewWriter(&z)
+	zw.Write(profile)
+	zw.Close()
+	body := append([]byte("sRGB\x00\x00"), z.Bytes()...)
+
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(body)))
+	chunk = append(append(chunk, "iCCP"...), body...)
+	chunk = binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+	ihdrEnd := 8 + 8 + 13 + 4
+	return append(append(append([]byte{}, data[:ihdrEnd]...), chunk...), data[ihdrEnd:]...)
+}
+
+// cmykJPEG собирает JPEG 8×8 с четырьмя компонентами одного цвета. Кодируется
+// только коэффициент DC, поэтому хватает минимальных таблиц Хаффмана.
+func cmykJPEG(c, m, y, k byte, adobe bool) []byte {
+	out := []byte{0xff, 0xd8}
+	segment := func(marker byte, data ...byte) {
+		out = append(out, 0xff, marker, byte((len(data)+2)>>8), byte(len(data)+2))
+		out = append(out, data...)
+	}
+	values := []byte{c, m, y, k}
+	if adobe {
+		segment(0xee, 'A', 'd', 'o', 'b', 'e', 0x00, 0x64, 0, 0, 0, 0, 0)
+		for i := range values {
+			values[i] = 255 - values[i]
+		}
+	}
+	segment(0xdb, append([]byte{0}, bytes.Repeat([]byte{1}, 64)...)...)
+	segment(0xc0, 8, 0, 8, 0, 8, 4, 1, 0x11, 0, 2, 0x11, 0, 3, 0x11, 0, 4, 0x11, 0)
+	// DC: категории 0–11 четырёхбитными кодами; AC: только EOB кодом "0"
+	dc := append([]byte{0x00, 0, 0, 0, 12, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, 0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11)
+	ac := []byte{0x10, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}
+	segment(0xc4, append(dc, ac...)...)
+	segment(0xda, 4, 1, 0, 2, 0, 3, 0, 4, 0, 0, 63, 0)
+
+	var bits []bool
+	put := func(v, n int) {
+		for i := n - 1; i >= 0; i-- {
+			bits = append(bits, v>>i&1 == 1)
+		}
+	}
+	for _, v := range values {
+		diff := 8 * (int(v) - 128)
+		size, mag := 0, diff
+		if mag < 0 {
+			mag = -mag
+		}
+		for mag>>size != 0 {
+			size++
+		}
+		put(size, 4)
+		if diff < 0 {
+			diff += 1<<size - 1
+		}
+		put(diff, size)
+		put(0, 1)
+	}
+	for len(bits)%8 != 0 {
+		bits = append(bits, true)
+	}
+	for i := 0; i < len(bits); i += 8 {
+		var b byte
+		for _, bit := range bits[i : i+8] {
+			b <<= 1
+			if bit {
+				b |= 1
+			}
+		}
+		out = append(out, b)
+		if b == 0xff {
+			out = append(out, 0)
+		}
+	}
+	return append(out, 0xff, 0xd9)
+}
+
+// cmykProfile возвращает заголовок профиля CMYK: для выбора ICCBased достаточно
+// цветового пространства в заголовке
+func cmykProfile() []byte {
+	p := slices.Clone(srgbProfile())
+	copy(p[16:], "CMYK")
+	return p
+}
+
+func TestICCProfileExtraction(t *testing.T) {
+	profile := srgbProfile()
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 8, 8)), nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := iccProfile(withJPEGICC(buf.Bytes(), profile, 1000), "jpeg"); !bytes.Equal(got, profile) {
+		t.Errorf("JPEG profile: got %d bytes, want %d", len(got), len(profile))
+	}
+	// Без одной из частей профиль не собирается
+	broken := withJPEGICC(buf.Bytes(), profile[:1000], 1000)
+	broken[2+4+len(jpegICCHeader)+1] = 2
+	if got := iccProfile(broken, "jpeg"); got != nil {
+		t.Errorf("Incomplete JPEG profile: got %d bytes, want none", len(got))
+	}
+
+	buf.Reset()
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 8, 8))); err != nil {
+		t.Fatal(err)
+	}
+	if got := iccProfile(withPNGICC(buf.Bytes(), profile), "png"); !bytes.Equal(got, profile) {
+		t.Errorf("PNG profile: got %d bytes, want %d", len(got), len(profile))
+	}
+
+	// TIFF с одним IFD: InterColorProfile, UNDEFINED, профиль сразу за IFD
+	tiff := []byte("II*\x00\x08\x00\x00\x00\x01\x00\x73\x87\x07\x00\x00\x00\x00\x00\x1a\x00\x00\x00\x00\x00\x00\x00")
+	binary.LittleEndian.PutUint32(tiff[14:], uint32(len(profile)))
+	if got := iccProfile(append(tiff, profile...), "tiff"); !bytes.Equal(got, profile) {
+		t.Errorf("TIFF profile: got %d bytes, want %d", len(got), len(profile))
+	}
+
+	if got := iccProfile(append(tiff, make([]byte, len(profile))...), "tiff"); got != nil {
+		t.Errorf("Not a profile: got %d bytes, want none", len(got))
+	}
+}
+
+func TestLoadImage_CMYKJPEG(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, adobe := range []bool{false, true} {
+		path := filepath.Join(tmpDir, "cmyk.jpg")
+		if err := os.WriteFile(path, cmykJPEG(10, 60, 120, 200, adobe), 0644); err != nil {
+			t.Fatal(err)
+		}
+		src, err := loadImage(path)
+		if err != nil {
+			t.Fatalf("adobe=%v: %v", adobe, err)
+		}
+		got := color.CMYKModel.Convert(src.Image.At(3, 3)).(color.CMYK)
+		want := color.CMYK{C: 10, M: 60, Y: 120, K: 200}
+		if absDiff(got.C, want.C) > 1 || absDiff(got.M, want.M) > 1 || absDiff(got.Y, want.Y) > 1 || absDiff(got.K, want.K) > 1 {
+			t.Errorf("adobe=%v: pixel = %v; want %v", adobe, got, want)
+		}
+
+		img, err := jpegPassthrough(src.Raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if inverted := len(img.Decode) > 0; inverted != adobe {
+			t.Errorf("adobe=%v: Decode = %v", adobe, img.Decode)
+		}
+	}
+}
+
+func TestConvert_ICCBased(t *testing.T) {
+	tmpDir := t.TempDir()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 16, 16)), nil); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string][]byte{
+		"1.jpg": withJPEGICC(buf.Bytes(), srgbProfile(), 1000),
+		"3.jpg": withJPEGICC(cmykJPEG(0, 0, 0, 0, true), cmykProfile(), 60000),
+	}
+	buf.Reset()
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 16, 16))); err != nil {
+		t.Fatal(err)
+	}
+	files["2.png"] = withPNGICC(buf.Bytes(), srgbProfile())
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	output := filepath.Join(tmpDir, "out.pdf")
+	if err := NewConverterWithOptions(DefaultOptions()).Convert(tmpDir, output, "nam"); err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+	if err := api.ValidateFile(output, nil); err != nil {
+		t.Fatalf("Output is not a valid PDF: %v", err)
+	}
+
+	ctx, err := api.ReadContextFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var profiles []types.IndirectRef
+	for i, img := range pdfImages(t, output) {
+		sd, _, err := ctx.DereferenceStreamDict(*types.NewIndirectRef(img.ObjNr, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		cs, ok := sd.Dict["ColorSpace"].(types.Array)
+		if !ok || len(cs) != 2 || cs[0] != types.Name("ICCBased") {
+			t.Errorf("Image %d: ColorSpace = %v; want ICCBased", i+1, sd.Dict["ColorSpace"])
+			continue
+		}
+		profiles = append(profiles, cs[1].(types.IndirectRef))
+		icc, _, err := ctx.DereferenceStreamDict(cs[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n, want := *icc.IntEntry("N"), []int{3, 3, 4}[i]; n != want {
+			t.Errorf("Image %d: profile N = %d; want %d", i+1, n, want)
+		}
+		if i == 2 && sd.ArrayEntry("Decode") == nil {
+			t.Error("Adobe CMYK JPEG has no inverting Decode array")
+		}
+	}
+	if len(profiles) == 3 && profiles[0] != profiles[1] {
+		t.Errorf("Equal profiles are stored twice: %v", profiles)
+	}
+
+	// В оттенках серого RGB-профиль к изображению не подходит
+	opts := DefaultOptions()
+	opts.Color = ColorGray
+	if err := NewConverterWithOptions(opts).Convert(filepath.Join(tmpDir, "1.jpg"), output, "seq"); err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+	if cs := pdfImages(t, output)[0].Cs; cs != "DeviceGray" {
+		t.Errorf("Gray page ColorSpace = %s; want DeviceGray", cs)
+	}
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"os"
+
+	"github.com/pdfcpu/pdfcpu/pkg/filter"
+	_ "golang.org/x/image/bmp"
+	_ "golang.org/x/image/tiff"
+	_ "golang.org/x/image/webp"
+)
+
+// sourceImage — декодированное изображение вместе с исходными байтами файла
+type sourceImage struct {
+	Image  image.Image
+	Format string
+	Raw    []byte
+	// ICC — встроенный ICC-профиль или nil
+	ICC []byte
+	// Bilevel — однобитный источник, например факс G3/G4
+	Bilevel bool
+}
+
+// loadImage читает и декодирует файл изображения
+func loadImage(path string) (*sourceImage, error) {
+	return loadFrame(path, 0)
+}
+
+// loadFrame читает и декодирует кадр frame многостраничного файла, 0 — первый
+// и единственный кадр
+func loadFrame(path string, frame int) (*sourceImage, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var img image.Image
+	var format string
+	// Анимированный WebP без выбора кадра, как и GIF, даёт первый кадр
+	if frame > 0 || webpAnimated(raw) {
+		img, format, raw, err = decodeFrame(raw, max(frame, 1))
+	} else {
+		img, format, err = image.Decode(bytes.NewReader(raw))
+		if err != nil && format == "jpeg" && !jpegAdobe(raw) {
+			img, err = decodeCMYKJPEG(raw, err)
+		}
+	}
+	if err != nil {
+		return nil, &ImageError{Path: ImageInfo{Path: path, Frame: frame}.source(), Reason: err.Error()}
+	}
+
+	return &sourceImage{
+		Image:   img,
+		Format:  format,
+		Raw:     raw,
+		ICC:     iccProfile(raw, format),
+		Bilevel: format == "tiff" && tiffBilevel(raw),
+	}, nil
+}
+
+// decodeCMYKJPEG декодирует CMYK JPEG без сегмента Adobe, который не принимает
+// image/jpeg. Декодеру подставляется сегмент Adobe, а инвертированные им отсчёты
+// возвращаются обратно. Для остальных файлов возвращается исходная ошибка decodeErr.
+func decodeCMYKJPEG(raw []byte, decodeErr error) (image.Image, error) {
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(raw))
+	if err != nil || cfg.ColorModel != color.CMYKModel {
+		return nil, decodeErr
+	}
+	// APP14: "Adobe", версия 100, два слова флагов и преобразование 0 (без YCC)
+	adobe := []byte{0xff, 0xee, 0x00, 0x0e, 'A', 'd', 'o', 'b', 'e', 0x00, 0x64, 0, 0, 0, 0, 0}
+	patched := append(append(append([]byte{}, raw[:2]...), adobe...), raw[2:]...)
+	img, err := jpeg.Decode(bytes.NewReader(patched))
+	if err != nil {
+		return nil, decodeErr
+	}
+	cmyk, ok := img.(*image.CMYK)
+	if !ok {
+		return nil, decodeErr
+	}
+	for i := range cmyk.Pix {
+		cmyk.Pix[i] = 255 - cmyk.Pix[i]
+	}
+	return cmyk, nil
+}
+
+// jpegPassthrough вставляет JPEG без перекодирования
+func jpegPassthrough(raw []byte) (*pdfImage, error) {
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+
+	img := &pdfImage{
+		Width:            cfg.Width,
+		Height:           cfg.Height,
+		BitsPerComponent: 8,
+		ColorSpace:       jpegColorSpace(cfg.ColorModel),
+		Filter:           filter.DCT,
+		Data:             raw,
+	}
+
+	// Adobe пишет CMYK JPEG в инвертированном виде, остальные программы — в прямом
+	if img.ColorSpace == "DeviceCMYK" && jpegAdobe(raw) {
+		img.Decode = []int{1, 0, 1, 0, 1, 0, 1, 0}
+	}
+	return img, nil
+}
+
+// encodeJPEG перекодирует изображение в JPEG с заданным качеством.
+// Альфа-канал JPEG не поддерживает, поэтому он сохраняется отдельной маской.
+func encodeJPEG(img image.Image, quality int) (*pdfImage, error) {
+	var smask *pdfImage
+	if !isOpaque(img) {
+		flat := encodeFlate(img)
+		smask = flat.SMask
+
+		// Цвет без альфы: копия, чтобы не испортить исходное изображение
+		src := toNRGBA(img)
+		w, h := src.Rect.Dx(), src.Rect.Dy()
+		opaque := image.NewNRGBA(image.Rect(0, 0, w, h))
+		for y := 0; y < h; y++ {
+			copy(opaque.Pix[y*opaque.Stride:], src.Pix[y*src.Stride:y*src.Stride+w*4])
+		}
+		for i := 3; i < len(opaque.Pix); i += 4 {
+			opaque.Pix[i] = 0xff
+		}
+		img = opaque
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+		return nil, err
+	}
+
+	out, err := jpegPassthrough(buf.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	out.SMask = smask
+	return out, nil
+}
+
+// isOpaque сообщает, что у изображения нет прозрачных пикселей
+func isOpaque(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+	return true
+}
+
+func jpegColorSpace(m color.Model) string {
+	switch m {
+	case color.GrayModel:
+		return "DeviceGray"
+	case color.CMYKModel:
+		return "DeviceCMYK"
+	default:
+		return "DeviceRGB"
+	}
+}
+
+// encodeFlate раскладывает изображение на отсчёты для FlateDecode.
+// Непрозрачный альфа-канал не сохраняется, прозрачный уходит в SMask.
+func encodeFlate(img image.Image) *pdfImage {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+
+	out := &pdfImage{Width: w, Height: h, BitsPerComponent: 8, Filter: filter.Flate}
+
+	switch src := img.(type) {
+	case *image.Gray:
+		out.ColorSpace = "DeviceGray"
+		out.Data = packRows(src.Pix, src.Stride, w, h)
+		return out
+
+	case *image.Gray16:
+		out.ColorSpace = "DeviceGray"
+		out.BitsPerComponent = 16
+		out.Data = packRows(src.Pix, src.Stride, w*2, h)
+		return out
+
+	case *image.CMYK:
+		out.ColorSpace = "DeviceCMYK"
+		out.Data = packRows(src.Pix, src.Stride, w*4, h)
+		return out
+
+	case *image.RGBA64, *image.NRGBA64:
+		return encodeFlate16(img)
+	}
+
+	nrgba := toNRGBA(img)
+
+	out.ColorSpace = "DeviceRGB"
+	out.Data = make([]byte, 0, w*h*3)
+	alpha := make([]byte, 0, w*h)
+	opaque := true
+
+	for y := 0; y < h; y++ {
+		row := nrgba.Pix[y*nrgba.Stride : y*nrgba.Stride+w*4]
+		for i := 0; i < len(row); i += 4 {
+			out.Data = append(out.Data, row[i], row[i+1], row[i+2])
+			alpha = append(alpha, row[i+3])
+			if row[i+3] != 0xff {
+				opaque = false
+			}
+		}
+	}
+
+	if !opaque {
+		out.SMask = &pdfImage{
+			Width:            w,
+			Height:           h,
+			BitsPerComponent: 8,
+			ColorSpace:       "DeviceGray",
+			Filter:           filter.Flate,
+			Data:             alpha,
+		}
+	}
+	return out
+}
+
+// encodeFlate16 сохраняет 16-битные цвет и альфу без потери точности
+func encodeFlate16(img image.Image) *pdfImage {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+
+	out := &pdfImage{Width: w, Height: h, BitsPerComponent: 16, ColorSpace: "DeviceRGB", Filter: filter.Flate}
+	out.Data = make([]byte, 0, w*h*6)
+	alpha := make([]byte, 0, w*h*2)
+	opaque := true
+
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.NRGBA64Model.Convert(img.At(x, y)).(color.NRGBA64)
+			out.Data = append(out.Data, byte(c.R>>8), byte(c.R), byte(c.G>>8), byte(c.G), byte(c.B>>8), byte(c.B))
+			alpha = append(alpha, byte(c.A>>8), byte(c.A))
+			if c.A != 0xffff {
+				opaque = false
+			}
+		}
+	}
+
+	if !opaque {
+		out.SMask = &pdfImage{
+			Width:            w,
+			Height:           h,
+			BitsPerComponent: 16,
+			ColorSpace:       "DeviceGray",
+			Filter:           filter.Flate,
+			Data:             alpha,
+		}
+	}
+	return out
+}
+
+// packRows копирует строки пикселей без выравнивания stride
+func packRows(pix []byte, stride, rowLen, h int) []byte {
+	if stride == rowLen {
+		return pix[:rowLen*h]
+	}
+	out := make([]byte, 0, rowLen*h)
+	for y := 0; y < h; y++ {
+		out = append(out, pix[y*stride:y*stride+rowLen]...)
+	}
+	return out
+}
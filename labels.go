@@ -0,0 +1,221 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// Стили номеров страниц, как в словаре /PageLabels
+const (
+	LabelDecimal    = "D"
+	LabelRomanUpper = "R"
+	LabelRomanLower = "r"
+	LabelAlphaUpper = "A"
+	LabelAlphaLower = "a"
+	// LabelNone — только префикс, без номера
+	LabelNone = "-"
+)
+
+var labelStyles = map[string]bool{
+	LabelDecimal:    true,
+	LabelRomanUpper: true,
+	LabelRomanLower: true,
+	LabelAlphaUpper: true,
+	LabelAlphaLower: true,
+	LabelNone:       true,
+}
+
+// labelRange — нумерация страниц результата начиная с Pages ("5" или "5-", "1-4").
+// Номер первой страницы диапазона — Start, по умолчанию 1.
+type labelRange struct {
+	Pages  string `json:"pages"`
+	Style  string `json:"style"`
+	Prefix string `json:"prefix,omitempty"`
+	Start  int    `json:"start,omitempty"`
+
+	// last равен 0 для открытого диапазона
+	first, last int
+}
+
+// pageLabels — диапазоны нумерации по возрастанию первой страницы без пропусков
+type pageLabels []labelRange
+
+// init проверяет диапазон нумерации
+func (r *labelRange) init() error {
+	pages := strings.TrimSpace(r.Pages)
+	var err error
+	if open, ok := strings.CutSuffix(pages, "-"); ok {
+		r.first, _, err = parsePageRange(open)
+		r.last = 0
+	} else {
+		r.first, r.last, err = parsePageRange(pages)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: page labels: %v", ErrInvalidInput, err)
+	}
+	if r.Style == "" {
+		r.Style = LabelDecimal
+	}
+	if !labelStyles[r.Style] {
+		return fmt.Errorf("%w: unknown page label style %q, expected D, R, r, A, a or -", ErrInvalidInput, r.Style)
+	}
+	if r.Start < 0 {
+		return fmt.Errorf("%w: negative page label start %d", ErrInvalidInput, r.Start)
+	}
+	if r.Start == 0 {
+		r.Start = 1
+	}
+	return nil
+}
+
+// parseLabels разбирает -labels: диапазоны через запятую в виде pages:style[:prefix[:start]],
+// например "1-4:r,5-:D" или "20-:D:A-:1"
+func parseLabels(spec string) ([]labelRange, error) {
+	var ranges []labelRange
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		fields := strings.SplitN(entry, ":", 4)
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("%w: page labels %q, expected pages:style[:prefix[:start]]", ErrInvalidInput, entry)
+		}
+		r := labelRange{Pages: fields[0], Style: strings.TrimSpace(fields[1])}
+		if len(fields) > 2 {
+			r.Prefix = fields[2]
+		}
+		if len(fields) > 3 {
+			start, err := strconv.Atoi(strings.TrimSpace(fields[3]))
+			if err != nil {
+				return nil, fmt.Errorf("%w: invalid page label start %q", ErrInvalidInput, fields[3])
+			}
+			r.Start = start
+		}
+		if err := r.init(); err != nil {
+			return nil, err
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges, nil
+}
+
+// newPageLabels упорядочивает диапазоны. Открытый диапазон продолжается до следующего,
+// после закрытого без продолжения страницы нумеруются своими номерами.
+func newPageLabels(ranges []labelRange) (pageLabels, error) {
+	ranges = slices.Clone(ranges)
+	slices.SortStableFunc(ranges, func(a, b labelRange) int { return a.first - b.first })
+
+	var labels pageLabels
+	for i, r := range ranges {
+		if i > 0 {
+			prev := ranges[i-1]
+			if r.first == prev.first || (prev.last > 0 && r.first <= prev.last) {
+				return nil, fmt.Errorf("%w: page label ranges %s and %s overlap", ErrInvalidInput, prev.Pages, r.Pages)
+			}
+		}
+		labels = append(labels, r)
+		if r.last > 0 && (i == len(ranges)-1 || ranges[i+1].first > r.last+1) {
+			labels = append(labels, labelRange{Style: LabelDecimal, Start: r.last + 1, first: r.last + 1})
+		}
+	}
+	return labels, nil
+}
+
+// pageLabels собирает нумерацию из -labels или, если флаг не задан, из манифеста
+func (o Options) pageLabels(m *Manifest) (pageLabels, error) {
+	ranges := m.Labels
+	if strings.TrimSpace(o.Labels) != "" {
+		var err error
+		if ranges, err = parseLabels(o.Labels); err != nil {
+			return nil, err
+		}
+	}
+	return newPageLabels(ranges)
+}
+
+// label возвращает номер страницы page (от 1) так, как его покажет просмотрщик
+func (l pageLabels) label(page int) string {
+	i := len(l) - 1
+	for i >= 0 && l[i].first > page {
+		i--
+	}
+	if i < 0 {
+		return strconv.Itoa(page)
+	}
+	r := l[i]
+	n := r.Start + page - r.first
+	switch r.Style {
+	case LabelRomanUpper:
+		return r.Prefix + roman(n)
+	case LabelRomanLower:
+		return r.Prefix + strings.ToLower(roman(n))
+	case LabelAlphaUpper:
+		return r.Prefix + alphaLabel(n)
+	case LabelAlphaLower:
+		return r.Prefix + strings.ToLower(alphaLabel(n))
+	case LabelNone:
+		return r.Prefix
+	}
+	return r.Prefix + strconv.Itoa(n)
+}
+
+// roman записывает n римскими цифрами
+func roman(n int) string {
+	numerals := []struct {
+		value  int
+		symbol string
+	}{
+		{1000, "M"}, {900, "CM"}, {500, "D"}, {400, "CD"}, {100, "C"}, {90, "XC"},
+		{50, "L"}, {40, "XL"}, {10, "X"}, {9, "IX"}, {5, "V"}, {4, "IV"}, {1, "I"},
+	}
+	var b strings.Builder
+	for _, num := range numerals {
+		for n >= num.value {
+			b.WriteString(num.symbol)
+			n -= num.value
+		}
+	}
+	return b.String()
+}
+
+// alphaLabel записывает n буквами, как в PDF: A..Z, затем AA..ZZ, AAA..ZZZ
+func alphaLabel(n int) string {
+	if n < 1 {
+		return ""
+	}
+	return strings.Repeat(string(rune('A'+(n-1)%26)), (n-1)/26+1)
+}
+
+// setPageLabels записывает в каталог документа дерево /PageLabels
+func (w *pdfWriter) setPageLabels(l pageLabels) {
+	if len(l) == 0 {
+		return
+	}
+	var nums types.Array
+	if l[0].first > 1 {
+		// Дерево должно начинаться с первой страницы
+		nums = append(nums, types.Integer(0), types.Dict(map[string]types.Object{"S": types.Name(LabelDecimal)}))
+	}
+	for _, r := range l {
+		if r.first > w.ctx.PageCount {
+			break
+		}
+		d := types.NewDict()
+		if r.Style != LabelNone {
+			d.Insert("S", types.Name(r.Style))
+		}
+		if r.Prefix != "" {
+			d.Insert("P", pdfString(r.Prefix))
+		}
+		if r.Start != 1 {
+			d.InsertInt("St", r.Start)
+		}
+		nums = append(nums, types.Integer(r.first-1), d)
+	}
+	w.ctx.RootDict.Insert("PageLabels", types.Dict(map[string]types.Object{"Nums": nums}))
+}
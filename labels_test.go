@@ -0,0 +1,128 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+)
+
+func TestPageLabels(t *testing.T) {
+	tests := []struct {
+		spec string
+		want string
+	}{
+		{"", "1 2 3 4 5 6"},
+		{"1-4:r,5-:D", "i ii iii iv 1 2"},
+		{"1-2:R,3-:D:A-", "I II A-1 A-2 A-3 A-4"},
+		// После закрытого диапазона страницы получают свои номера
+		{"2-3:a", "1 a b 4 5 6"},
+		{"1:-:Cover,2-:D::10", "Cover 10 11 12 13 14"},
+		{"5-:A,1-:r", "i ii iii iv A B"},
+	}
+	for _, tt := range tests {
+		ranges, err := parseLabels(tt.spec)
+		if err != nil {
+			t.Errorf("parseLabels(%q) failed: %v", tt.spec, err)
+			continue
+		}
+		labels, err := newPageLabels(ranges)
+		if err != nil {
+			t.Errorf("newPageLabels(%q) failed: %v", tt.spec, err)
+			continue
+		}
+		var got []string
+		for page := 1; page <= 6; page++ {
+			got = append(got, labels.label(page))
+		}
+		if strings.Join(got, " ") != tt.want {
+			t.Errorf("%q: labels = %q; want %q", tt.spec, strings.Join(got, " "), tt.want)
+		}
+	}
+
+	for _, spec := range []string{"1-4", "x:D", "1-4:q", "1-4:r,3-:D", "1-:D,1:r", "1:D::-2"} {
+		ranges, err := parseLabels(spec)
+		if err == nil {
+			_, err = newPageLabels(ranges)
+		}
+		if !IsInvalidInput(err) {
+			t.Errorf("%q: expected ErrInvalidInput, got %v", spec, err)
+		}
+	}
+}
+
+func TestRomanAndAlpha(t *testing.T) {
+	for n, want := range map[int]string{1: "I", 4: "IV", 9: "IX", 14: "XIV", 40: "XL", 1994: "MCMXCIV"} {
+		if got := roman(n); got != want {
+			t.Errorf("roman(%d) = %q; want %q", n, got, want)
+		}
+	}
+	for n, want := range map[int]string{1: "A", 26: "Z", 27: "AA", 28: "BB", 53: "AAA"} {
+		if got := alphaLabel(n); got != want {
+			t.Errorf("alphaLabel(%d) = %q; want %q", n, got, want)
+		}
+	}
+}
+
+func TestConvert_PageLabels(t *testing.T) {
+	tmpDir := t.TempDir()
+	createTree(t, tmpDir, "1.jpg", "2.jpg", "3.jpg", "4.jpg")
+	manifest := filepath.Join(tmpDir, "manifest.json")
+	err := os.WriteFile(manifest, []byte(`{"labels": [
+		{"pages": "1-2", "style": "r"},
+		{"pages": "3-", "prefix": "A-"}
+	]}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := DefaultOptions()
+	opts.Manifest = manifest
+	output := filepath.Join(tmpDir, "out.pdf")
+	converter := NewConverterWithOptions(opts)
+	if err := converter.Convert(tmpDir, output, "nam"); err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+	if err := api.ValidateFile(output, nil); err != nil {
+		t.Fatalf("Output is not a valid PDF: %v", err)
+	}
+
+	var labels []string
+	for _, p := range converter.Report().Pages {
+		labels = append(labels, p.Label)
+	}
+	if got := strings.Join(labels, " "); got != "i ii A-1 A-2" {
+		t.Errorf("Report labels = %q; want %q", got, "i ii A-1 A-2")
+	}
+
+	ctx, err := api.ReadContextFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := ctx.DereferenceDict(ctx.RootDict["PageLabels"])
+	if err != nil || d == nil {
+		t.Fatalf("Catalog has no PageLabels: %v", err)
+	}
+	nums := d.ArrayEntry("Nums")
+	if len(nums) != 4 {
+		t.Fatalf("Nums = %v; want two ranges", nums)
+	}
+	if first, _ := ctx.DereferenceDict(nums[1]); first.NameEntry("S") == nil || *first.NameEntry("S") != "r" {
+		t.Errorf("First range = %v; want style r", nums[1])
+	}
+	if second, _ := ctx.DereferenceDict(nums[3]); second.NameEntry("S") == nil || *second.NameEntry("S") != "D" {
+		t.Errorf("Second range = %v; want style D", nums[3])
+	}
+
+	// Флаг заменяет нумерацию из манифеста
+	opts.Labels = "1-:A"
+	converter = NewConverterWithOptions(opts)
+	if err := converter.Convert(tmpDir, output, "nam"); err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+	if got := converter.Report().Pages[3].Label; got != "D" {
+		t.Errorf("Label of page 4 = %q; want D", got)
+	}
+}
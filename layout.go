@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+)
+
+// rect — прямоугольник в пунктах, начало координат в левом нижнем углу страницы
+type rect struct {
+	X, Y, W, H float64
+}
+
+// pageSizeFor возвращает размер страницы в пунктах для изображения w×h пикселей.
+// В режиме auto страница повторяет размер изображения (1 пиксель = 1 пункт) плюс поля.
+func (o Options) pageSizeFor(w, h int) (float64, float64, error) {
+	dim, err := o.pageDim()
+	if err != nil {
+		return 0, 0, err
+	}
+	if dim == nil {
+		return float64(w) + 2*o.Margin, float64(h) + 2*o.Margin, nil
+	}
+	return dim.Width, dim.Height, nil
+}
+
+// contentBox возвращает область страницы внутри полей
+func (o Options) contentBox(pageW, pageH float64) rect {
+	return rect{
+		X: o.Margin,
+		Y: o.Margin,
+		W: math.Max(pageW-2*o.Margin, 1),
+		H: math.Max(pageH-2*o.Margin, 1),
+	}
+}
+
+// fitRect вписывает изображение w×h в box с сохранением пропорций и центрирует его
+func fitRect(box rect, w, h float64) rect {
+	scale := math.Min(box.W/w, box.H/h)
+	fw, fh := w*scale, h*scale
+	return rect{
+		X: box.X + (box.W-fw)/2,
+		Y: box.Y + (box.H-fh)/2,
+		W: fw,
+		H: fh,
+	}
+}
+
+// drawImageOp возвращает оператор content stream, рисующий XObject name в области r.
+// С flip изображение отражается по горизонтали.
+func drawImageOp(name string, r rect, flip bool) string {
+	if flip {
+		return fmt.Sprintf("q %.4f 0 0 %.4f %.4f %.4f cm /%s Do Q\n", -r.W, r.H, r.X+r.W, r.Y, name)
+	}
+	return fmt.Sprintf("q %.4f 0 0 %.4f %.4f %.4f cm /%s Do Q\n", r.W, r.H, r.X, r.Y, name)
+}
+
+// orientedImageOp рисует XObject name так, что после отражения и поворота o
+// изображение занимает область r. В отличие от /Rotate поворачивается только изображение.
+func orientedImageOp(name string, r rect, o orientation) string {
+	// Матрица переводит единичный квадрат изображения в r
+	a, b, c, d, e, f := r.W, 0.0, 0.0, r.H, r.X, r.Y
+	switch o.Rotate {
+	case 90:
+		a, b, c, d, e, f = 0, -r.H, r.W, 0, r.X, r.Y+r.H
+	case 180:
+		a, b, c, d, e, f = -r.W, 0, 0, -r.H, r.X+r.W, r.Y+r.H
+	case 270:
+		a, b, c, d, e, f = 0, r.H, -r.W, 0, r.X+r.W, r.Y
+	}
+	if o.Flip {
+		// Отражение выполняется до поворота: u заменяется на 1-u
+		a, b, e, f = -a, -b, e+a, f+b
+	}
+	return fmt.Sprintf("q %.4f %.4f %.4f %.4f %.4f %.4f cm /%s Do Q\n", a, b, c, d, e, f, name)
+}
+
+// clipImageOp рисует XObject в области full, оставляя видимой только часть внутри clip
+func clipImageOp(name string, full, clip rect, flip bool) string {
+	return fmt.Sprintf("q %.4f %.4f %.4f %.4f re W n\n", clip.X, clip.Y, clip.W, clip.H) +
+		drawImageOp(name, full, flip) + "Q\n"
+}
+
+// fillRectOp заливает область r цветом col
+func fillRectOp(col color.NRGBA, r rect) string {
+	return fmt.Sprintf("q %.4f %.4f %.4f rg %.4f %.4f %.4f %.4f re f Q\n",
+		float64(col.R)/255, float64(col.G)/255, float64(col.B)/255, r.X, r.Y, r.W, r.H)
+}
+
+// mirror отражает r по горизонтали относительно центра области c
+func (r rect) mirror(c rect) rect {
+	r.X = 2*c.X + c.W - r.X - r.W
+	return r
+}
+
+// union возвращает наименьший прямоугольник, содержащий r и o
+func (r rect) union(o rect) rect {
+	x0, y0 := math.Min(r.X, o.X), math.Min(r.Y, o.Y)
+	x1, y1 := math.Max(r.X+r.W, o.X+o.W), math.Max(r.Y+r.H, o.Y+o.H)
+	return rect{X: x0, Y: y0, W: x1 - x0, H: y1 - y0}
+}
+
+// uncroppedRect возвращает область, которую занимает всё изображение bounds,
+// если его часть crop размещена в place
+func uncroppedRect(place rect, bounds, crop image.Rectangle) rect {
+	scale := place.W / float64(crop.Dx())
+	return rect{
+		X: place.X - float64(crop.Min.X-bounds.Min.X)*scale,
+		// Ось Y в PDF направлена вверх, поэтому отступ считается от нижнего края
+		Y: place.Y - float64(bounds.Max.Y-crop.Max.Y)*scale,
+		W: float64(bounds.Dx()) * scale,
+		H: float64(bounds.Dy()) * scale,
+	}
+}
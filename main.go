@@ -12,6 +12,87 @@ func main() {
 		order  = flag.String("order", "seq", "default sequently order")
 		output = flag.String("o", "output.pdf", "Output PDF file path")
 		help   = flag.Bool("help", false, "Show help")
+
+		animation = flag.String("animation", "first", "Frames of animated GIF and WebP images: first, all or a frame range like 2-5")
+
+		pageSize = flag.String("page-size", "auto", "Page size: auto, A4, A4L, Letter, ...")
+		margin   = flag.Float64("margin", 0, "Page margin in millimetres")
+		dpi      = flag.Int("dpi", 0, "Downsample images to this resolution on the page")
+		maxDim   = flag.Int("max-dimension", 0, "Downsample images so the longest side fits in this many pixels")
+		resample = flag.String("resample", "catmullrom", "Resample filter: nearest, bilinear, catmullrom, lanczos")
+
+		rotate        = flag.String("rotate", "", "Rotate pages clockwise: degrees or auto, optionally per glob or page: \"scan_0*.jpg=90;3=180\"")
+		flip          = flag.String("flip", "", "Flip pages: h, v or hv, optionally per glob or page: \"back_*.jpg=h\"")
+		manifest      = flag.String("manifest", "", "JSON manifest with per-page settings")
+		nup           = flag.String("nup", "", "Place several images per page in a columns x rows grid, e.g. 2x3")
+		gutter        = flag.Float64("gutter", 0, "Space between -nup cells in millimetres")
+		caption       = flag.String("caption", "", "Captions under -nup cells: name, date or name,date")
+		fill          = flag.String("fill", "row", "Fill order of the -nup grid: row, column")
+		booklet       = flag.Bool("booklet", false, "Impose pages two per landscape sheet in saddle-stitch booklet order")
+		removeBlank   = flag.Bool("remove-blank", false, "Remove blank pages, e.g. empty backs of duplex scans")
+		blankThresh   = flag.Float64("blank-threshold", 0.2, "Ink coverage in percent below which -remove-blank treats a page as blank")
+		blankMargin   = flag.Float64("blank-margin", 5, "Border band in percent of each side that -remove-blank ignores")
+		blankEvery    = flag.Int("blank-every", 0, "Insert a separator page after every N pages")
+		separate      = flag.String("separate", "none", "Separate input groups: none, page, odd (start each group on an odd page)")
+		sepColor      = flag.String("separator-color", "white", "Separator page color: name, #RRGGBB or \"r g b\"")
+		split         = flag.String("split", "none", "Split images into pages: none, spread (two facing pages)")
+		rtl           = flag.Bool("rtl", false, "Right-to-left reading order: the right half of a spread comes first")
+		deskew        = flag.Bool("deskew", false, "Straighten slightly rotated scans")
+		maxSkew       = flag.Float64("max-skew", 5, "Largest skew angle in degrees that -deskew corrects")
+		crop          = flag.String("crop", "", "Crop: [pages:]left,top,right,bottom in pixels or percent, entries separated by ';'")
+		autoCrop      = flag.Bool("autocrop", false, "Trim white or black scan borders")
+		cropTolerance = flag.Int("crop-tolerance", 32, "Brightness tolerance for -autocrop borders (0-255)")
+		cropPadding   = flag.Int("crop-padding", 0, "Pixels to keep around the content found by -autocrop")
+
+		alpha      = flag.String("alpha", "keep", "Transparency: keep (soft mask), flatten (onto -background), white")
+		background = flag.String("background", "white", "Background color for -alpha flatten: name, #RRGGBB or \"r g b\"")
+
+		colorMode = flag.String("color", "color", "Color mode: color, gray, bw (1-bit, CCITT G4)")
+		threshold = flag.Int("threshold", 0, "Threshold for -color bw (1-255), 0 picks it automatically (Otsu)")
+		dither    = flag.Bool("dither", false, "Dither -color bw pages instead of thresholding")
+
+		compression = flag.String("compression", "keep", "Compression policy: keep, flate, jpeg, auto")
+		quality     = flag.Int("quality", 90, "JPEG quality for re-encoded images (1-100)")
+
+		title    = flag.String("title", "", "Document title")
+		author   = flag.String("author", "", "Document author")
+		subject  = flag.String("subject", "", "Document subject")
+		keywords = flag.String("keywords", "", "Document keywords, comma-separated")
+		creator  = flag.String("creator", "", "Application that created the original content")
+		created  = flag.String("created", "", "Creation date: now, earliest, latest (image times) or 2006-01-02[T15:04[:05]]")
+		modified = flag.String("modified", "", "Modification date: now, earliest, latest (image times) or 2006-01-02[T15:04[:05]]")
+
+		userPW      = flag.String("user-password", "", "Password to open the PDF: TEXT, pass:TEXT, env:NAME or file:PATH")
+		ownerPW     = flag.String("owner-password", "", "Password for full access, same forms as -user-password")
+		encryptAlg  = flag.String("encryption", "aes256", "Encryption algorithm: aes128, aes256")
+		permissions = flag.String("permissions", "print", "What a reader with the user password may do: print, copy, modify, annotate, all, none")
+
+		wmText     = flag.String("watermark-text", "", "Watermark text")
+		wmImage    = flag.String("watermark-image", "", "Watermark image file, e.g. a PNG logo with transparency")
+		wmRotate   = flag.Float64("watermark-rotate", 45, "Watermark rotation in degrees, counterclockwise")
+		wmOpacity  = flag.Float64("watermark-opacity", 0.3, "Watermark opacity (0-1]")
+		wmScale    = flag.Float64("watermark-scale", 0.5, "Watermark width as a fraction of the page width")
+		wmPosition = flag.String("watermark-position", "center", "Watermark position: center, top, bottom, left, right, top-left, ...")
+		wmTile     = flag.Bool("watermark-tile", false, "Repeat the watermark across the whole page")
+		wmLayer    = flag.String("watermark-layer", "over", "Draw the watermark over or under the page image")
+		wmColor    = flag.String("watermark-color", "gray", "Watermark text color: name, #RRGGBB or \"r g b\"")
+		wmPages    = flag.String("watermark-pages", "", "Pages to watermark, e.g. \"1-3,5,8-\" (default all)")
+
+		header       = flag.String("header", "", "Header template: {page}, {pages}, {file}, {date}, {exif.date}")
+		footer       = flag.String("footer", "", "Footer template, e.g. \"Page {page} of {pages}\"")
+		stampFont    = flag.String("stamp-font", "Helvetica", "Header and footer font: Helvetica, Times-Roman, Courier and their variants")
+		stampSize    = flag.Int("stamp-size", 9, "Header and footer font size in points")
+		stampAlign   = flag.String("stamp-align", "center", "Header and footer alignment: left, center, right")
+		stampMargin  = flag.Float64("stamp-margin", 8, "Distance of the header and footer from the page edge in millimetres")
+		stampOpacity = flag.Float64("stamp-opacity", 1, "Header and footer opacity (0-1]")
+		stampColor   = flag.String("stamp-color", "black", "Header and footer color: name, #RRGGBB or \"r g b\"")
+
+		labels    = flag.String("labels", "", "Page labels: ranges pages:style[:prefix[:start]], e.g. \"1-4:r,5-:D\" (styles D, R, r, A, a, -)")
+		bookmarks = flag.String("bookmarks", "none", "Document outline: none, dir (nested by directory), file (one per file), manifest")
+		pdfa      = flag.String("pdfa", "", "Archival PDF/A conformance level: 2b")
+
+		dryRun = flag.Bool("dry-run", false, "Print the conversion plan without writing the PDF")
+		report = flag.String("report", "", "Write a JSON conversion report to this file")
 	)
 	flag.Parse()
 
@@ -25,32 +106,281 @@ func main() {
 		return
 	}
 
-	converter := NewConverter()
+	opts := DefaultOptions()
+	opts.Animation = *animation
+	opts.PageSize = *pageSize
+	opts.Margin = *margin * mmToPoints
+	opts.DPI = *dpi
+	opts.MaxDimension = *maxDim
+	opts.Resample = *resample
+	opts.Rotate = *rotate
+	opts.Flip = *flip
+	opts.Manifest = *manifest
+	opts.Nup = *nup
+	opts.Gutter = *gutter * mmToPoints
+	opts.Caption = *caption
+	opts.Fill = *fill
+	opts.Booklet = *booklet
+	opts.RemoveBlank = *removeBlank
+	opts.BlankThreshold = *blankThresh
+	opts.BlankMargin = *blankMargin
+	opts.BlankEvery = *blankEvery
+	opts.Separate = *separate
+	opts.SeparatorColor = *sepColor
+	opts.Split = *split
+	opts.RTL = *rtl
+	opts.Deskew = *deskew
+	opts.MaxSkew = *maxSkew
+	opts.Crop = *crop
+	opts.AutoCrop = *autoCrop
+	opts.CropTolerance = *cropTolerance
+	opts.CropPadding = *cropPadding
+	opts.Alpha = *alpha
+	opts.Background = *background
+	opts.Color = *colorMode
+	opts.Threshold = *threshold
+	opts.Dither = *dither
+	opts.Compression = *compression
+	opts.Quality = *quality
+	opts.Title = *title
+	opts.Author = *author
+	opts.Subject = *subject
+	opts.Keywords = *keywords
+	opts.Creator = *creator
+	opts.Created = *created
+	opts.Modified = *modified
+	opts.UserPassword = *userPW
+	opts.OwnerPassword = *ownerPW
+	opts.Encryption = *encryptAlg
+	opts.Permissions = *permissions
+	opts.WatermarkText = *wmText
+	opts.WatermarkImage = *wmImage
+	opts.WatermarkRotate = *wmRotate
+	opts.WatermarkOpacity = *wmOpacity
+	opts.WatermarkScale = *wmScale
+	opts.WatermarkPosition = *wmPosition
+	opts.WatermarkTile = *wmTile
+	opts.WatermarkLayer = *wmLayer
+	opts.WatermarkColor = *wmColor
+	opts.WatermarkPages = *wmPages
+	opts.Header = *header
+	opts.Footer = *footer
+	opts.StampFont = *stampFont
+	opts.StampSize = *stampSize
+	opts.StampAlign = *stampAlign
+	opts.StampMargin = *stampMargin * mmToPoints
+	opts.StampOpacity = *stampOpacity
+	opts.StampColor = *stampColor
+	opts.Labels = *labels
+	opts.Bookmarks = *bookmarks
+	opts.PDFA = *pdfa
+	opts.DryRun = *dryRun
+	opts.ReportPath = *report
+
+	converter := NewConverterWithOptions(opts)
 	if err := converter.Convert(*input, *output, *order); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 
+	if *dryRun {
+		converter.Report().Print(os.Stdout)
+		return
+	}
+
 	fmt.Printf("Successfully converted to %s\n", *output)
 }
 
 func printUsage() {
 	fmt.Println("Image to PDF Converter")
-	fmt.Println("\nSupported formats: JPG, JPEG, PNG, WEBP, TIFF")
+	fmt.Println("\nSupported formats: JPG, JPEG, PNG, WEBP, TIFF, GIF, BMP, SVG")
 	fmt.Println("\nUsage:")
 	fmt.Println("  ./img2pdf -i <directory|files> -o <pdf_file> -order <order type>")
 	fmt.Println("\nExamples:")
 	fmt.Println("  ./img2pdf -i images/")
 	fmt.Println("  ./img2pdf -i \"image1.jpg,photo.png,scan.tiff\" -o result.pdf")
 	fmt.Println("  ./img2pdf -i \"images/,photo.jpg,scan.png\" -o result.pdf -order mod")
+	fmt.Println("  ./img2pdf -i duplex_scan/ -o letter.pdf -order duplex")
+	fmt.Println("  ./img2pdf -i duplex_scan/ -order duplex -remove-blank -report report.json")
+	fmt.Println("  ./img2pdf -i photos/ -page-size A4 -margin 10 -dpi 150")
+	fmt.Println("  ./img2pdf -i screenshots/ -compression auto -quality 80 -dry-run")
+	fmt.Println("  ./img2pdf -i scans/ -deskew -autocrop -crop-padding 20")
+	fmt.Println("  ./img2pdf -i scans/ -rotate \"scan_0*.jpg=90;back_*.jpg=180\" -flip \"mirror.png=h\"")
+	fmt.Println("  ./img2pdf -i book/ -split spread -page-size A5")
+	fmt.Println("  ./img2pdf -i proofs/ -nup 2x3 -gutter 5 -margin 10 -caption name,date")
+	fmt.Println("  ./img2pdf -i zine/ -booklet -page-size A4 -gutter 10")
+	fmt.Println("  ./img2pdf -i \"letter/,invoice/\" -separate odd -o print.pdf")
+	fmt.Println("  ./img2pdf -i invoices/ -color bw")
+	fmt.Println("  ./img2pdf -i \"fax.tif#2-5,cover.tif#1\" -o faxes.pdf")
+	fmt.Println("  ./img2pdf -i diagrams/ -animation all")
+	fmt.Println("  ./img2pdf -i \"scans/,diagrams/\" -page-size A4 -margin 20")
+	fmt.Println("  ./img2pdf -i drafts/ -watermark-text DRAFT -watermark-tile -watermark-scale 0.3")
+	fmt.Println("  ./img2pdf -i contracts/ -user-password env:PDF_PASSWORD -permissions print")
+	fmt.Println("  ./img2pdf -i trip/ -title \"Summer 2024\" -author \"J. Doe\" -created earliest -modified latest")
 	fmt.Println("\nNote: The -i flag accepts both directories and individual files (comma-separated)")
+	fmt.Println("      Select frames of a multi-page TIFF or an animated image with #: scan.tif#2, scan.tif#2-5, anim.gif#3-")
 	fmt.Println("\nOptions:")
 	fmt.Println("  -i string")
 	fmt.Println("    \tInput directory or JPG files (space-separated)")
 	fmt.Println("  -o string")
 	fmt.Println("    \tOutput PDF file path (default \"output.pdf\")")
 	fmt.Println("  -order string")
-	fmt.Println("    \tSorting order for images: seq (sequential), nam (by name), mod (by modification time) (default \"seq\")")
+	fmt.Println("    \tSorting order for images: seq (sequential), nam (by name), mod (by modification time),")
+	fmt.Println("    \tduplex (all fronts, then all backs in reverse, as a sheet-feed scanner produces them) (default \"seq\")")
+	fmt.Println("  -animation string")
+	fmt.Println("    \tFrames of animated GIF and WebP images: first (the first frame), all (every frame as a page) or a frame")
+	fmt.Println("    \trange like 2-5 or 3- (default \"first\")")
+	fmt.Println("  -page-size string")
+	fmt.Println("    \tPage size: auto (page matches the image), A4, A4L, Letter, ... (default \"auto\")")
+	fmt.Println("  -margin float")
+	fmt.Println("    \tPage margin in millimetres")
+	fmt.Println("  -dpi int")
+	fmt.Println("    \tDownsample images to this resolution on the page, images are never upscaled")
+	fmt.Println("  -max-dimension int")
+	fmt.Println("    \tDownsample images so the longest side fits in this many pixels")
+	fmt.Println("  -resample string")
+	fmt.Println("    \tResample filter: nearest, bilinear, catmullrom, lanczos (default \"catmullrom\")")
+	fmt.Println("  -rotate string")
+	fmt.Println("    \tRotate pages clockwise by degrees, or auto to match the page orientation.")
+	fmt.Println("    \tPrefix with a glob or page range to rotate only them, separate entries with ';': \"scan_0*.jpg=90;3-4=180\"")
+	fmt.Println("  -flip string")
+	fmt.Println("    \tFlip pages: h (horizontal), v (vertical) or hv, optionally per glob or page: \"back_*.jpg=h\"")
+	fmt.Println("  -manifest string")
+	fmt.Println("    \tJSON manifest with per-page settings (rotate, flip, bookmark) selected by glob or page range,")
+	fmt.Println("    \tseparator pages at explicit positions and page labels")
+	fmt.Println("  -nup string")
+	fmt.Println("    \tPlace several images per page in a columns x rows grid, e.g. 2x3 (A4 when -page-size is auto)")
+	fmt.Println("  -gutter float")
+	fmt.Println("    \tSpace between -nup cells in millimetres")
+	fmt.Println("  -caption string")
+	fmt.Println("    \tCaptions under -nup cells: name (file name), date (EXIF date or modification time) or name,date")
+	fmt.Println("  -fill string")
+	fmt.Println("    \tFill order of the -nup grid: row (left to right), column (top to bottom) (default \"row\")")
+	fmt.Println("  -booklet")
+	fmt.Println("    \tImpose pages two per landscape sheet in saddle-stitch order, padded with blank pages to a multiple of 4.")
+	fmt.Println("    \t-page-size is the sheet size (A4 when auto), -gutter is the space at the fold")
+	fmt.Println("  -remove-blank")
+	fmt.Println("    \tRemove blank pages, e.g. empty backs of duplex scans; removed files are listed in the report")
+	fmt.Println("  -blank-threshold float")
+	fmt.Println("    \tInk coverage in percent below which -remove-blank treats a page as blank (default 0.2)")
+	fmt.Println("  -blank-margin float")
+	fmt.Println("    \tBorder band in percent of each side that -remove-blank ignores (default 5)")
+	fmt.Println("  -blank-every int")
+	fmt.Println("    \tInsert a separator page after every N pages")
+	fmt.Println("  -separate string")
+	fmt.Println("    \tSeparate input groups (each -i entry or directory): none, page (a separator page),")
+	fmt.Println("    \todd (a blank page where needed so each group starts on an odd page) (default \"none\")")
+	fmt.Println("  -separator-color string")
+	fmt.Println("    \tSeparator page color: name, #RRGGBB or \"r g b\"; white pages are left empty (default \"white\")")
+	fmt.Println("  -split string")
+	fmt.Println("    \tSplit images into pages: none, spread (cut landscape two-page spreads at the gutter) (default \"none\")")
+	fmt.Println("  -rtl")
+	fmt.Println("    \tRight-to-left reading order: the right half of a spread comes first")
+	fmt.Println("  -deskew")
+	fmt.Println("    \tStraighten slightly rotated scans, the detected angle is shown in the report")
+	fmt.Println("  -max-skew float")
+	fmt.Println("    \tLargest skew angle in degrees that -deskew corrects (default 5)")
+	fmt.Println("  -crop string")
+	fmt.Println("    \tCrop left,top,right,bottom in pixels or percent (10%); one value crops all sides.")
+	fmt.Println("    \tPrefix with pages to crop only them, separate entries with ';': \"5%;1:0,0,0,200;3-4:40\"")
+	fmt.Println("  -autocrop")
+	fmt.Println("    \tTrim white or black scan borders")
+	fmt.Println("  -crop-tolerance int")
+	fmt.Println("    \tBrightness tolerance for -autocrop borders, 0-255 (default 32)")
+	fmt.Println("  -crop-padding int")
+	fmt.Println("    \tPixels to keep around the content found by -autocrop")
+	fmt.Println("  -alpha string")
+	fmt.Println("    \tTransparency: keep (soft mask), flatten (onto -background), white (default \"keep\")")
+	fmt.Println("  -background string")
+	fmt.Println("    \tBackground color for -alpha flatten: name, #RRGGBB or \"r g b\" (default \"white\")")
+	fmt.Println("  -color string")
+	fmt.Println("    \tColor mode: color, gray, bw (1-bit pages with CCITT G4 compression) (default \"color\")")
+	fmt.Println("  -threshold int")
+	fmt.Println("    \tThreshold for -color bw, 1-255; 0 picks it automatically (Otsu)")
+	fmt.Println("  -dither")
+	fmt.Println("    \tDither -color bw pages (Floyd-Steinberg) instead of thresholding")
+	fmt.Println("  -compression string")
+	fmt.Println("    \tCompression policy: keep (JPEG as is, the rest lossless), flate, jpeg, auto (default \"keep\")")
+	fmt.Println("  -quality int")
+	fmt.Println("    \tJPEG quality for re-encoded images, 1-100 (default 90)")
+	fmt.Println("  -title string")
+	fmt.Println("    \tDocument title")
+	fmt.Println("  -author string")
+	fmt.Println("    \tDocument author")
+	fmt.Println("  -subject string")
+	fmt.Println("    \tDocument subject")
+	fmt.Println("  -keywords string")
+	fmt.Println("    \tDocument keywords, comma-separated")
+	fmt.Println("  -creator string")
+	fmt.Println("    \tApplication that created the original content, e.g. the scanner software")
+	fmt.Println("  -created string")
+	fmt.Println("    \tCreation date: now, earliest or latest image time (EXIF date or file time), or 2006-01-02[T15:04[:05]]")
+	fmt.Println("  -modified string")
+	fmt.Println("    \tModification date, same values as -created")
+	fmt.Println("  -user-password string")
+	fmt.Println("    \tPassword needed to open the PDF. Given as TEXT, pass:TEXT, env:NAME (environment")
+	fmt.Println("    \tvariable) or file:PATH (first line of the file). Passwords starting with env:, file:")
+	fmt.Println("    \tor pass: must be written as pass:TEXT")
+	fmt.Println("  -owner-password string")
+	fmt.Println("    \tPassword for full access, same forms as -user-password (default random)")
+	fmt.Println("  -encryption string")
+	fmt.Println("    \tEncryption algorithm: aes128, aes256 (default \"aes256\")")
+	fmt.Println("  -permissions string")
+	fmt.Println("    \tComma-separated actions allowed with the user password: print, copy, modify, annotate,")
+	fmt.Println("    \tall or none (default \"print\")")
+	fmt.Println("  -watermark-text string")
+	fmt.Println("    \tWatermark text")
+	fmt.Println("  -watermark-image string")
+	fmt.Println("    \tWatermark image file, e.g. a PNG logo with transparency")
+	fmt.Println("  -watermark-rotate float")
+	fmt.Println("    \tWatermark rotation in degrees, counterclockwise (default 45)")
+	fmt.Println("  -watermark-opacity float")
+	fmt.Println("    \tWatermark opacity, 0-1 (default 0.3)")
+	fmt.Println("  -watermark-scale float")
+	fmt.Println("    \tWatermark width as a fraction of the page width (default 0.5)")
+	fmt.Println("  -watermark-position string")
+	fmt.Println("    \tWatermark position: center, top, bottom, left, right, top-left, top-right, bottom-left,")
+	fmt.Println("    \tbottom-right (default \"center\")")
+	fmt.Println("  -watermark-tile")
+	fmt.Println("    \tRepeat the watermark across the whole page instead of placing it once")
+	fmt.Println("  -watermark-layer string")
+	fmt.Println("    \tDraw the watermark over or under the page image (default \"over\")")
+	fmt.Println("  -watermark-color string")
+	fmt.Println("    \tWatermark text color: name, #RRGGBB or \"r g b\" (default \"gray\")")
+	fmt.Println("  -watermark-pages string")
+	fmt.Println("    \tPages of the result to watermark, e.g. \"1-3,5,8-\" (default all)")
+	fmt.Println("  -header string")
+	fmt.Println("    \tHeader template with placeholders {page} (page label), {pages}, {file} (first image on")
+	fmt.Println("    \tthe page), {date} (conversion time) and {exif.date} (EXIF date or modification time)")
+	fmt.Println("  -footer string")
+	fmt.Println("    \tFooter template, same placeholders as -header, e.g. \"Page {page} of {pages}\"")
+	fmt.Println("  -stamp-font string")
+	fmt.Println("    \tHeader and footer font: Helvetica, Times-Roman, Courier and their -Bold, -Oblique/-Italic")
+	fmt.Println("    \tvariants (default \"Helvetica\")")
+	fmt.Println("  -stamp-size int")
+	fmt.Println("    \tHeader and footer font size in points (default 9)")
+	fmt.Println("  -stamp-align string")
+	fmt.Println("    \tHeader and footer alignment: left, center, right (default \"center\")")
+	fmt.Println("  -stamp-margin float")
+	fmt.Println("    \tDistance of the header and footer from the page edge in millimetres (default 8)")
+	fmt.Println("  -stamp-opacity float")
+	fmt.Println("    \tHeader and footer opacity, 0-1 (default 1)")
+	fmt.Println("  -stamp-color string")
+	fmt.Println("    \tHeader and footer color: name, #RRGGBB or \"r g b\" (default \"black\")")
+	fmt.Println("  -labels string")
+	fmt.Println("    \tPage numbers shown by viewers: comma-separated ranges pages:style[:prefix[:start]],")
+	fmt.Println("    \te.g. \"1-4:r,5-:D\" or \"1-4:r,5-:D,20-:D:A-\". Styles: D (1, 2), R (I, II), r (i, ii),")
+	fmt.Println("    \tA (A, B), a (a, b), - (prefix only)")
+	fmt.Println("  -bookmarks string")
+	fmt.Println("    \tDocument outline: none, dir (a bookmark per directory, nested by path), file (a bookmark")
+	fmt.Println("    \tper file named after it) or manifest (bookmark titles from -manifest) (default \"none\")")
+	fmt.Println("  -pdfa string")
+	fmt.Println("    \tWrite archival PDF/A: 2b embeds an sRGB output intent and XMP identification, flattens")
+	fmt.Println("    \ttransparency and checks the result, reporting requirements that are not met")
+	fmt.Println("  -dry-run")
+	fmt.Println("    \tPrint the per-page conversion plan without writing the PDF")
+	fmt.Println("  -report string")
+	fmt.Println("    \tWrite a JSON conversion report to this file")
 	fmt.Println("  -help")
 	fmt.Println("    \tShow this help message")
 }
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// pageRule — настройки для изображений, выбранных шаблоном имени (Match)
+// или номерами во входном списке (Pages: 3 или 2-5). Пустой выбор подходит всем.
+type pageRule struct {
+	Match  string `json:"match,omitempty"`
+	Pages  string `json:"pages,omitempty"`
+	Rotate string `json:"rotate,omitempty"`
+	Flip   string `json:"flip,omitempty"`
+	// Bookmark — заголовок закладки для -bookmarks file и manifest
+	Bookmark string `json:"bookmark,omitempty"`
+
+	first, last int
+}
+
+// Manifest — JSON-файл с настройками отдельных страниц (-manifest)
+type Manifest struct {
+	Pages      []pageRule      `json:"pages"`
+	Separators []separatorRule `json:"separators,omitempty"`
+	Labels     []labelRange    `json:"labels,omitempty"`
+}
+
+// LoadManifest читает и проверяет манифест
+func LoadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: manifest: %v", ErrInvalidInput, err)
+	}
+
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("%w: manifest %s: %v", ErrInvalidInput, path, err)
+	}
+	for i := range m.Pages {
+		if err := m.Pages[i].init(); err != nil {
+			return nil, err
+		}
+	}
+	for _, s := range m.Separators {
+		if s.After < 0 {
+			return nil, fmt.Errorf("%w: manifest %s: negative separator position %d", ErrInvalidInput, path, s.After)
+		}
+		if s.Color != "" {
+			if _, err := parseColor(s.Color); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for i := range m.Labels {
+		if err := m.Labels[i].init(); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := newPageLabels(m.Labels); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// init проверяет правило и разбирает диапазон страниц
+func (r *pageRule) init() error {
+	if r.Match != "" {
+		if _, err := filepath.Match(r.Match, ""); err != nil {
+			return fmt.Errorf("%w: invalid pattern %q", ErrInvalidInput, r.Match)
+		}
+	}
+	if r.Pages != "" {
+		first, last, err := parsePageRange(r.Pages)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidInput, err)
+		}
+		r.first, r.last = first, last
+	}
+	if _, _, err := parseRotation(r.Rotate); err != nil {
+		return err
+	}
+	if _, err := parseFlip(r.Flip); err != nil {
+		return err
+	}
+	return nil
+}
+
+// matches сообщает, подходит ли правило изображению path с номером index (от 1)
+func (r pageRule) matches(path string, index int) bool {
+	if r.first > 0 && (index < r.first || index > r.last) {
+		return false
+	}
+	if r.Match == "" {
+		return true
+	}
+	// Шаблон с разделителем пути сравнивается с путём целиком, иначе — с именем файла
+	if strings.ContainsRune(r.Match, '/') || strings.ContainsRune(r.Match, filepath.Separator) {
+		ok, _ := filepath.Match(filepath.Clean(r.Match), filepath.Clean(path))
+		return ok
+	}
+	ok, _ := filepath.Match(r.Match, filepath.Base(path))
+	return ok
+}
+
+// merge переносит в r заданные в next значения
+func (r *pageRule) merge(next pageRule) {
+	if next.Rotate != "" {
+		r.Rotate = next.Rotate
+	}
+	if next.Flip != "" {
+		r.Flip = next.Flip
+	}
+	if next.Bookmark != "" {
+		r.Bookmark = next.Bookmark
+	}
+}
+
+// parseRuleFlag разбирает флаг вида "[selector=]value;...", где selector — шаблон имени
+// файла, номер или диапазон страниц. set записывает значение в правило.
+func parseRuleFlag(spec string, set func(*pageRule, string)) ([]pageRule, error) {
+	var rules []pageRule
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		var rule pageRule
+		value := entry
+		if selector, v, ok := strings.Cut(entry, "="); ok {
+			selector = strings.TrimSpace(selector)
+			if _, _, err := parsePageRange(selector); err == nil {
+				rule.Pages = selector
+			} else {
+				rule.Match = selector
+			}
+			value = v
+		}
+		set(&rule, strings.TrimSpace(value))
+
+		if err := rule.init(); err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// loadManifest читает манифест из параметров или возвращает пустой
+func (o Options) loadManifest() (*Manifest, error) {
+	if o.Manifest == "" {
+		return &Manifest{}, nil
+	}
+	return LoadManifest(o.Manifest)
+}
+
+// pageRules собирает правила манифеста m и флагов -rotate и -flip.
+// Более поздние правила переопределяют ранние, флаги важнее манифеста.
+func (o Options) pageRules(m *Manifest) ([]pageRule, error) {
+	rules := slices.Clone(m.Pages)
+
+	rotate, err := parseRuleFlag(o.Rotate, func(r *pageRule, v string) { r.Rotate = v })
+	if err != nil {
+		return nil, err
+	}
+	flip, err := parseRuleFlag(o.Flip, func(r *pageRule, v string) { r.Flip = v })
+	if err != nil {
+		return nil, err
+	}
+	return append(append(rules, rotate...), flip...), nil
+}
+
+// ruleFor объединяет все правила, подходящие изображению
+func ruleFor(rules []pageRule, path string, index int) pageRule {
+	var result pageRule
+	for _, r := range rules {
+		if r.matches(path, index) {
+			result.merge(r)
+		}
+	}
+	return result
+}
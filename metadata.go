@@ -0,0 +1,215 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// Источники дат документа (-created, -modified)
+const (
+	// DateNow — время конвертации
+	DateNow = "now"
+	// DateEarliest — самое раннее время съёмки или изменения изображений
+	DateEarliest = "earliest"
+	// DateLatest — самое позднее время съёмки или изменения изображений
+	DateLatest = "latest"
+)
+
+// dateLayouts — форматы явно заданных дат, время без зоны считается местным
+var dateLayouts = []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02"}
+
+// producer записывается в Info и XMP
+var producer = "img2pdf (pdfcpu " + model.VersionStr + ")"
+
+// docInfo — метаданные документа для словаря Info и XMP
+type docInfo struct {
+	Title, Author, Subject, Keywords, Creator string
+	Created, Modified                         time.Time
+	// PDFA — заявленный уровень PDF/A, например 2b
+	PDFA string
+}
+
+// parseDate разбирает дату документа. Для now, earliest и latest время берётся из
+// now и из диапазона времени изображений first..last.
+func parseDate(s string, now, first, last time.Time) (time.Time, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", DateNow:
+		return now, nil
+	case DateEarliest:
+		return first, nil
+	case DateLatest:
+		return last, nil
+	}
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, strings.TrimSpace(s), time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%w: invalid date %q, expected now, earliest, latest or 2006-01-02[T15:04[:05]]", ErrInvalidInput, s)
+}
+
+// hasMetadata сообщает, что документу нужны метаданные помимо тех, что пишет pdfcpu
+func (o Options) hasMetadata() bool {
+	// PDF/A требует XMP в любом случае
+	return o.Title != "" || o.Author != "" || o.Subject != "" || o.Keywords != "" ||
+		o.Creator != "" || o.Created != "" || o.Modified != "" || o.PDFA != ""
+}
+
+// docInfo собирает метаданные документа. first и last — диапазон времени изображений.
+func (o Options) docInfo(first, last time.Time) (*docInfo, error) {
+	// Даты в PDF хранятся с точностью до секунды
+	now := time.Now().Truncate(time.Second)
+	if first.IsZero() {
+		first, last = now, now
+	}
+	created, err := parseDate(o.Created, now, first, last)
+	if err != nil {
+		return nil, err
+	}
+	modified, err := parseDate(o.Modified, now, first, last)
+	if err != nil {
+		return nil, err
+	}
+	return &docInfo{
+		Title:    o.Title,
+		Author:   o.Author,
+		Subject:  o.Subject,
+		Keywords: o.Keywords,
+		Creator:  o.Creator,
+		Created:  created,
+		Modified: modified,
+		PDFA:     o.PDFA,
+	}, nil
+}
+
+// keywords возвращает ключевые слова, перечисленные через запятую
+func (m *docInfo) keywords() []string {
+	var words []string
+	for _, w := range strings.Split(m.Keywords, ",") {
+		if w = strings.TrimSpace(w); w != "" {
+			words = append(words, w)
+		}
+	}
+	return words
+}
+
+// infoDict возвращает словарь Info
+func (m *docInfo) infoDict() types.Dict {
+	d := types.NewDict()
+	for _, f := range []struct{ key, value string }{
+		{"Title", m.Title},
+		{"Author", m.Author},
+		{"Subject", m.Subject},
+		{"Keywords", strings.Join(m.keywords(), ", ")},
+		{"Creator", m.Creator},
+	} {
+		if f.value != "" {
+			d.Insert(f.key, pdfString(f.value))
+		}
+	}
+	d.InsertString("Producer", producer)
+	d.InsertString("CreationDate", types.DateString(m.Created))
+	d.InsertString("ModDate", types.DateString(m.Modified))
+	return d
+}
+
+// pdfString кодирует текстовую строку PDF: ASCII как есть, остальное в UTF-16BE
+func pdfString(s string) types.Object {
+	for _, r := range s {
+		if r >= 0x80 {
+			return types.NewHexLiteral([]byte(types.EncodeUTF16String(s)))
+		}
+	}
+	escaped, _ := types.Escape(s)
+	return types.StringLiteral(*escaped)
+}
+
+// xmp возвращает пакет XMP с теми же сведениями, что и Info
+func (m *docInfo) xmp() []byte {
+	var b strings.Builder
+	esc := func(s string) string {
+		var e strings.Builder
+		xml.EscapeText(&e, []byte(s))
+		return e.String()
+	}
+	date := func(t time.Time) string { return t.Format(time.RFC3339) }
+
+	b.WriteString("<?xpacket begin=\"\ufeff\" id=\"W5M0MpCehiHzreSzNTczkc9d\"?>\n")
+	b.WriteString("<x:xmpmeta xmlns:x=\"adobe:ns:meta/\">\n")
+	b.WriteString(" <rdf:RDF xmlns:rdf=\"http://www.w3.org/1999/02/22-rdf-syntax-ns#\">\n")
+	b.WriteString("  <rdf:Description rdf:about=\"\"\n")
+	b.WriteString("    xmlns:dc=\"http://purl.org/dc/elements/1.1/\"\n")
+	b.WriteString("    xmlns:xmp=\"http://ns.adobe.com/xap/1.0/\"\n")
+	b.WriteString("    xmlns:pdf=\"http://ns.adobe.com/pdf/1.3/\"")
+	if m.PDFA != "" {
+		b.WriteString("\n    xmlns:pdfaid=\"http://www.aiim.org/pdfa/ns/id/\"")
+	}
+	b.WriteString(">\n")
+	b.WriteString("   <dc:format>application/pdf</dc:format>\n")
+	if m.PDFA != "" {
+		fmt.Fprintf(&b, "   <pdfaid:part>%s</pdfaid:part>\n", m.PDFA[:1])
+		fmt.Fprintf(&b, "   <pdfaid:conformance>%s</pdfaid:conformance>\n", strings.ToUpper(m.PDFA[1:]))
+	}
+	if m.Title != "" {
+		fmt.Fprintf(&b, "   <dc:title><rdf:Alt><rdf:li xml:lang=\"x-default\">%s</rdf:li></rdf:Alt></dc:title>\n", esc(m.Title))
+	}
+	if m.Author != "" {
+		fmt.Fprintf(&b, "   <dc:creator><rdf:Seq><rdf:li>%s</rdf:li></rdf:Seq></dc:creator>\n", esc(m.Author))
+	}
+	if m.Subject != "" {
+		fmt.Fprintf(&b, "   <dc:description><rdf:Alt><rdf:li xml:lang=\"x-default\">%s</rdf:li></rdf:Alt></dc:description>\n", esc(m.Subject))
+	}
+	if words := m.keywords(); len(words) > 0 {
+		b.WriteString("   <dc:subject><rdf:Bag>")
+		for _, w := range words {
+			fmt.Fprintf(&b, "<rdf:li>%s</rdf:li>", esc(w))
+		}
+		b.WriteString("</rdf:Bag></dc:subject>\n")
+		fmt.Fprintf(&b, "   <pdf:Keywords>%s</pdf:Keywords>\n", esc(strings.Join(words, ", ")))
+	}
+	fmt.Fprintf(&b, "   <pdf:Producer>%s</pdf:Producer>\n", esc(producer))
+	if m.Creator != "" {
+		fmt.Fprintf(&b, "   <xmp:CreatorTool>%s</xmp:CreatorTool>\n", esc(m.Creator))
+	}
+	fmt.Fprintf(&b, "   <xmp:CreateDate>%s</xmp:CreateDate>\n", date(m.Created))
+	fmt.Fprintf(&b, "   <xmp:ModifyDate>%s</xmp:ModifyDate>\n", date(m.Modified))
+	fmt.Fprintf(&b, "   <xmp:MetadataDate>%s</xmp:MetadataDate>\n", date(m.Modified))
+	b.WriteString("  </rdf:Description>\n")
+	b.WriteString(" </rdf:RDF>\n")
+	b.WriteString("</x:xmpmeta>\n")
+	b.WriteString("<?xpacket end=\"w\"?>")
+	return []byte(b.String())
+}
+
+// setMetadata записывает словарь Info и добавляет в каталог поток XMP
+func (w *pdfWriter) setMetadata(m *docInfo) error {
+	info, err := w.ctx.IndRefForNewObject(m.infoDict())
+	if err != nil {
+		return err
+	}
+	w.ctx.Info = info
+
+	// Поток метаданных не сжимается, чтобы его могли прочитать программы без поддержки PDF
+	sd := types.StreamDict{
+		Dict: types.Dict(map[string]types.Object{
+			"Type":    types.Name("Metadata"),
+			"Subtype": types.Name("XML"),
+		}),
+		Content: m.xmp(),
+	}
+	if err := sd.Encode(); err != nil {
+		return err
+	}
+	ref, err := w.ctx.IndRefForNewObject(sd)
+	if err != nil {
+		return err
+	}
+	w.ctx.RootDict.Insert("Metadata", *ref)
+	w.meta = m
+	return nil
+}
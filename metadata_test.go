@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+func TestParseDate(t *testing.T) {
+	now := time.Date(2024, 7, 1, 12, 0, 0, 0, time.Local)
+	first := time.Date(2024, 6, 1, 9, 30, 0, 0, time.Local)
+	last := time.Date(2024, 6, 14, 18, 5, 0, 0, time.Local)
+
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"", now},
+		{"now", now},
+		{"Earliest", first},
+		{"latest", last},
+		{"2023-12-31", time.Date(2023, 12, 31, 0, 0, 0, 0, time.Local)},
+		{"2023-12-31T08:15", time.Date(2023, 12, 31, 8, 15, 0, 0, time.Local)},
+		{"2023-12-31T08:15:42", time.Date(2023, 12, 31, 8, 15, 42, 0, time.Local)},
+		{"2023-12-31T08:15:42Z", time.Date(2023, 12, 31, 8, 15, 42, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := parseDate(tt.in, now, first, last)
+		if err != nil {
+			t.Errorf("parseDate(%q) failed: %v", tt.in, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("parseDate(%q) = %v; want %v", tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{"yesterday", "31.12.2023", "2023-13-01"} {
+		if _, err := parseDate(in, now, first, last); !IsInvalidInput(err) {
+			t.Errorf("parseDate(%q): expected ErrInvalidInput, got %v", in, err)
+		}
+	}
+}
+
+func TestConvert_Metadata(t *testing.T) {
+	tmpDir := t.TempDir()
+	times := []time.Time{
+		time.Date(2024, 6, 14, 18, 5, 0, 0, time.Local),
+		time.Date(2024, 6, 1, 9, 30, 0, 0, time.Local),
+	}
+	paths := createImagesWithTimes(t, tmpDir, []string{"a.jpg", "b.jpg"}, times)
+
+	opts := DefaultOptions()
+	opts.Title = "Лето 2024"
+	opts.Author = "J. Doe"
+	opts.Subject = "Holiday <photos>"
+	opts.Keywords = "sea, family,,"
+	opts.Creator = "Scanner 3000"
+	opts.Created = DateEarliest
+	opts.Modified = "2024-07-01T10:00"
+
+	output := filepath.Join(tmpDir, "out.pdf")
+	if err := NewConverterWithOptions(opts).Convert(strings.Join(paths, ","), output, "seq"); err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+	if err := api.ValidateFile(output, nil); err != nil {
+		t.Fatalf("Output is not a valid PDF: %v", err)
+	}
+	// Метаданные записываются вместе с документом, без инкрементальных обновлений
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "%PDF-1.7") || strings.Count(string(data), "%%EOF") != 1 {
+		t.Errorf("Expected a single PDF 1.7 revision, got header %q and %d revisions", data[:8], strings.Count(string(data), "%%EOF"))
+	}
+
+	f, err := os.Open(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	info, err := api.PDFInfo(f, output, nil, false, nil)
+	if err != nil {
+		t.Fatalf("PDFInfo failed: %v", err)
+	}
+	if info.Title != opts.Title || info.Author != opts.Author || info.Subject != opts.Subject || info.Creator != opts.Creator {
+		t.Errorf("Info = %q, %q, %q, %q; want options values", info.Title, info.Author, info.Subject, info.Creator)
+	}
+	// pdfcpu возвращает ключевые слова без порядка
+	keywords := slices.Sorted(slices.Values(info.Keywords))
+	if strings.Join(keywords, "|") != "family|sea" {
+		t.Errorf("Keywords = %q; want [sea family]", info.Keywords)
+	}
+	if !strings.HasPrefix(info.Producer, "img2pdf") {
+		t.Errorf("Producer = %q; want img2pdf", info.Producer)
+	}
+
+	// Даты сверяются по словарю Info
+	ctx, err := api.ReadContextFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := ctx.DereferenceDict(*ctx.Info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dates := map[string]time.Time{"CreationDate": times[1], "ModDate": time.Date(2024, 7, 1, 10, 0, 0, 0, time.Local)}
+	for key, want := range dates {
+		s := d.StringEntry(key)
+		if s == nil || *s != types.DateString(want) {
+			t.Errorf("%s = %v; want %s", key, s, types.DateString(want))
+		}
+	}
+
+	ref := ctx.RootDict.IndirectRefEntry("Metadata")
+	if ref == nil {
+		t.Fatal("Catalog has no Metadata stream")
+	}
+	sd, _, err := ctx.DereferenceStreamDict(*ref)
+	if err != nil || sd == nil {
+		t.Fatalf("Metadata stream: %v", err)
+	}
+	if err := sd.Decode(); err != nil {
+		t.Fatal(err)
+	}
+	xmp := string(sd.Content)
+	for _, want := range []string{
+		"Лето 2024",
+		"Holiday &lt;photos&gt;",
+		"<rdf:li>sea</rdf:li><rdf:li>family</rdf:li>",
+		"<xmp:CreateDate>" + times[1].Format(time.RFC3339),
+		"<xmp:CreatorTool>Scanner 3000",
+	} {
+		if !strings.Contains(xmp, want) {
+			t.Errorf("XMP does not contain %q:\n%s", want, xmp)
+		}
+	}
+}
+
+func TestConvert_MetadataInvalidDate(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Created = "last week"
+	if err := opts.Validate(); !IsInvalidInput(err) {
+		t.Errorf("Expected ErrInvalidInput, got %v", err)
+	}
+}
+
+func TestKeepInfoDates(t *testing.T) {
+	w, err := newPDFWriter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	created := types.DateString(time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC))
+	d := types.NewDict()
+	d.InsertString("CreationDate", created)
+	d.InsertString("ModDate", created)
+	d.InsertString("Producer", producer)
+	if w.ctx.Info, err = w.ctx.IndRefForNewObject(d); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	restore := keepInfoDates(w.ctx)
+	err = api.WriteContext(w.ctx, &buf)
+	restore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.ctx.RootVersion != nil {
+		t.Errorf("RootVersion = %v after restore; want nil", w.ctx.RootVersion)
+	}
+	// Если проверка не проходит, pdfcpu изменил условие подмены дат в ensureInfoDict
+	if !bytes.HasPrefix(buf.Bytes(), []byte("%PDF-1.7")) {
+		t.Errorf("Header = %q; want %%PDF-1.7", buf.Bytes()[:8])
+	}
+	ctx, err := api.ReadContext(bytes.NewReader(buf.Bytes()), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := ctx.DereferenceDict(*ctx.Info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]string{"CreationDate": created, "ModDate": created, "Producer": producer} {
+		got := ""
+		if s := info.StringEntry(key); s != nil {
+			got = *s
+		}
+		if got != want {
+			t.Errorf("%s = %q; want %q, pdfcpu replaced it on write", key, got, want)
+		}
+	}
+}
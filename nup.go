@@ -0,0 +1,315 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pdfcpu/pdfcpu/pkg/font"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// Порядок заполнения сетки (-fill)
+const (
+	// FillRow заполняет сетку по строкам слева направо
+	FillRow = "row"
+	// FillColumn заполняет сетку по столбцам сверху вниз
+	FillColumn = "column"
+)
+
+var fillOrders = map[string]bool{
+	FillRow:    true,
+	FillColumn: true,
+}
+
+// Подписи под изображениями в сетке (-caption)
+const (
+	// CaptionName подписывает изображение именем файла
+	CaptionName = "name"
+	// CaptionDate подписывает изображение датой съёмки из EXIF или временем изменения файла
+	CaptionDate = "date"
+)
+
+const (
+	captionFont     = "Helvetica"
+	captionFontSize = 8
+	// captionGap — зазор между изображением и подписью в пунктах
+	captionGap = 2
+	// sheetFallback — формат страницы сетки, когда размер страницы auto
+	sheetFallback = "A4"
+)
+
+// nupGrid — сетка изображений на странице
+type nupGrid struct {
+	Cols, Rows int
+}
+
+// parseNup разбирает сетку "столбцы x строки". Пустая строка отключает сетку.
+func parseNup(s string) (nupGrid, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nupGrid{}, nil
+	}
+	c, r, ok := strings.Cut(strings.ToLower(s), "x")
+	cols, errC := strconv.Atoi(strings.TrimSpace(c))
+	rows, errR := strconv.Atoi(strings.TrimSpace(r))
+	if !ok || errC != nil || errR != nil || cols < 1 || rows < 1 {
+		return nupGrid{}, fmt.Errorf("%w: invalid grid %q, expected columns x rows like 2x3", ErrInvalidInput, s)
+	}
+	return nupGrid{Cols: cols, Rows: rows}, nil
+}
+
+// parseCaption разбирает список подписей через запятую: name, date или none
+func parseCaption(s string) ([]string, error) {
+	var fields []string
+	for _, f := range strings.Split(s, ",") {
+		f = strings.ToLower(strings.TrimSpace(f))
+		switch f {
+		case "", "none":
+		case CaptionName, CaptionDate:
+			fields = append(fields, f)
+		default:
+			return nil, fmt.Errorf("%w: unknown caption %q", ErrInvalidInput, f)
+		}
+	}
+	return fields, nil
+}
+
+// validateNup проверяет параметры сетки
+func (o Options) validateNup() error {
+	grid, err := parseNup(o.Nup)
+	if err != nil {
+		return err
+	}
+	if o.Gutter < 0 {
+		return fmt.Errorf("%w: negative gutter", ErrInvalidInput)
+	}
+	if !fillOrders[o.Fill] {
+		return fmt.Errorf("%w: unknown fill order %q", ErrInvalidInput, o.Fill)
+	}
+	if _, err := parseCaption(o.Caption); err != nil {
+		return err
+	}
+	if o.Booklet {
+		if grid.Cols > 0 {
+			return fmt.Errorf("%w: -booklet and -nup cannot be combined", ErrInvalidInput)
+		}
+		grid = bookletGrid
+	}
+	if grid.Cols == 0 {
+		return nil
+	}
+	w, h, err := o.sheetSize()
+	if err != nil {
+		return err
+	}
+	_, err = o.gridCells(w, h, grid)
+	return err
+}
+
+// sheetSize возвращает размер страницы сетки. Размер по изображению для сетки
+// не имеет смысла, поэтому в режиме auto используется A4.
+func (o Options) sheetSize() (float64, float64, error) {
+	dim, err := o.pageDim()
+	if err != nil {
+		return 0, 0, err
+	}
+	if dim == nil {
+		dim = types.PaperSize[sheetFallback]
+	}
+	if o.Booklet && dim.Width < dim.Height {
+		// Лист брошюры всегда альбомный: на нём рядом стоят две страницы
+		return dim.Height, dim.Width, nil
+	}
+	return dim.Width, dim.Height, nil
+}
+
+// gridCells делит область страницы внутри полей на ячейки с промежутками Gutter
+// и возвращает их в порядке заполнения
+func (o Options) gridCells(pageW, pageH float64, g nupGrid) ([]rect, error) {
+	box := o.contentBox(pageW, pageH)
+	cw := (box.W - float64(g.Cols-1)*o.Gutter) / float64(g.Cols)
+	ch := (box.H - float64(g.Rows-1)*o.Gutter) / float64(g.Rows)
+	// Под изображением должно остаться место для подписи
+	if cw < 1 || ch-o.captionHeight() < 1 {
+		return nil, fmt.Errorf("%w: no room for a %dx%d grid on the page", ErrInvalidInput, g.Cols, g.Rows)
+	}
+
+	// Строки нумеруются сверху, а ось Y в PDF направлена вверх
+	cell := func(col, row int) rect {
+		return rect{
+			X: box.X + float64(col)*(cw+o.Gutter),
+			Y: box.Y + box.H - float64(row+1)*ch - float64(row)*o.Gutter,
+			W: cw,
+			H: ch,
+		}
+	}
+
+	cells := make([]rect, 0, g.Cols*g.Rows)
+	if o.Fill == FillColumn {
+		for col := 0; col < g.Cols; col++ {
+			for row := 0; row < g.Rows; row++ {
+				cells = append(cells, cell(col, row))
+			}
+		}
+	} else {
+		for row := 0; row < g.Rows; row++ {
+			for col := 0; col < g.Cols; col++ {
+				cells = append(cells, cell(col, row))
+			}
+		}
+	}
+	return cells, nil
+}
+
+// captionHeight возвращает высоту полосы подписи под изображением, 0 без подписей
+func (o Options) captionHeight() float64 {
+	if captions, _ := parseCaption(o.Caption); len(captions) > 0 {
+		return captionFontSize + captionGap
+	}
+	return 0
+}
+
+// nupSheet — страница сетки, которая заполняется по мере поступления изображений
+type nupSheet struct {
+	width, height float64
+	cells         []rect
+	used          int
+	content       strings.Builder
+	xobjects      map[string]types.IndirectRef
+	fonts         map[string]types.IndirectRef
+}
+
+// newSheet начинает пустую страницу сетки
+func (c *Converter) newSheet() (*nupSheet, error) {
+	w, h, err := c.opts.sheetSize()
+	if err != nil {
+		return nil, err
+	}
+	cells, err := c.opts.gridCells(w, h, c.grid)
+	if err != nil {
+		return nil, err
+	}
+	return &nupSheet{
+		width:    w,
+		height:   h,
+		cells:    cells,
+		xobjects: map[string]types.IndirectRef{},
+		fonts:    map[string]types.IndirectRef{},
+	}, nil
+}
+
+// addCell размещает часть изображения в следующей свободной ячейке, начиная новую
+// страницу, когда текущая заполнена. Поворот выполняется матрицей, а не /Rotate,
+// поэтому ячейки одной страницы могут быть повёрнуты по-разному.
+func (c *Converter) addCell(w *pdfWriter, sheet *nupSheet, pp preparedPart) error {
+	if sheet.used == len(sheet.cells) {
+		if err := c.flushSheet(w, sheet); err != nil {
+			return err
+		}
+	}
+	cell := sheet.cells[sheet.used]
+	sheet.used++
+
+	area := cell
+	area.Y += c.opts.captionHeight()
+	area.H -= c.opts.captionHeight()
+
+	pp.fitTo(&types.Dim{Width: area.W, Height: area.H})
+	dw, dh := pp.displaySize()
+	place := fitRect(area, float64(dw), float64(dh))
+
+	// Ширина изображения до поворота на странице
+	widthPt := place.W
+	if pp.orient.swapsSides() {
+		widthPt = place.H
+	}
+	tw, th := targetSize(pp.crop.Dx(), pp.crop.Dy(), widthPt, c.opts.DPI, c.opts.MaxDimension)
+
+	r, err := c.renderPart(w, &pp, tw, th, false)
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("Im%d", sheet.used-1)
+	sheet.xobjects[name] = r.ref
+	sheet.content.WriteString(orientedImageOp(name, place, pp.orient))
+
+	if text := c.captionText(pp); text != "" {
+		ref, err := w.standardFont(captionFont)
+		if err != nil {
+			return err
+		}
+		sheet.fonts["F0"] = ref
+		text = fitText(winAnsi(text), captionFont, captionFontSize, cell.W)
+		x := cell.X + (cell.W-font.TextWidth(text, captionFont, captionFontSize))/2
+		y := cell.Y + font.Descent(captionFont, captionFontSize)
+		sheet.content.WriteString(textOp("F0", captionFontSize, x, y, text))
+	}
+
+	entry := pp.pageReport(w.ctx.PageCount+1, r)
+	entry.Cell = sheet.used
+	c.report.Pages = append(c.report.Pages, entry)
+	return nil
+}
+
+// addBlankCell оставляет следующую ячейку пустой или заливает её цветом разделителя
+func (c *Converter) addBlankCell(w *pdfWriter, sheet *nupSheet, info ImageInfo) error {
+	if sheet.used == len(sheet.cells) {
+		if err := c.flushSheet(w, sheet); err != nil {
+			return err
+		}
+	}
+	cell := sheet.cells[sheet.used]
+	sheet.used++
+
+	if info.Color != nil {
+		sheet.content.WriteString(fillRectOp(*info.Color, cell))
+	}
+	c.report.Pages = append(c.report.Pages, PageReport{
+		Page:   w.ctx.PageCount + 1,
+		Cell:   sheet.used,
+		Blank:  true,
+		Reason: blankReason(info),
+	})
+	return nil
+}
+
+// flushSheet добавляет заполненную страницу сетки в документ и очищает её
+func (c *Converter) flushSheet(w *pdfWriter, sheet *nupSheet) error {
+	if sheet.used == 0 {
+		return nil
+	}
+	page := pdfPage{
+		Width:    sheet.width,
+		Height:   sheet.height,
+		Content:  []byte(sheet.content.String()),
+		XObjects: sheet.xobjects,
+		Fonts:    sheet.fonts,
+	}
+	sheet.used = 0
+	sheet.content.Reset()
+	sheet.xobjects = map[string]types.IndirectRef{}
+	sheet.fonts = map[string]types.IndirectRef{}
+	return w.addPage(page)
+}
+
+// captionText собирает подпись к части изображения из выбранных полей
+func (c *Converter) captionText(pp preparedPart) string {
+	captions, _ := parseCaption(c.opts.Caption)
+	var fields []string
+	for _, f := range captions {
+		switch f {
+		case CaptionName:
+			name := filepath.Base(pp.info.source())
+			if pp.part.Name != "" {
+				name += " (" + pp.part.Name + ")"
+			}
+			fields = append(fields, name)
+		case CaptionDate:
+			fields = append(fields, pp.taken.Format("2006-01-02 15:04"))
+		}
+	}
+	return strings.Join(fields, " · ")
+}
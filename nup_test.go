@@ -0,0 +1,210 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+)
+
+func TestParseNup(t *testing.T) {
+	if g, err := parseNup("2x3"); err != nil || g != (nupGrid{Cols: 2, Rows: 3}) {
+		t.Errorf("parseNup(2x3) = %+v, %v", g, err)
+	}
+	if g, err := parseNup(""); err != nil || g.Cols != 0 {
+		t.Errorf("parseNup(\"\") = %+v, %v; want no grid", g, err)
+	}
+	for _, bad := range []string{"2", "0x3", "2x", "ax2", "-1x2"} {
+		if _, err := parseNup(bad); !IsInvalidInput(err) {
+			t.Errorf("parseNup(%q) = %v; want invalid input error", bad, err)
+		}
+	}
+}
+
+func TestGridCells_FillOrder(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Gutter = 10
+	grid := nupGrid{Cols: 2, Rows: 2}
+
+	rows, err := opts.gridCells(210, 210, grid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []rect{
+		{X: 0, Y: 110, W: 100, H: 100},
+		{X: 110, Y: 110, W: 100, H: 100},
+		{X: 0, Y: 0, W: 100, H: 100},
+		{X: 110, Y: 0, W: 100, H: 100},
+	}
+	for i := range want {
+		if rows[i] != want[i] {
+			t.Errorf("Row-major cell %d = %+v; want %+v", i, rows[i], want[i])
+		}
+	}
+
+	opts.Fill = FillColumn
+	cols, err := opts.gridCells(210, 210, grid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cols[1] != want[2] || cols[2] != want[1] {
+		t.Errorf("Column-major cells = %+v", cols)
+	}
+
+	opts.Gutter = 300
+	if _, err := opts.gridCells(210, 210, grid); !IsInvalidInput(err) {
+		t.Errorf("Expected an invalid input error for a gutter wider than the page, got %v", err)
+	}
+}
+
+func TestOrientedImageOp(t *testing.T) {
+	// Каждый пиксель матрица должна переносить туда же, куда его переносит orientGray
+	src := image.NewGray(image.Rect(0, 0, 3, 2))
+	for i := range src.Pix {
+		src.Pix[i] = uint8(i + 1)
+	}
+	for _, o := range exifOrientations[1:] {
+		shown := orientGray(src, o)
+		dw, dh := shown.Bounds().Dx(), shown.Bounds().Dy()
+
+		var a, b, c, d, e, f float64
+		op := orientedImageOp("Im0", rect{W: float64(dw), H: float64(dh)}, o)
+		if _, err := fmt.Sscanf(op, "q %f %f %f %f %f %f cm", &a, &b, &c, &d, &e, &f); err != nil {
+			t.Fatalf("Unexpected operator %q: %v", op, err)
+		}
+		for y := 0; y < 2; y++ {
+			for x := 0; x < 3; x++ {
+				u, v := (float64(x)+0.5)/3, 1-(float64(y)+0.5)/2
+				px := int(math.Floor(a*u + c*v + e))
+				py := dh - 1 - int(math.Floor(b*u+d*v+f))
+				if got, want := shown.GrayAt(px, py).Y, src.GrayAt(x, y).Y; got != want {
+					t.Errorf("%+v: pixel %d,%d is drawn where orientGray shows %d; want %d", o, x, y, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestExifDate(t *testing.T) {
+	// IFD0 с указателем на Exif IFD, в котором лежит DateTimeOriginal
+	tiff := make([]byte, 64)
+	copy(tiff, "II*\x00")
+	le := binary.LittleEndian
+	le.PutUint32(tiff[4:], 8)
+	le.PutUint16(tiff[8:], 1)
+	le.PutUint16(tiff[10:], exifTagExifIFD)
+	le.PutUint16(tiff[12:], 4)
+	le.PutUint32(tiff[14:], 1)
+	le.PutUint32(tiff[18:], 26)
+	le.PutUint16(tiff[26:], 1)
+	le.PutUint16(tiff[28:], exifTagDateTimeOriginal)
+	le.PutUint16(tiff[30:], 2)
+	le.PutUint32(tiff[32:], 20)
+	le.PutUint32(tiff[36:], 44)
+	copy(tiff[44:], "2023:07:14 18:30:05\x00")
+
+	got, ok := exifDate(tiff)
+	if want := time.Date(2023, 7, 14, 18, 30, 5, 0, time.UTC); !ok || !got.Equal(want) {
+		t.Errorf("exifDate = %v, %v; want %v", got, ok, want)
+	}
+	if _, ok := exifDate(tiff[:20]); ok {
+		t.Error("Expected no date in a truncated EXIF block")
+	}
+}
+
+func TestConvert_Nup(t *testing.T) {
+	tmpDir := t.TempDir()
+	var paths []string
+	for i := 0; i < 7; i++ {
+		path := filepath.Join(tmpDir, "photo"+strconv.Itoa(i)+".png")
+		w, h := 300, 200
+		if i%2 == 1 {
+			w, h = 200, 300
+		}
+		if err := createTestImage(path, w, h, "png"); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+
+	opts := DefaultOptions()
+	opts.Nup = "2x3"
+	opts.Gutter = 10
+	opts.Margin = 20
+	opts.Caption = "name,date"
+	opts.Rotate = RotateAuto
+
+	output := filepath.Join(tmpDir, "proofs.pdf")
+	converter := NewConverterWithOptions(opts)
+	if err := converter.Convert(strings.Join(paths, ","), output, "seq"); err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+	if err := api.ValidateFile(output, nil); err != nil {
+		t.Fatalf("Invalid PDF: %v", err)
+	}
+
+	// Размер страницы auto для сетки — A4
+	dims, err := api.PageDimsFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dims) != 2 || int(dims[0].Width) != 595 || int(dims[0].Height) != 842 {
+		t.Fatalf("Expected two A4 pages, got %+v", dims)
+	}
+
+	pages := converter.Report().Pages
+	if len(pages) != 7 {
+		t.Fatalf("Expected 7 report entries, got %d", len(pages))
+	}
+	for i, p := range pages {
+		if wantPage, wantCell := i/6+1, i%6+1; p.Page != wantPage || p.Cell != wantCell {
+			t.Errorf("Entry %d: page %d cell %d; want page %d cell %d", i, p.Page, p.Cell, wantPage, wantCell)
+		}
+	}
+	// Книжные снимки доворачиваются под альбомные ячейки
+	if pages[0].Rotate != 0 || pages[1].Rotate != 90 {
+		t.Errorf("Rotations = %d, %d; want 0, 90", pages[0].Rotate, pages[1].Rotate)
+	}
+
+	ctx, err := api.ReadContextFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pageDict, _, _, err := ctx.PageDict(1, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resources := pageDict.DictEntry("Resources")
+	if resources.DictEntry("Font") == nil || len(resources.DictEntry("XObject")) != 6 {
+		t.Errorf("Expected 6 images and a caption font on the first page, got %v", resources)
+	}
+}
+
+func TestConvert_NupInvalid(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "photo.png")
+	if err := createTestImage(path, 100, 100, "png"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, opts := range []func(*Options){
+		func(o *Options) { o.Nup = "3" },
+		func(o *Options) { o.Nup = "2x2"; o.Fill = "spiral" },
+		func(o *Options) { o.Nup = "2x2"; o.Caption = "title" },
+		func(o *Options) { o.Nup = "2x2"; o.Gutter = -1 },
+	} {
+		o := DefaultOptions()
+		opts(&o)
+		err := NewConverterWithOptions(o).Convert(path, filepath.Join(tmpDir, "out.pdf"), "seq")
+		if !IsInvalidInput(err) {
+			t.Errorf("Options %+v: expected invalid input error, got %v", o, err)
+		}
+	}
+}
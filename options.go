@@ -0,0 +1,320 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// mmToPoints переводит миллиметры в пункты PDF (1/72 дюйма)
+const mmToPoints = 72 / 25.4
+
+// Options задаёт параметры конвертации
+type Options struct {
+	// PageSize — формат страницы (A4, A4L, Letter, ...). Пустая строка или "auto"
+	// означают, что страница совпадает с размером изображения.
+	PageSize string
+	// Margin — поля страницы в пунктах
+	Margin float64
+	// DPI — целевое разрешение изображения на странице, 0 — без ограничения
+	DPI int
+	// MaxDimension — максимальная длина стороны изображения в пикселях, 0 — без ограничения
+	MaxDimension int
+	// Resample — фильтр для уменьшения изображений
+	Resample string
+
+	// Rotate — поворот страниц: "[selector=]degrees|auto;...", см. parseRuleFlag
+	Rotate string
+	// Flip — отражение страниц: "[selector=]h|v|hv;..."
+	Flip string
+	// Manifest — путь к JSON-манифесту с настройками отдельных страниц
+	Manifest string
+
+	// Nup — сетка из нескольких изображений на странице, "столбцы x строки", например 2x3
+	Nup string
+	// Gutter — промежуток между ячейками сетки в пунктах
+	Gutter float64
+	// Caption — подписи под изображениями в сетке: name, date или name,date
+	Caption string
+	// Fill — порядок заполнения сетки: row или column
+	Fill string
+	// Booklet — раскладка брошюры: по две страницы на альбомном листе в порядке для сгиба
+	Booklet bool
+
+	// RemoveBlank — убирать пустые страницы (обороты односторонних документов)
+	RemoveBlank bool
+	// BlankThreshold — покрытие краской в процентах, ниже которого страница пустая
+	BlankThreshold float64
+	// BlankMargin — полоса по краям в процентах стороны, которая не учитывается
+	BlankMargin float64
+
+	// BlankEvery — вставлять разделитель после каждых BlankEvery страниц, 0 — нет
+	BlankEvery int
+	// Separate — разделители между входными группами: none, page или odd
+	Separate string
+	// SeparatorColor — цвет страниц-разделителей, белые остаются пустыми
+	SeparatorColor string
+
+	// Split — деление изображений на страницы: none или spread
+	Split string
+	// RTL — порядок чтения справа налево: правая половина разворота идёт первой
+	RTL bool
+
+	// Deskew — выравнивать наклонённые сканы
+	Deskew bool
+	// MaxSkew — наибольший исправляемый наклон в градусах
+	MaxSkew float64
+
+	// Crop — ручная обрезка всех или отдельных страниц, см. parseCrop
+	Crop string
+	// AutoCrop — обрезать белые и чёрные поля сканов
+	AutoCrop bool
+	// CropTolerance — допустимое отклонение яркости поля от цвета края, от 0 до 255
+	CropTolerance int
+	// CropPadding — отступ в пикселях, оставляемый вокруг найденного содержимого
+	CropPadding int
+
+	// Alpha — обработка прозрачности: keep, flatten или white
+	Alpha string
+	// Background — цвет подложки для Alpha = flatten
+	Background string
+
+	// Color — цветовой режим: color, gray или bw
+	Color string
+	// Threshold — порог бинаризации для bw от 1 до 255, 0 — подбор методом Оцу
+	Threshold int
+	// Dither — рассеивать ошибку при бинаризации вместо жёсткого порога
+	Dither bool
+
+	// Compression — политика сжатия: keep, flate, jpeg или auto, пустая строка — keep
+	Compression string
+	// Quality — качество JPEG при перекодировании, от 1 до 100, 0 — по умолчанию
+	Quality int
+
+	// Title, Author, Subject, Keywords (через запятую) и Creator записываются
+	// в словарь Info и в метаданные XMP
+	Title    string
+	Author   string
+	Subject  string
+	Keywords string
+	Creator  string
+	// Created и Modified — даты документа: now, earliest, latest или 2006-01-02[T15:04[:05]]
+	Created  string
+	Modified string
+
+	// WatermarkText и WatermarkImage — текст и путь к изображению водяного знака
+	WatermarkText  string
+	WatermarkImage string
+	// WatermarkRotate — поворот знака против часовой стрелки в градусах
+	WatermarkRotate float64
+	// WatermarkOpacity — непрозрачность знака от 0 до 1
+	WatermarkOpacity float64
+	// WatermarkScale — ширина знака в долях ширины страницы
+	WatermarkScale float64
+	// WatermarkPosition — положение знака: center, top, bottom-right и т.п.
+	WatermarkPosition string
+	// WatermarkTile — заполнить страницу копиями знака вместо одной
+	WatermarkTile bool
+	// WatermarkLayer — слой знака: over или under
+	WatermarkLayer string
+	// WatermarkColor — цвет текстового знака
+	WatermarkColor string
+	// WatermarkPages — страницы результата со знаком ("1-3,5,8-"), пусто — все
+	WatermarkPages string
+
+	// Header и Footer — шаблоны колонтитулов с подстановками {page}, {pages}, {file},
+	// {date} и {exif.date}
+	Header string
+	Footer string
+	// StampFont — стандартный шрифт колонтитулов, StampSize — его размер в пунктах
+	StampFont string
+	StampSize int
+	// StampAlign — выравнивание колонтитулов: left, center или right
+	StampAlign string
+	// StampMargin — отступ колонтитулов от края страницы в пунктах
+	StampMargin float64
+	// StampOpacity — непрозрачность колонтитулов от 0 до 1
+	StampOpacity float64
+	// StampColor — цвет текста колонтитулов
+	StampColor string
+
+	// UserPassword и OwnerPassword — пароли для открытия документа и для снятия
+	// ограничений; значение env:NAME или file:PATH читает пароль из окружения или файла,
+	// pass:TEXT задаёт сам пароль. Без пароля владельца он выбирается случайно.
+	UserPassword  string
+	OwnerPassword string
+	// Encryption — алгоритм шифрования: aes128 или aes256
+	Encryption string
+	// Permissions — права при открытии паролем пользователя: print, copy, modify, annotate
+	Permissions string
+
+	// PDFA — уровень соответствия PDF/A, пустая строка — обычный PDF
+	PDFA string
+
+	// Labels — нумерация страниц для просмотрщиков, см. parseLabels
+	Labels string
+	// Bookmarks — источник закладок: none, dir, file или manifest
+	Bookmarks string
+
+	// Animation — кадры анимированных изображений: first, all или диапазон N-M
+	Animation string
+
+	// DryRun — только построить план и отчёт, не записывая PDF
+	DryRun bool
+	// ReportPath — путь для отчёта о конвертации в формате JSON
+	ReportPath string
+}
+
+// DefaultOptions возвращает параметры, при которых поведение совпадает с исходным
+func DefaultOptions() Options {
+	return Options{
+		PageSize:          "auto",
+		Resample:          "catmullrom",
+		Fill:              FillRow,
+		BlankThreshold:    0.2,
+		BlankMargin:       5,
+		Separate:          SeparateNone,
+		SeparatorColor:    "white",
+		Split:             SplitNone,
+		MaxSkew:           5,
+		CropTolerance:     32,
+		Alpha:             AlphaKeep,
+		Background:        "white",
+		Color:             ColorKeep,
+		Compression:       CompressionKeep,
+		Quality:           defaultQuality,
+		WatermarkRotate:   45,
+		WatermarkOpacity:  0.3,
+		WatermarkScale:    0.5,
+		WatermarkPosition: "center",
+		WatermarkLayer:    WatermarkOver,
+		WatermarkColor:    "gray",
+		StampFont:         "Helvetica",
+		StampSize:         9,
+		StampAlign:        AlignCenter,
+		StampMargin:       8 * mmToPoints,
+		StampOpacity:      1,
+		StampColor:        "black",
+		Encryption:        EncryptAES256,
+		Permissions:       "print",
+		Bookmarks:         BookmarksNone,
+		Animation:         AnimationFirst,
+	}
+}
+
+// Validate проверяет параметры и возвращает ErrInvalidInput с пояснением
+func (o Options) Validate() error {
+	if _, err := o.pageDim(); err != nil {
+		return err
+	}
+	if o.Margin < 0 {
+		return fmt.Errorf("%w: negative margin", ErrInvalidInput)
+	}
+	if o.DPI < 0 {
+		return fmt.Errorf("%w: negative dpi", ErrInvalidInput)
+	}
+	if o.MaxDimension < 0 {
+		return fmt.Errorf("%w: negative max dimension", ErrInvalidInput)
+	}
+	if _, ok := resampleFilters[strings.ToLower(o.Resample)]; !ok {
+		return fmt.Errorf("%w: unknown resample filter %q", ErrInvalidInput, o.Resample)
+	}
+	if _, err := parseRuleFlag(o.Rotate, func(r *pageRule, v string) { r.Rotate = v }); err != nil {
+		return err
+	}
+	if _, err := parseRuleFlag(o.Flip, func(r *pageRule, v string) { r.Flip = v }); err != nil {
+		return err
+	}
+	if err := o.validateNup(); err != nil {
+		return err
+	}
+	if o.BlankThreshold < 0 || o.BlankThreshold > 100 {
+		return fmt.Errorf("%w: blank threshold must be between 0 and 100 percent", ErrInvalidInput)
+	}
+	if o.BlankMargin < 0 || o.BlankMargin >= 50 {
+		return fmt.Errorf("%w: blank margin must be between 0 and 50 percent", ErrInvalidInput)
+	}
+	if err := o.validateSeparators(); err != nil {
+		return err
+	}
+	if !splitModes[o.Split] {
+		return fmt.Errorf("%w: unknown split mode %q", ErrInvalidInput, o.Split)
+	}
+	if o.Booklet && o.Split != SplitNone {
+		// Порядок листов считается по входным изображениям, до деления разворотов
+		return fmt.Errorf("%w: -booklet and -split cannot be combined", ErrInvalidInput)
+	}
+	if o.MaxSkew <= 0 || o.MaxSkew > 45 {
+		return fmt.Errorf("%w: max skew must be between 0 and 45 degrees", ErrInvalidInput)
+	}
+	if _, err := parseCrop(o.Crop); err != nil {
+		return err
+	}
+	if o.CropTolerance < 0 || o.CropTolerance > 255 {
+		return fmt.Errorf("%w: crop tolerance must be between 0 and 255", ErrInvalidInput)
+	}
+	if o.CropPadding < 0 {
+		return fmt.Errorf("%w: negative crop padding", ErrInvalidInput)
+	}
+	if !alphaPolicies[o.Alpha] {
+		return fmt.Errorf("%w: unknown alpha policy %q", ErrInvalidInput, o.Alpha)
+	}
+	if _, _, err := o.alphaBackground(); err != nil {
+		return err
+	}
+	if !colorModes[o.Color] {
+		return fmt.Errorf("%w: unknown color mode %q", ErrInvalidInput, o.Color)
+	}
+	if o.Threshold < 0 || o.Threshold > 255 {
+		return fmt.Errorf("%w: threshold must be between 0 and 255", ErrInvalidInput)
+	}
+	if o.Compression != "" && !compressionPolicies[o.Compression] {
+		return fmt.Errorf("%w: unknown compression policy %q", ErrInvalidInput, o.Compression)
+	}
+	if o.Quality < 0 || o.Quality > 100 {
+		return fmt.Errorf("%w: jpeg quality must be between 1 and 100", ErrInvalidInput)
+	}
+	if err := o.validateWatermark(); err != nil {
+		return err
+	}
+	if err := o.validateEncryption(); err != nil {
+		return err
+	}
+	if err := o.validatePDFA(); err != nil {
+		return err
+	}
+	if err := o.validateStamps(); err != nil {
+		return err
+	}
+	if labels, err := parseLabels(o.Labels); err != nil {
+		return err
+	} else if _, err := newPageLabels(labels); err != nil {
+		return err
+	}
+	if err := o.validateBookmarks(); err != nil {
+		return err
+	}
+	if err := o.validateAnimation(); err != nil {
+		return err
+	}
+	for _, date := range []string{o.Created, o.Modified} {
+		if _, err := parseDate(date, time.Time{}, time.Time{}, time.Time{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pageDim возвращает размер страницы в пунктах или nil для режима auto
+func (o Options) pageDim() (*types.Dim, error) {
+	if o.PageSize == "" || strings.EqualFold(o.PageSize, "auto") {
+		return nil, nil
+	}
+	dim, _, err := types.ParsePageFormat(o.PageSize)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unknown page size %q", ErrInvalidInput, o.PageSize)
+	}
+	return dim, nil
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// RotateAuto поворачивает страницу под ориентацию выходного формата
+const RotateAuto = "auto"
+
+// orientation — поворот страницы без потерь: сначала зеркальное отражение по горизонтали
+// (матрицей в content stream), затем поворот по часовой стрелке (записью /Rotate)
+type orientation struct {
+	Flip   bool
+	Rotate int
+}
+
+// then возвращает преобразование, равное применению o, а затем next
+func (o orientation) then(next orientation) orientation {
+	rotate := o.Rotate
+	if next.Flip {
+		// Отражение меняет направление уже выполненного поворота
+		rotate = -rotate
+	}
+	return orientation{
+		Flip:   o.Flip != next.Flip,
+		Rotate: ((rotate+next.Rotate)%360 + 360) % 360,
+	}
+}
+
+// swapsSides сообщает, что ширина и высота страницы меняются местами
+func (o orientation) swapsSides() bool {
+	return o.Rotate%180 != 0
+}
+
+// exifOrientations — преобразования, приводящие снимок с тегом Orientation к нормальному виду
+var exifOrientations = [...]orientation{
+	1: {},
+	2: {Flip: true},
+	3: {Rotate: 180},
+	4: {Flip: true, Rotate: 180},
+	5: {Flip: true, Rotate: 270},
+	6: {Rotate: 90},
+	7: {Flip: true, Rotate: 90},
+	8: {Rotate: 270},
+}
+
+// parseRotation разбирает значение поворота: угол в градусах по часовой стрелке или auto
+func parseRotation(s string) (float64, bool, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, false, nil
+	}
+	if strings.EqualFold(s, RotateAuto) {
+		return 0, true, nil
+	}
+	deg, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(deg) || math.IsInf(deg, 0) {
+		return 0, false, fmt.Errorf("%w: invalid rotation %q", ErrInvalidInput, s)
+	}
+	return deg, false, nil
+}
+
+// splitRotation раскладывает угол на поворот, кратный 90°, и остаток от -45° до 45°
+func splitRotation(deg float64) (int, float64) {
+	quarters := math.Round(deg / 90)
+	turn := ((int(quarters)%4 + 4) % 4) * 90
+	return turn, deg - quarters*90
+}
+
+// parseFlip разбирает отражение: h, v, hv или none
+func parseFlip(s string) (orientation, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "none":
+		return orientation{}, nil
+	case "h":
+		return orientation{Flip: true}, nil
+	case "v":
+		return orientation{Flip: true, Rotate: 180}, nil
+	case "hv", "vh":
+		return orientation{Rotate: 180}, nil
+	}
+	return orientation{}, fmt.Errorf("%w: invalid flip %q", ErrInvalidInput, s)
+}
+
+// displaySide возвращает, какой стороной (left, top, right, bottom = 0..3) станет
+// сторона side исходного изображения после преобразования
+func (o orientation) displaySide(side int) int {
+	if o.Flip && side%2 == 0 {
+		side = 2 - side
+	}
+	return (side + o.Rotate/90) % 4
+}
+
+// pageOrientation объединяет ориентацию из EXIF с поворотом и отражением из правил.
+// Возвращает поворот без потерь, остаток угла по часовой стрелке для поворота пикселей
+// и признак режима auto.
+func pageOrientation(exif orientation, rule pageRule) (orientation, float64, bool, error) {
+	deg, auto, err := parseRotation(rule.Rotate)
+	if err != nil {
+		return orientation{}, 0, false, err
+	}
+	flip, err := parseFlip(rule.Flip)
+	if err != nil {
+		return orientation{}, 0, false, err
+	}
+	turn, rest := splitRotation(deg)
+	return exif.then(flip).then(orientation{Rotate: turn}), rest, auto, nil
+}
+
+// fitOrientation доворачивает страницу w×h на 90°, если её ориентация не совпадает
+// с форматом dim. Страницы по размеру изображения (dim == nil) не доворачиваются.
+func (o orientation) fitOrientation(w, h int, dim *types.Dim) orientation {
+	if dim == nil {
+		return o
+	}
+	if o.swapsSides() {
+		w, h = h, w
+	}
+	landscape := dim.Width > dim.Height
+	if w == h || (w > h) == landscape {
+		return o
+	}
+	return o.then(orientation{Rotate: 90})
+}
+
+// orientGray применяет преобразование к пикселям серого изображения
+func orientGray(img *image.Gray, o orientation) *image.Gray {
+	if o == (orientation{}) {
+		return img
+	}
+
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	dw, dh := w, h
+	if o.swapsSides() {
+		dw, dh = h, w
+	}
+	dst := image.NewGray(image.Rect(0, 0, dw, dh))
+
+	for y := 0; y < h; y++ {
+		row := img.Pix[y*img.Stride : y*img.Stride+w]
+		for x, v := range row {
+			fx := x
+			if o.Flip {
+				fx = w - 1 - x
+			}
+			var dx, dy int
+			switch o.Rotate {
+			case 90:
+				dx, dy = h-1-y, fx
+			case 180:
+				dx, dy = w-1-fx, h-1-y
+			case 270:
+				dx, dy = y, w-1-fx
+			default:
+				dx, dy = fx, y
+			}
+			dst.Pix[dy*dst.Stride+dx] = v
+		}
+	}
+	return dst
+}
@@ -0,0 +1,367 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// createExifJPG сохраняет JPEG с тегом EXIF Orientation
+func createExifJPG(path string, width, height, orientation int) error {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for i := range img.Pix {
+		img.Pix[i] = uint8(i)
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		return err
+	}
+
+	// TIFF-структура с одним IFD: Orientation, SHORT, 1 значение
+	tiff := []byte("MM\x00*\x00\x00\x00\x08\x00\x01\x01\x12\x00\x03\x00\x00\x00\x01\x00\x00\x00\x00\x00\x00\x00\x00")
+	binary.BigEndian.PutUint16(tiff[18:], uint16(orientation))
+	app1 := append([]byte{0xff, 0xe1, 0, 0}, exifHeader...)
+	app1 = append(app1, tiff...)
+	binary.BigEndian.PutUint16(app1[2:], uint16(len(app1)-2))
+
+	data := buf.Bytes()
+	out := append(append(append([]byte{}, data[:2]...), app1...), data[2:]...)
+	return os.WriteFile(path, out, 0644)
+}
+
+// pageRotations возвращает /Rotate и размер видимой страницы (с учётом поворота)
+func pageRotations(t *testing.T, path string) ([]int, []types.Dim) {
+	t.Helper()
+
+	ctx, err := api.ReadContextFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	boundaries, err := ctx.PageBoundaries(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dims, err := ctx.PageDims()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var rotations []int
+	for _, pb := range boundaries {
+		rotations = append(rotations, pb.Rot)
+	}
+	return rotations, dims
+}
+
+func TestOrientation_Then(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 3, 2))
+	for i := range img.Pix {
+		img.Pix[i] = uint8(i)
+	}
+
+	var all []orientation
+	for _, flip := range []bool{false, true} {
+		for rotate := 0; rotate < 360; rotate += 90 {
+			all = append(all, orientation{Flip: flip, Rotate: rotate})
+		}
+	}
+
+	for _, a := range all {
+		for _, b := range all {
+			want := orientGray(orientGray(img, a), b)
+			got := orientGray(img, a.then(b))
+			if got.Bounds() != want.Bounds() || !bytes.Equal(got.Pix, want.Pix) {
+				t.Errorf("%+v then %+v = %+v does not match applying them in turn", a, b, a.then(b))
+			}
+		}
+	}
+}
+
+func TestOrientGray_ExifOrientations(t *testing.T) {
+	// Снимок 2×1: левый пиксель 1, правый 2. Для каждого тега — как его хранит камера
+	tests := []struct {
+		tag    int
+		stored *image.Gray
+	}{
+		{1, &image.Gray{Pix: []uint8{1, 2}, Stride: 2, Rect: image.Rect(0, 0, 2, 1)}},
+		{2, &image.Gray{Pix: []uint8{2, 1}, Stride: 2, Rect: image.Rect(0, 0, 2, 1)}},
+		{3, &image.Gray{Pix: []uint8{2, 1}, Stride: 2, Rect: image.Rect(0, 0, 2, 1)}},
+		{6, &image.Gray{Pix: []uint8{2, 1}, Stride: 1, Rect: image.Rect(0, 0, 1, 2)}},
+		{8, &image.Gray{Pix: []uint8{1, 2}, Stride: 1, Rect: image.Rect(0, 0, 1, 2)}},
+	}
+
+	for _, tt := range tests {
+		got := orientGray(tt.stored, exifOrientations[tt.tag])
+		if got.Bounds().Dx() != 2 || got.Pix[0] != 1 || got.Pix[1] != 2 {
+			t.Errorf("Orientation %d: got %v %v; want [1 2]", tt.tag, got.Bounds(), got.Pix)
+		}
+	}
+}
+
+func TestExifOrientation(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "photo.jpg")
+	if err := createExifJPG(path, 40, 20, 6); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := exifOrientation(exifData(raw, "jpeg")); got != 6 {
+		t.Errorf("exifOrientation = %d; want 6", got)
+	}
+	if got := exifOrientation(nil); got != 1 {
+		t.Errorf("exifOrientation without EXIF = %d; want 1", got)
+	}
+}
+
+func TestSplitRotation(t *testing.T) {
+	tests := []struct {
+		deg  float64
+		turn int
+		rest float64
+	}{
+		{0, 0, 0},
+		{90, 90, 0},
+		{-90, 270, 0},
+		{450, 90, 0},
+		{92, 90, 2},
+		{-1.5, 0, -1.5},
+		{136, 180, -44},
+	}
+	for _, tt := range tests {
+		turn, rest := splitRotation(tt.deg)
+		if turn != tt.turn || rest != tt.rest {
+			t.Errorf("splitRotation(%v) = %d, %v; want %d, %v", tt.deg, turn, rest, tt.turn, tt.rest)
+		}
+	}
+}
+
+func TestParseRuleFlag(t *testing.T) {
+	rules, err := parseRuleFlag("90; scan_0*.jpg=180 ;3-4=auto", func(r *pageRule, v string) { r.Rotate = v })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 3 {
+		t.Fatalf("Expected 3 rules, got %d", len(rules))
+	}
+
+	tests := []struct {
+		path  string
+		index int
+		want  string
+	}{
+		{"dir/scan_01.jpg", 1, "180"},
+		{"dir/photo.jpg", 2, "90"},
+		{"dir/scan_02.jpg", 3, "auto"},
+		{"dir/photo.jpg", 5, "90"},
+	}
+	for _, tt := range tests {
+		if got := ruleFor(rules, tt.path, tt.index).Rotate; got != tt.want {
+			t.Errorf("Rotate for %s (page %d) = %q; want %q", tt.path, tt.index, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"abc", "x.jpg=ninety", "[=90"} {
+		if _, err := parseRuleFlag(bad, func(r *pageRule, v string) { r.Rotate = v }); !IsInvalidInput(err) {
+			t.Errorf("parseRuleFlag(%q) = %v; want invalid input error", bad, err)
+		}
+	}
+}
+
+func TestConvert_ExifOrientationIsLossless(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "photo.jpg")
+	if err := createExifJPG(path, 40, 20, 6); err != nil {
+		t.Fatal(err)
+	}
+
+	output := filepath.Join(tmpDir, "out.pdf")
+	converter := NewConverter()
+	if err := converter.Convert(path, output, "seq"); err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+
+	rotations, dims := pageRotations(t, output)
+	if rotations[0] != 90 {
+		t.Errorf("Rotate = %d; want 90", rotations[0])
+	}
+	if dims[0].Width != 20 || dims[0].Height != 40 {
+		t.Errorf("Visible page = %.0fx%.0f; want 20x40", dims[0].Width, dims[0].Height)
+	}
+	if got := converter.Report().Pages[0].Encoding; got != EncodingPassthrough {
+		t.Errorf("Encoding = %q; want %q", got, EncodingPassthrough)
+	}
+}
+
+func TestConvert_RotateRules(t *testing.T) {
+	tmpDir := t.TempDir()
+	var paths []string
+	for _, name := range []string{"scan_01.jpg", "scan_02.jpg", "photo.jpg"} {
+		path := filepath.Join(tmpDir, name)
+		if err := createTestJPG(path, 60, 40); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+
+	manifest := filepath.Join(tmpDir, "manifest.json")
+	if err := os.WriteFile(manifest, []byte(`{"pages": [{"pages": "3", "rotate": "180", "flip": "h"}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := DefaultOptions()
+	opts.Manifest = manifest
+	opts.Rotate = "scan_0*.jpg=90;2=270"
+
+	output := filepath.Join(tmpDir, "out.pdf")
+	converter := NewConverterWithOptions(opts)
+	input := paths[0] + "," + paths[1] + "," + paths[2]
+	if err := converter.Convert(input, output, "seq"); err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+	if err := api.ValidateFile(output, nil); err != nil {
+		t.Fatalf("Invalid PDF: %v", err)
+	}
+
+	rotations, _ := pageRotations(t, output)
+	want := []int{90, 270, 180}
+	for i := range want {
+		if rotations[i] != want[i] {
+			t.Errorf("Page %d: Rotate = %d; want %d", i+1, rotations[i], want[i])
+		}
+	}
+	if !converter.Report().Pages[2].Flip {
+		t.Error("Page 3 should be flipped")
+	}
+	for i, p := range converter.Report().Pages {
+		if p.Encoding != EncodingPassthrough {
+			t.Errorf("Page %d: encoding = %q; want %q", i+1, p.Encoding, EncodingPassthrough)
+		}
+	}
+}
+
+func TestConvert_RotateAuto(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "landscape.png")
+	if err := createTestImage(path, 300, 200, "png"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		pageSize string
+		want     int
+	}{
+		{"A4", 90},
+		{"A4L", 0},
+		// Страница по размеру изображения уже совпадает с ним по ориентации
+		{"auto", 0},
+	} {
+		t.Run(tt.pageSize, func(t *testing.T) {
+			opts := DefaultOptions()
+			opts.PageSize = tt.pageSize
+			opts.Rotate = RotateAuto
+
+			output := filepath.Join(tmpDir, tt.pageSize+".pdf")
+			if err := NewConverterWithOptions(opts).Convert(path, output, "seq"); err != nil {
+				t.Fatalf("Convert failed: %v", err)
+			}
+
+			rotations, dims := pageRotations(t, output)
+			if rotations[0] != tt.want {
+				t.Errorf("Rotate = %d; want %d", rotations[0], tt.want)
+			}
+			if dims[0].Width > dims[0].Height != (tt.pageSize != "A4") {
+				t.Errorf("Visible page %.0fx%.0f has the wrong orientation", dims[0].Width, dims[0].Height)
+			}
+		})
+	}
+}
+
+func TestConvert_ArbitraryRotation(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "photo.jpg")
+	if err := createTestJPG(path, 60, 40); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := DefaultOptions()
+	opts.Rotate = "92"
+
+	output := filepath.Join(tmpDir, "out.pdf")
+	converter := NewConverterWithOptions(opts)
+	if err := converter.Convert(path, output, "seq"); err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+
+	page := converter.Report().Pages[0]
+	if page.Rotate != 90 {
+		t.Errorf("Rotate = %d; want 90", page.Rotate)
+	}
+	// Остаток в 2° требует поворота пикселей и перекодирования
+	if page.Encoding == EncodingPassthrough {
+		t.Error("Rotation by 92° cannot be lossless")
+	}
+	// Холст растёт до описанного прямоугольника, углы не обрезаются
+	if page.Width != 62 || page.Height != 43 {
+		t.Errorf("Rotated image is %dx%d; want 62x43", page.Width, page.Height)
+	}
+}
+
+func TestCropRect_FollowsOrientation(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 200, 100))
+	opts := DefaultOptions()
+	// Срезать 10 пикселей сверху видимого изображения
+	opts.Crop = "0,10,0,0"
+
+	tests := []struct {
+		orient orientation
+		want   image.Rectangle
+	}{
+		{orientation{}, image.Rect(0, 10, 200, 100)},
+		{orientation{Rotate: 90}, image.Rect(10, 0, 200, 100)},
+		{orientation{Rotate: 180}, image.Rect(0, 0, 200, 90)},
+		{orientation{Rotate: 270}, image.Rect(0, 0, 190, 100)},
+		{orientation{Flip: true, Rotate: 90}, image.Rect(0, 0, 190, 100)},
+	}
+	for _, tt := range tests {
+		got, err := opts.cropRect(img, 1, tt.orient)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("%+v: cropRect = %v; want %v", tt.orient, got, tt.want)
+		}
+	}
+}
+
+func TestLoadManifest_Invalid(t *testing.T) {
+	tmpDir := t.TempDir()
+	for name, content := range map[string]string{
+		"syntax.json": `{"pages": [`,
+		"rotate.json": `{"pages": [{"rotate": "sideways"}]}`,
+		"flip.json":   `{"pages": [{"flip": "x"}]}`,
+		"pages.json":  `{"pages": [{"pages": "0", "rotate": "90"}]}`,
+	} {
+		path := filepath.Join(tmpDir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadManifest(path); !IsInvalidInput(err) {
+			t.Errorf("%s: expected invalid input error, got %v", name, err)
+		}
+	}
+
+	if _, err := LoadManifest(filepath.Join(tmpDir, "missing.json")); !IsInvalidInput(err) {
+		t.Errorf("Missing manifest: expected invalid input error, got %v", err)
+	}
+}
@@ -0,0 +1,448 @@
+package main
+
+import (
+	"crypto/sha256"
+	"os"
+	"path/filepath"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/filter"
+	"github.com/pdfcpu/pdfcpu/pkg/font"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// pdfImage — закодированное изображение, готовое к записи в PDF как XObject
+type pdfImage struct {
+	Width            int
+	Height           int
+	BitsPerComponent int
+	ColorSpace       string
+	// Filter — filter.DCT и filter.CCITTFax для уже сжатых данных,
+	// filter.Flate для несжатых отсчётов
+	Filter      string
+	DecodeParms map[string]int
+	Data        []byte
+	Decode      []int
+	SMask       *pdfImage
+	// ICC — встроенный профиль исходного файла. Он записывается как ICCBased, если
+	// совпадает с ColorSpace по числу компонентов.
+	ICC []byte
+}
+
+// pdfPage — страница, собранная из content stream и набора XObject
+type pdfPage struct {
+	Width  float64
+	Height float64
+	// MediaBox задаётся, когда изображение выходит за пределы страницы (обрезка
+	// без перекодирования). Видимая область 0 0 Width Height тогда пишется как CropBox.
+	MediaBox *rect
+	// Rotate — поворот страницы при показе по часовой стрелке, кратный 90
+	Rotate   int
+	Content  []byte
+	XObjects map[string]types.IndirectRef
+	// Fonts — шрифты, которыми content stream выводит текст
+	Fonts map[string]types.IndirectRef
+}
+
+// pdfWriter собирает документ поверх модели pdfcpu
+type pdfWriter struct {
+	ctx       *model.Context
+	pagesDict types.Dict
+	pagesRef  types.IndirectRef
+	// fonts — уже добавленные стандартные шрифты
+	fonts map[string]types.IndirectRef
+	// meta — метаданные документа, nil — только то, что пишет pdfcpu
+	meta *docInfo
+	// encrypt — параметры шифрования, nil — документ не шифруется
+	encrypt *encryption
+	// profiles — уже добавленные ICC-профили по их хешу
+	profiles map[[sha256.Size]byte]types.IndirectRef
+}
+
+func newPDFWriter() (*pdfWriter, error) {
+	ctx, err := pdfcpu.CreateContextWithXRefTable(model.NewDefaultConfiguration(), types.PaperSize["A4"])
+	if err != nil {
+		return nil, err
+	}
+
+	pagesRef, err := ctx.Pages()
+	if err != nil {
+		return nil, err
+	}
+
+	pagesDict, err := ctx.DereferenceDict(*pagesRef)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pdfWriter{
+		ctx:       ctx,
+		pagesDict: pagesDict,
+		pagesRef:  *pagesRef,
+		fonts:     map[string]types.IndirectRef{},
+		profiles:  map[[sha256.Size]byte]types.IndirectRef{},
+	}, nil
+}
+
+// standardFontStyle — сведения дескриптора стандартного шрифта из его файла AFM,
+// которых нет в метриках pdfcpu
+type standardFontStyle struct {
+	flags       int
+	italicAngle float64
+	stemV       int
+}
+
+// Флаги дескриптора шрифта
+const (
+	fontFixedPitch  = 1 << 0
+	fontSerif       = 1 << 1
+	fontNonsymbolic = 1 << 5
+	fontItalic      = 1 << 6
+)
+
+// standardFontStyles — стили стандартных шрифтов с кодировкой WinAnsiEncoding
+var standardFontStyles = map[string]standardFontStyle{
+	"Helvetica":             {fontNonsymbolic, 0, 88},
+	"Helvetica-Bold":        {fontNonsymbolic, 0, 140},
+	"Helvetica-Oblique":     {fontNonsymbolic | fontItalic, -12, 88},
+	"Helvetica-BoldOblique": {fontNonsymbolic | fontItalic, -12, 140},
+	"Times-Roman":           {fontSerif | fontNonsymbolic, 0, 84},
+	"Times-Bold":            {fontSerif | fontNonsymbolic, 0, 139},
+	"Times-Italic":          {fontSerif | fontNonsymbolic | fontItalic, -15.5, 76},
+	"Times-BoldItalic":      {fontSerif | fontNonsymbolic | fontItalic, -15, 121},
+	"Courier":               {fontFixedPitch | fontNonsymbolic, 0, 51},
+	"Courier-Bold":          {fontFixedPitch | fontNonsymbolic, 0, 106},
+	"Courier-Oblique":       {fontFixedPitch | fontNonsymbolic | fontItalic, -12, 51},
+	"Courier-BoldOblique":   {fontFixedPitch | fontNonsymbolic | fontItalic, -12, 106},
+}
+
+// Коды символов, для которых записываются ширины
+const (
+	fontFirstChar = 0x20
+	fontLastChar  = 0xff
+)
+
+// standardFont добавляет в документ один из 14 стандартных шрифтов с кодировкой
+// WinAnsiEncoding. Шрифт не встраивается, поэтому добавляется один раз. С PDF 1.5
+// ширины символов и дескриптор обязательны и для стандартных шрифтов, они берутся
+// из тех же метрик, по которым fitText измеряет строки.
+func (w *pdfWriter) standardFont(name string) (types.IndirectRef, error) {
+	if ref, ok := w.fonts[name]; ok {
+		return ref, nil
+	}
+
+	style := standardFontStyles[name]
+	bbox := font.BoundingBox(name)
+	descriptor, err := w.ctx.IndRefForNewObject(types.Dict(map[string]types.Object{
+		"Type":        types.Name("FontDescriptor"),
+		"FontName":    types.Name(name),
+		"Flags":       types.Integer(style.flags),
+		"FontBBox":    bbox.Array(),
+		"ItalicAngle": types.Float(style.italicAngle),
+		"Ascent":      types.Float(bbox.UR.Y),
+		"Descent":     types.Float(bbox.LL.Y),
+		"StemV":       types.Integer(style.stemV),
+	}))
+	if err != nil {
+		return types.IndirectRef{}, err
+	}
+
+	widths := make(types.Array, 0, fontLastChar-fontFirstChar+1)
+	for c := fontFirstChar; c <= fontLastChar; c++ {
+		widths = append(widths, types.Integer(font.CharWidth(name, rune(c))))
+	}
+
+	ref, err := w.ctx.IndRefForNewObject(types.Dict(map[string]types.Object{
+		"Type":           types.Name("Font"),
+		"Subtype":        types.Name("Type1"),
+		"BaseFont":       types.Name(name),
+		"Encoding":       types.Name("WinAnsiEncoding"),
+		"FirstChar":      types.Integer(fontFirstChar),
+		"LastChar":       types.Integer(fontLastChar),
+		"Widths":         widths,
+		"FontDescriptor": *descriptor,
+	}))
+	if err != nil {
+		return types.IndirectRef{}, err
+	}
+	w.fonts[name] = *ref
+	return *ref, nil
+}
+
+// addProfile добавляет в документ ICC-профиль с запасным пространством alternate.
+// Одинаковые профили разных изображений хранятся один раз.
+func (w *pdfWriter) addProfile(profile []byte, alternate string) (types.IndirectRef, error) {
+	key := sha256.Sum256(profile)
+	if ref, ok := w.profiles[key]; ok {
+		return ref, nil
+	}
+	sd := types.StreamDict{
+		Dict: types.Dict(map[string]types.Object{
+			"N":         types.Integer(iccComponents(profile)),
+			"Alternate": types.Name(alternate),
+		}),
+		Content:        profile,
+		FilterPipeline: []types.PDFFilter{{Name: filter.Flate}},
+	}
+	sd.InsertName("Filter", filter.Flate)
+	if err := sd.Encode(); err != nil {
+		return types.IndirectRef{}, err
+	}
+	ref, err := w.ctx.IndRefForNewObject(sd)
+	if err != nil {
+		return types.IndirectRef{}, err
+	}
+	w.profiles[key] = *ref
+	return *ref, nil
+}
+
+// addImage добавляет изображение (и его маску прозрачности) в документ
+func (w *pdfWriter) addImage(img *pdfImage) (types.IndirectRef, error) {
+	sd := types.StreamDict{
+		Dict: types.Dict(map[string]types.Object{
+			"Type":             types.Name("XObject"),
+			"Subtype":          types.Name("Image"),
+			"Width":            types.Integer(img.Width),
+			"Height":           types.Integer(img.Height),
+			"BitsPerComponent": types.Integer(img.BitsPerComponent),
+			"ColorSpace":       types.Name(img.ColorSpace),
+		}),
+		Content: img.Data,
+	}
+
+	if img.Filter == filter.Flate {
+		sd.FilterPipeline = []types.PDFFilter{{Name: filter.Flate}}
+	}
+	sd.InsertName("Filter", img.Filter)
+
+	var parms types.Dict
+	if len(img.DecodeParms) > 0 {
+		parms = types.NewDict()
+		for k, v := range img.DecodeParms {
+			parms.InsertInt(k, v)
+		}
+		sd.Insert("DecodeParms", parms)
+	}
+
+	if len(img.Decode) > 0 {
+		sd.Insert("Decode", types.NewIntegerArray(img.Decode...))
+	}
+
+	// Профиль не подходит изображению, цвет которого уже изменён: например, после
+	// перевода в оттенки серого или перекодирования CMYK в RGB
+	if img.ICC != nil && img.BitsPerComponent > 1 && iccComponents(img.ICC) == deviceComponents[img.ColorSpace] {
+		ref, err := w.addProfile(img.ICC, img.ColorSpace)
+		if err != nil {
+			return types.IndirectRef{}, err
+		}
+		sd.Update("ColorSpace", types.Array{types.Name("ICCBased"), ref})
+	}
+
+	if img.SMask != nil {
+		ref, err := w.addImage(img.SMask)
+		if err != nil {
+			return types.IndirectRef{}, err
+		}
+		sd.Insert("SMask", ref)
+	}
+
+	if err := sd.Encode(); err != nil {
+		return types.IndirectRef{}, err
+	}
+
+	// Отсчёты больше не нужны, в файл пишется только сжатый поток
+	sd.Content = nil
+	sd.FilterPipeline = []types.PDFFilter{{Name: img.Filter, DecodeParms: parms}}
+
+	ref, err := w.ctx.IndRefForNewObject(sd)
+	if err != nil {
+		return types.IndirectRef{}, err
+	}
+	return *ref, nil
+}
+
+// imageSize возвращает размер сжатых данных изображения вместе с маской
+func (w *pdfWriter) imageSize(ref types.IndirectRef) int {
+	sd, _, err := w.ctx.DereferenceStreamDict(ref)
+	if err != nil || sd == nil {
+		return 0
+	}
+	size := len(sd.Raw)
+	if smask := sd.IndirectRefEntry("SMask"); smask != nil {
+		size += w.imageSize(*smask)
+	}
+	return size
+}
+
+// addForm добавляет Form XObject с рисунком width×height пунктов. Матрица формы
+// сводит рисунок к единичному квадрату, поэтому на странице форма размещается
+// так же, как изображение.
+func (w *pdfWriter) addForm(content []byte, width, height float64, resources types.Dict) (types.IndirectRef, error) {
+	sd, err := w.ctx.NewStreamDictForBuf(content)
+	if err != nil {
+		return types.IndirectRef{}, err
+	}
+	sd.InsertName("Type", "XObject")
+	sd.InsertName("Subtype", "Form")
+	sd.Insert("BBox", types.NewNumberArray(0, 0, width, height))
+	sd.Insert("Matrix", types.NewNumberArray(1/width, 0, 0, 1/height, 0, 0))
+	sd.Insert("Resources", resources)
+	if err := sd.Encode(); err != nil {
+		return types.IndirectRef{}, err
+	}
+	ref, err := w.ctx.IndRefForNewObject(*sd)
+	if err != nil {
+		return types.IndirectRef{}, err
+	}
+	return *ref, nil
+}
+
+// addPage добавляет страницу в конец документа
+func (w *pdfWriter) addPage(p pdfPage) error {
+	xobjects := types.NewDict()
+	for name, ref := range p.XObjects {
+		xobjects.Insert(name, ref)
+	}
+
+	resources := types.Dict(map[string]types.Object{
+		"ProcSet": types.NewNameArray("PDF", "ImageB", "ImageC", "ImageI"),
+		"XObject": xobjects,
+	})
+	if len(p.Fonts) > 0 {
+		fonts := types.NewDict()
+		for name, ref := range p.Fonts {
+			fonts.Insert(name, ref)
+		}
+		resources.Insert("Font", fonts)
+		resources.Update("ProcSet", types.NewNameArray("PDF", "Text", "ImageB", "ImageC", "ImageI"))
+	}
+
+	sd, err := w.ctx.NewStreamDictForBuf(p.Content)
+	if err != nil {
+		return err
+	}
+	if err := sd.Encode(); err != nil {
+		return err
+	}
+
+	contentRef, err := w.ctx.IndRefForNewObject(*sd)
+	if err != nil {
+		return err
+	}
+
+	pageBox := types.RectForDim(p.Width, p.Height)
+	pageDict := types.Dict(map[string]types.Object{
+		"Type":      types.Name("Page"),
+		"Parent":    w.pagesRef,
+		"MediaBox":  pageBox.Array(),
+		"Resources": resources,
+		"Contents":  *contentRef,
+	})
+	if p.Rotate != 0 {
+		pageDict.InsertInt("Rotate", p.Rotate)
+	}
+	if p.MediaBox != nil {
+		mediaBox := types.NewRectangle(p.MediaBox.X, p.MediaBox.Y, p.MediaBox.X+p.MediaBox.W, p.MediaBox.Y+p.MediaBox.H)
+		pageDict.Update("MediaBox", mediaBox.Array())
+		pageDict.Insert("CropBox", pageBox.Array())
+	}
+
+	pageRef, err := w.ctx.IndRefForNewObject(pageDict)
+	if err != nil {
+		return err
+	}
+
+	if err := model.AppendPageTree(pageRef, 1, w.pagesDict); err != nil {
+		return err
+	}
+	w.ctx.PageCount++
+	return nil
+}
+
+// addContent добавляет к готовой странице d ещё один content stream поверх
+// остального содержимого или, с under, под ним
+func (w *pdfWriter) addContent(d types.Dict, content []byte, under bool) error {
+	sd, err := w.ctx.NewStreamDictForBuf(content)
+	if err != nil {
+		return err
+	}
+	if err := sd.Encode(); err != nil {
+		return err
+	}
+	ref, err := w.ctx.IndRefForNewObject(*sd)
+	if err != nil {
+		return err
+	}
+
+	contents, ok := d["Contents"].(types.Array)
+	if !ok {
+		contents = types.Array{d["Contents"]}
+	}
+	if under {
+		contents = append(types.Array{*ref}, contents...)
+	} else {
+		contents = append(contents, *ref)
+	}
+	d["Contents"] = contents
+	return nil
+}
+
+// addResource добавляет в ресурсы страницы d объект ref вида kind (Font, XObject,
+// ExtGState) с именем name
+func addResource(d types.Dict, kind, name string, ref types.IndirectRef) {
+	resources := d.DictEntry("Resources")
+	entries := resources.DictEntry(kind)
+	if entries == nil {
+		entries = types.NewDict()
+		resources.Insert(kind, entries)
+	}
+	entries.Update(name, ref)
+	if kind == "Font" {
+		resources.Update("ProcSet", types.NewNameArray("PDF", "Text", "ImageB", "ImageC", "ImageI"))
+	}
+}
+
+// keepInfoDates не даёт pdfcpu заменить даты и Producer словаря Info временем записи
+// и возвращает функцию, отменяющую это. Настройки для этого в pdfcpu нет: подмена
+// выполняется для документов до PDF 2.0, поэтому на время записи документу назначается
+// версия выше известных, а заголовок остаётся 1.7. TestKeepInfoDates проверяет, что
+// обновление pdfcpu этого не сломало.
+func keepInfoDates(ctx *model.Context) (restore func()) {
+	if ctx.XRefTable.Version() >= model.V20 {
+		return func() {}
+	}
+	v := model.V20 + 1
+	ctx.RootVersion = &v
+	return func() { ctx.RootVersion = nil }
+}
+
+// save записывает документ во временный файл рядом с output и атомарно переименовывает его
+func (w *pdfWriter) save(output string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(output), ".img2pdf-*.pdf")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if w.encrypt != nil {
+		w.encrypt.apply(w.ctx)
+	}
+	if w.meta != nil {
+		defer keepInfoDates(w.ctx)()
+	}
+	if err := api.WriteContext(w.ctx, tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), output)
+}
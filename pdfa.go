@@ -0,0 +1,212 @@
+package main
+
+import (
+	"crypto/md5"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// Уровни соответствия PDF/A (-pdfa)
+const (
+	// PDFA2B — PDF/A-2b: воспроизводимый внешний вид документа
+	PDFA2B = "2b"
+)
+
+var pdfaLevels = map[string]bool{
+	"":     true,
+	PDFA2B: true,
+}
+
+// validatePDFA проверяет, что параметры совместимы с PDF/A
+func (o Options) validatePDFA() error {
+	if !pdfaLevels[o.PDFA] {
+		return fmt.Errorf("%w: unknown PDF/A level %q, expected 2b", ErrInvalidInput, o.PDFA)
+	}
+	if o.PDFA == "" {
+		return nil
+	}
+	if o.UserPassword != "" || o.OwnerPassword != "" {
+		return fmt.Errorf("%w: PDF/A forbids encryption", ErrInvalidInput)
+	}
+	if o.hasWatermark() && o.WatermarkOpacity < 1 {
+		return fmt.Errorf("%w: PDF/A forbids transparency, set -watermark-opacity 1", ErrInvalidInput)
+	}
+	// Текст выводится стандартными шрифтами PDF, которые не встраиваются в файл
+	if o.hasStamps() {
+		return fmt.Errorf("%w: PDF/A requires embedded fonts, -header and -footer use standard fonts", ErrInvalidInput)
+	}
+	if o.WatermarkText != "" {
+		return fmt.Errorf("%w: PDF/A requires embedded fonts, -watermark-text uses a standard font", ErrInvalidInput)
+	}
+	if grid, _ := parseNup(o.Nup); (grid.Cols > 0 || o.Booklet) && o.captionHeight() > 0 {
+		return fmt.Errorf("%w: PDF/A requires embedded fonts, -caption uses a standard font", ErrInvalidInput)
+	}
+	return nil
+}
+
+// setOutputIntent добавляет в каталог OutputIntent со встроенным профилем sRGB
+func (w *pdfWriter) setOutputIntent() error {
+	profile, err := w.addProfile(srgbProfile(), "DeviceRGB")
+	if err != nil {
+		return err
+	}
+	intent, err := w.ctx.IndRefForNewObject(types.Dict(map[string]types.Object{
+		"Type":                      types.Name("OutputIntent"),
+		"S":                         types.Name("GTS_PDFA1"),
+		"OutputConditionIdentifier": types.StringLiteral(srgbDescription),
+		"Info":                      types.StringLiteral(srgbDescription),
+		"RegistryName":              types.StringLiteral("http://www.color.org"),
+		"DestOutputProfile":         profile,
+	}))
+	if err != nil {
+		return err
+	}
+	w.ctx.RootDict.Insert("OutputIntents", types.Array{*intent})
+	return nil
+}
+
+// setFileID задаёт идентификатор файла в трейлере. pdfcpu при записи меняет только
+// второй элемент, первый остаётся постоянным, как требует PDF/A.
+func (w *pdfWriter) setFileID(output string, m *docInfo) {
+	sum := md5.Sum(fmt.Appendf(nil, "%s|%d|%s|%d", output, time.Now().UnixNano(), m.xmp(), w.ctx.PageCount))
+	id := types.HexLiteral(fmt.Sprintf("%X", sum))
+	w.ctx.ID = types.Array{id, id}
+}
+
+// checkPDFA проверяет записанный документ или, при -dry-run, собранный в памяти,
+// и сообщает о нарушениях PDF/A в отчёте и предупреждениях
+func (c *Converter) checkPDFA(w *pdfWriter, output string) error {
+	ctx := w.ctx
+	if !c.opts.DryRun {
+		var err error
+		if ctx, err = api.ReadContextFile(output); err != nil {
+			return err
+		}
+	}
+	c.report.PDFA = pdfaIssues(ctx)
+	for _, issue := range c.report.PDFA {
+		fmt.Printf("Warning: PDF/A: %s\n", issue)
+	}
+	return nil
+}
+
+// pdfaIssues проверяет документ на требования PDF/A-2b, которые могут нарушить
+// параметры конвертации, и возвращает список нарушений
+func pdfaIssues(ctx *model.Context) []string {
+	var issues []string
+	if ctx.Encrypt != nil {
+		issues = append(issues, "the document is encrypted")
+	}
+	if ctx.XRefTable.Version() > model.V17 {
+		issues = append(issues, fmt.Sprintf("PDF version %s is newer than 1.7", ctx.XRefTable.VersionString()))
+	}
+	if len(ctx.ID) != 2 {
+		issues = append(issues, "the trailer has no file identifier")
+	}
+	if !hasOutputIntent(ctx) {
+		issues = append(issues, "no output intent with an embedded ICC profile")
+	}
+	if xmp := metadataPacket(ctx); !strings.Contains(xmp, "<pdfaid:part>2</pdfaid:part>") ||
+		!strings.Contains(xmp, "<pdfaid:conformance>B</pdfaid:conformance>") {
+		issues = append(issues, "XMP metadata does not declare PDF/A-2b")
+	}
+
+	objNrs := make([]int, 0, len(ctx.Table))
+	for nr, entry := range ctx.Table {
+		if entry != nil && !entry.Free && entry.Object != nil {
+			objNrs = append(objNrs, nr)
+		}
+	}
+	slices.Sort(objNrs)
+	for _, nr := range objNrs {
+		switch o := ctx.Table[nr].Object.(type) {
+		case types.Dict:
+			if issue := pdfaDictIssue(ctx, nr, o); issue != "" {
+				issues = append(issues, issue)
+			}
+		case types.StreamDict:
+			if sub := o.Subtype(); sub == nil || *sub != "Image" {
+				continue
+			}
+			if _, ok := o.Find("SMask"); ok {
+				issues = append(issues, fmt.Sprintf("image (object %d) has a soft mask", nr))
+			}
+			if b := o.BooleanEntry("Interpolate"); b != nil && *b {
+				issues = append(issues, fmt.Sprintf("image (object %d) asks for interpolation", nr))
+			}
+			if cs := o.NameEntry("ColorSpace"); cs != nil && *cs == "DeviceCMYK" {
+				issues = append(issues, fmt.Sprintf("image (object %d) uses DeviceCMYK with an RGB output intent", nr))
+			}
+		}
+	}
+	return issues
+}
+
+// pdfaDictIssue проверяет шрифт или графическое состояние
+func pdfaDictIssue(ctx *model.Context, nr int, d types.Dict) string {
+	if d.Type() == nil {
+		return ""
+	}
+	switch *d.Type() {
+	case "Font":
+		sub := d.Subtype()
+		if sub == nil || *sub == "Type0" || *sub == "Type3" {
+			// Шрифты Type0 проверяются по дочерним, Type3 описаны в самом PDF
+			return ""
+		}
+		name := "?"
+		if n := d.NameEntry("BaseFont"); n != nil {
+			name = *n
+		}
+		fd, err := ctx.DereferenceDict(d["FontDescriptor"])
+		if err != nil || fd == nil || (fd["FontFile"] == nil && fd["FontFile2"] == nil && fd["FontFile3"] == nil) {
+			return fmt.Sprintf("font %s (object %d) is not embedded", name, nr)
+		}
+	case "ExtGState":
+		for _, key := range []string{"ca", "CA"} {
+			if v, ok := d[key].(types.Float); ok && v < 1 {
+				return fmt.Sprintf("graphics state (object %d) is transparent", nr)
+			}
+		}
+		if sm, ok := d["SMask"].(types.Name); d["SMask"] != nil && (!ok || sm != "None") {
+			return fmt.Sprintf("graphics state (object %d) has a soft mask", nr)
+		}
+	}
+	return ""
+}
+
+// hasOutputIntent сообщает, что в каталоге есть OutputIntent PDF/A со встроенным профилем
+func hasOutputIntent(ctx *model.Context) bool {
+	intents, err := ctx.DereferenceArray(ctx.RootDict["OutputIntents"])
+	if err != nil {
+		return false
+	}
+	for _, o := range intents {
+		d, err := ctx.DereferenceDict(o)
+		if err != nil || d == nil {
+			continue
+		}
+		if s := d.NameEntry("S"); s != nil && *s == "GTS_PDFA1" && d["DestOutputProfile"] != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// metadataPacket возвращает пакет XMP каталога или пустую строку
+func metadataPacket(ctx *model.Context) string {
+	sd, _, err := ctx.DereferenceStreamDict(ctx.RootDict["Metadata"])
+	if err != nil || sd == nil {
+		return ""
+	}
+	if err := sd.Decode(); err != nil {
+		return ""
+	}
+	return string(sd.Content)
+}
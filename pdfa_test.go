@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+)
+
+func TestSRGBProfile(t *testing.T) {
+	p := srgbProfile()
+	if size := binary.BigEndian.Uint32(p); int(size) != len(p) {
+		t.Fatalf("Profile size field = %d; want %d", size, len(p))
+	}
+	if string(p[12:24]) != "mntrRGB XYZ " || string(p[36:40]) != "acsp" {
+		t.Errorf("Unexpected profile header % x", p[:40])
+	}
+	count := int(binary.BigEndian.Uint32(p[128:]))
+	if count != 9 {
+		t.Fatalf("Tag count = %d; want 9", count)
+	}
+	for i := range count {
+		e := p[132+12*i:]
+		off, n := binary.BigEndian.Uint32(e[4:]), binary.BigEndian.Uint32(e[8:])
+		if off%4 != 0 || int(off+n) > len(p) || string(p[off:off+4]) == "\x00\x00\x00\x00" {
+			t.Errorf("Tag %s at %d+%d is out of place", e[:4], off, n)
+		}
+	}
+}
+
+func TestValidatePDFA(t *testing.T) {
+	tests := []struct {
+		name string
+		set  func(*Options)
+	}{
+		{"level", func(o *Options) { o.PDFA = "1a" }},
+		{"encryption", func(o *Options) { o.OwnerPassword = "secret" }},
+		{"watermark", func(o *Options) { o.WatermarkText = "DRAFT" }},
+		{"stamps", func(o *Options) { o.Footer = "{page}"; o.StampOpacity = 0.5 }},
+		// Стандартные шрифты не встраиваются даже без прозрачности
+		{"opaque footer", func(o *Options) { o.Footer = "{page}" }},
+		{"opaque text watermark", func(o *Options) { o.WatermarkText = "DRAFT"; o.WatermarkOpacity = 1 }},
+		{"captions", func(o *Options) { o.Nup = "2x2"; o.Caption = "name" }},
+	}
+	for _, tt := range tests {
+		opts := DefaultOptions()
+		opts.PDFA = PDFA2B
+		tt.set(&opts)
+		if err := opts.Validate(); !IsInvalidInput(err) {
+			t.Errorf("%s: expected ErrInvalidInput, got %v", tt.name, err)
+		}
+	}
+}
+
+func TestConvert_PDFA(t *testing.T) {
+	tmpDir := t.TempDir()
+	createTree(t, tmpDir, "1.jpg")
+	if err := createTestImageWithAlpha(filepath.Join(tmpDir, "2.png"), 20, 20, "png", 128); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := DefaultOptions()
+	opts.PDFA = PDFA2B
+	opts.Title = "Records"
+	output := filepath.Join(tmpDir, "out.pdf")
+	c := NewConverterWithOptions(opts)
+	if err := c.Convert(tmpDir, output, "nam"); err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+	if err := api.ValidateFile(output, nil); err != nil {
+		t.Fatalf("Output is not a valid PDF: %v", err)
+	}
+	if issues := c.Report().PDFA; len(issues) > 0 {
+		t.Errorf("PDF/A issues: %v", issues)
+	}
+	if alpha := c.Report().Pages[1].Alpha; !strings.HasPrefix(alpha, "flattened") {
+		t.Errorf("Transparent image alpha = %q; want flattened", alpha)
+	}
+
+	ctx, err := api.ReadContextFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hasOutputIntent(ctx) {
+		t.Error("No PDF/A output intent")
+	}
+	if xmp := metadataPacket(ctx); !strings.Contains(xmp, "<pdfaid:part>2</pdfaid:part>") {
+		t.Errorf("XMP does not declare PDF/A-2:\n%s", xmp)
+	}
+	if len(ctx.ID) != 2 {
+		t.Errorf("Trailer ID = %v; want two identifiers", ctx.ID)
+	}
+
+	// Текст SVG вывел бы стандартный шрифт, который не встраивается
+	svgPath := filepath.Join(tmpDir, "3.svg")
+	if err := os.WriteFile(svgPath, []byte(testDrawing), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err = NewConverterWithOptions(opts).Convert(svgPath, output, "seq")
+	if !IsConversionError(err) || !strings.Contains(err.Error(), "PDF/A") {
+		t.Errorf("SVG text: expected a PDF/A conversion error, got %v", err)
+	}
+}
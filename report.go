@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+// PageReport — сведения об одной странице результата или об ячейке сетки (Cell с 1).
+// Deskew — исправленный наклон в градусах, положительный — по часовой стрелке.
+type PageReport struct {
+	Page      int     `json:"page"`
+	Label     string  `json:"label,omitempty"`
+	Cell      int     `json:"cell,omitempty"`
+	Source    string  `json:"source"`
+	Blank     bool    `json:"blank,omitempty"`
+	Part      string  `json:"part,omitempty"`
+	Width     int     `json:"width"`
+	Height    int     `json:"height"`
+	Rotate    int     `json:"rotate,omitempty"`
+	Flip      bool    `json:"flip,omitempty"`
+	Crop      string  `json:"crop,omitempty"`
+	Deskew    float64 `json:"deskew,omitempty"`
+	Resampled bool    `json:"resampled"`
+	Encoding  string  `json:"encoding"`
+	Quality   int     `json:"quality,omitempty"`
+	Reason    string  `json:"reason"`
+	Alpha     string  `json:"alpha,omitempty"`
+	Bytes     int     `json:"bytes"`
+
+	// taken — время съёмки изображения для колонтитулов
+	taken time.Time
+}
+
+// RemovedImage — изображение, исключённое как пустая страница.
+// Coverage — покрытие краской в процентах.
+type RemovedImage struct {
+	Source   string  `json:"source"`
+	Coverage float64 `json:"coverage"`
+}
+
+// Report — отчёт о конвертации, заполняется по ходу создания PDF
+type Report struct {
+	Output  string         `json:"output"`
+	DryRun  bool           `json:"dry_run"`
+	Pages   []PageReport   `json:"pages"`
+	Removed []RemovedImage `json:"removed,omitempty"`
+	// PDFA — нарушения PDF/A, найденные проверкой готового документа
+	PDFA []string `json:"pdfa_issues,omitempty"`
+}
+
+// WriteJSON сохраняет отчёт в файл
+func (r *Report) WriteJSON(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Print выводит отчёт в виде таблицы
+func (r *Report) Print(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PAGE\tSOURCE\tPIXELS\tENCODING\tBYTES\tREASON")
+	for _, p := range r.Pages {
+		enc := p.Encoding
+		if p.Quality > 0 {
+			enc = fmt.Sprintf("%s q%d", enc, p.Quality)
+		}
+		pixels := fmt.Sprintf("%dx%d", p.Width, p.Height)
+		if p.Deskew != 0 {
+			pixels += fmt.Sprintf(" (deskewed %+.2f°)", p.Deskew)
+		}
+		if p.Resampled {
+			pixels += " (resampled)"
+		}
+		source := p.Source
+		if p.Blank {
+			source, pixels = "(blank)", "-"
+		}
+		page := strconv.Itoa(p.Page)
+		if p.Label != "" && p.Label != page {
+			page += " (" + p.Label + ")"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%s\n", page, source, pixels, enc, p.Bytes, p.Reason)
+	}
+	tw.Flush()
+
+	for _, r := range r.Removed {
+		fmt.Fprintf(w, "removed blank page %s (ink %.3f%%)\n", r.Source, r.Coverage)
+	}
+}
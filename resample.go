@@ -0,0 +1,86 @@
+package main
+
+import (
+	"image"
+	"math"
+	"strings"
+
+	"golang.org/x/image/draw"
+)
+
+// lanczos3 — ядро Ланцоша с окном 3, в x/image/draw его нет
+var lanczos3 = &draw.Kernel{
+	Support: 3,
+	At: func(t float64) float64 {
+		if t == 0 {
+			return 1
+		}
+		if t < 0 {
+			t = -t
+		}
+		if t >= 3 {
+			return 0
+		}
+		x := math.Pi * t
+		return 3 * math.Sin(x) * math.Sin(x/3) / (x * x)
+	},
+}
+
+// resampleFilters — фильтры, доступные через -resample
+var resampleFilters = map[string]draw.Interpolator{
+	"nearest":    draw.NearestNeighbor,
+	"bilinear":   draw.BiLinear,
+	"catmullrom": draw.CatmullRom,
+	"lanczos":    lanczos3,
+}
+
+// targetSize вычисляет размер в пикселях, до которого нужно уменьшить изображение
+// w×h, выводимое на странице шириной dispW пунктов, чтобы не превышать dpi и maxDim.
+// Изображение никогда не увеличивается.
+func targetSize(w, h int, dispW float64, dpi, maxDim int) (int, int) {
+	scale := 1.0
+
+	if dpi > 0 && dispW > 0 {
+		scale = math.Min(scale, dispW/72*float64(dpi)/float64(w))
+	}
+
+	if maxDim > 0 {
+		scale = math.Min(scale, float64(maxDim)/float64(max(w, h)))
+	}
+
+	if scale >= 1 {
+		return w, h
+	}
+
+	tw := max(1, int(math.Round(float64(w)*scale)))
+	th := max(1, int(math.Round(float64(h)*scale)))
+	return tw, th
+}
+
+// newImageLike создаёт изображение w×h с той же цветовой моделью и глубиной, что у img
+func newImageLike(img image.Image, w, h int) draw.Image {
+	r := image.Rect(0, 0, w, h)
+	switch img.(type) {
+	case *image.Gray:
+		return image.NewGray(r)
+	case *image.Gray16:
+		return image.NewGray16(r)
+	case *image.CMYK:
+		return image.NewCMYK(r)
+	case *image.RGBA64, *image.NRGBA64:
+		return image.NewNRGBA64(r)
+	}
+	return image.NewNRGBA(r)
+}
+
+// resampleImage уменьшает img до w×h выбранным фильтром
+func resampleImage(img image.Image, w, h int, filter string) image.Image {
+	interp, ok := resampleFilters[strings.ToLower(filter)]
+	if !ok {
+		interp = draw.CatmullRom
+	}
+
+	dst := newImageLike(img, w, h)
+	interp.Scale(dst, dst.Bounds(), img, img.Bounds(), draw.Src, nil)
+	return dst
+}
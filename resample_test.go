@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+)
+
+// pdfImages возвращает изображения PDF по страницам в порядке следования страниц
+func pdfImages(t *testing.T, path string) []model.Image {
+	t.Helper()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	pages, err := api.Images(f, nil, nil)
+	if err != nil {
+		t.Fatalf("Failed to list images: %v", err)
+	}
+
+	var images []model.Image
+	for _, page := range pages {
+		for _, img := range page {
+			if !img.IsImgMask && img.Name != "" {
+				images = append(images, img)
+			}
+		}
+	}
+	return images
+}
+
+func TestTargetSize(t *testing.T) {
+	tests := []struct {
+		name         string
+		w, h         int
+		dispW        float64
+		dpi, maxDim  int
+		wantW, wantH int
+	}{
+		{"no limits", 4000, 3000, 500, 0, 0, 4000, 3000},
+		// 500pt = 6.94in, при 144 dpi нужно 1000 пикселей
+		{"dpi", 4000, 3000, 500, 144, 0, 1000, 750},
+		{"max dimension", 4000, 3000, 4000, 0, 2000, 2000, 1500},
+		{"stricter wins", 4000, 3000, 500, 144, 800, 800, 600},
+		{"never upscale", 100, 50, 500, 300, 0, 100, 50},
+		{"max dimension already fits", 100, 50, 100, 0, 200, 100, 50},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, h := targetSize(tt.w, tt.h, tt.dispW, tt.dpi, tt.maxDim)
+			if w != tt.wantW || h != tt.wantH {
+				t.Errorf("targetSize = %dx%d; want %dx%d", w, h, tt.wantW, tt.wantH)
+			}
+		})
+	}
+}
+
+func TestResampleImageFilters(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "src.png")
+	if err := createTestImage(path, 200, 100, "png"); err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := loadImage(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name := range resampleFilters {
+		img := resampleImage(src.Image, 50, 25, name)
+		if b := img.Bounds(); b.Dx() != 50 || b.Dy() != 25 {
+			t.Errorf("%s: got %dx%d, want 50x25", name, b.Dx(), b.Dy())
+		}
+	}
+
+	// 16-битные изображения остаются 16-битными
+	r := image.Rect(0, 0, 200, 100)
+	for img, want := range map[image.Image]string{
+		image.NewGray16(r):  "*image.Gray16",
+		image.NewRGBA64(r):  "*image.NRGBA64",
+		image.NewNRGBA64(r): "*image.NRGBA64",
+	} {
+		if got := fmt.Sprintf("%T", resampleImage(img, 50, 25, "lanczos")); got != want {
+			t.Errorf("%T resampled to %s; want %s", img, got, want)
+		}
+	}
+}
+
+func TestConvert_DownsampleToDPI(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	photo := filepath.Join(tmpDir, "photo.jpg")
+	small := filepath.Join(tmpDir, "small.png")
+	if err := createTestJPG(photo, 2000, 1000); err != nil {
+		t.Fatal(err)
+	}
+	if err := createTestImage(small, 40, 20, "png"); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := DefaultOptions()
+	opts.PageSize = "A4L"
+	opts.DPI = 72
+	opts.Resample = "lanczos"
+
+	output := filepath.Join(tmpDir, "out.pdf")
+	if err := NewConverterWithOptions(opts).Convert(photo+","+small, output, "seq"); err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+
+	images := pdfImages(t, output)
+	if len(images) != 2 {
+		t.Fatalf("Expected 2 images, got %d", len(images))
+	}
+
+	// A4L — 842pt по ширине, при 72 dpi фотография должна уменьшиться до 842 пикселей
+	if images[0].Width != 842 {
+		t.Errorf("Expected photo width 842, got %d", images[0].Width)
+	}
+
+	// Маленькое изображение не увеличивается
+	if images[1].Width != 40 || images[1].Height != 20 {
+		t.Errorf("Small image should keep 40x20, got %dx%d", images[1].Width, images[1].Height)
+	}
+}
+
+func TestConvert_MaxDimensionKeepsPageSize(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	photo := filepath.Join(tmpDir, "photo.jpg")
+	if err := createTestJPG(photo, 1200, 600); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := DefaultOptions()
+	opts.MaxDimension = 300
+
+	output := filepath.Join(tmpDir, "out.pdf")
+	if err := NewConverterWithOptions(opts).Convert(photo, output, "seq"); err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+
+	images := pdfImages(t, output)
+	if len(images) != 1 || images[0].Width != 300 || images[0].Height != 150 {
+		t.Fatalf("Expected one 300x150 image, got %+v", images)
+	}
+
+	// Размер страницы по-прежнему определяется исходным изображением
+	dims, err := api.PageDimsFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dims[0].Width != 1200 || dims[0].Height != 600 {
+		t.Errorf("Expected 1200x600 page, got %.0fx%.0f", dims[0].Width, dims[0].Height)
+	}
+}
+
+func TestOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Options)
+	}{
+		{"unknown page size", func(o *Options) { o.PageSize = "A42" }},
+		{"negative margin", func(o *Options) { o.Margin = -1 }},
+		{"negative dpi", func(o *Options) { o.DPI = -1 }},
+		{"unknown filter", func(o *Options) { o.Resample = "bicubic" }},
+		{"zero max skew", func(o *Options) { o.MaxSkew = 0 }},
+		{"max skew too large", func(o *Options) { o.MaxSkew = 60 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			tt.modify(&opts)
+			if err := opts.Validate(); !IsInvalidInput(err) {
+				t.Errorf("Expected invalid input error, got %v", err)
+			}
+		})
+	}
+}
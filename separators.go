@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"maps"
+	"slices"
+)
+
+// Разделители между входными группами (-separate)
+const (
+	// SeparateNone не разделяет группы
+	SeparateNone = "none"
+	// SeparatePage вставляет страницу-разделитель между группами
+	SeparatePage = "page"
+	// SeparateOdd вставляет пустую страницу, когда без неё группа начнётся с чётной
+	// страницы, чтобы при двусторонней печати каждая группа начиналась с нового листа
+	SeparateOdd = "odd"
+)
+
+var separateModes = map[string]bool{
+	SeparateNone: true,
+	SeparatePage: true,
+	SeparateOdd:  true,
+}
+
+// separatorRule — страница-разделитель из манифеста после изображения с номером After
+// во входном списке, 0 — перед первым
+type separatorRule struct {
+	After int    `json:"after"`
+	Color string `json:"color,omitempty"`
+}
+
+// separatorPlan решает, где вставить разделители, по мере вывода страниц
+type separatorPlan struct {
+	every int
+	mode  string
+	color *color.NRGBA
+	// manual — разделители из манифеста по номеру изображения, после которого они идут
+	manual map[int][]ImageInfo
+
+	group   string
+	content int
+	placed  int
+	everyAt int
+}
+
+// separatorPlan собирает правила вставки разделителей из параметров и манифеста
+func (c *Converter) separatorPlan(rules []separatorRule) (*separatorPlan, error) {
+	p := &separatorPlan{
+		every:  c.opts.BlankEvery,
+		mode:   c.opts.Separate,
+		manual: map[int][]ImageInfo{},
+	}
+	var err error
+	if p.color, err = separatorColor(c.opts.SeparatorColor); err != nil {
+		return nil, err
+	}
+	for _, r := range rules {
+		col := p.color
+		if r.Color != "" {
+			if col, err = separatorColor(r.Color); err != nil {
+				return nil, err
+			}
+		}
+		p.manual[r.After] = append(p.manual[r.After], ImageInfo{Blank: true, Color: col})
+	}
+	return p, nil
+}
+
+// separatorColor разбирает цвет разделителя. Для белого возвращается nil: страница остаётся пустой.
+func separatorColor(s string) (*color.NRGBA, error) {
+	col, err := parseColor(s)
+	if err != nil {
+		return nil, err
+	}
+	if col == (color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}) {
+		return nil, nil
+	}
+	return &col, nil
+}
+
+// before возвращает разделители перед очередной страницей изображения img с номером
+// index. first — страница первая из полученных из этого изображения.
+func (p *separatorPlan) before(img ImageInfo, index int, first bool) []ImageInfo {
+	var seps []ImageInfo
+	if first {
+		seps = append(seps, p.manual[index-1]...)
+	}
+	if p.every > 0 && p.content > 0 && p.content%p.every == 0 && p.everyAt != p.content {
+		p.everyAt = p.content
+		seps = append(seps, ImageInfo{Blank: true, Color: p.color})
+	}
+	if first && index > 1 && img.Group != p.group {
+		switch p.mode {
+		case SeparatePage:
+			seps = append(seps, ImageInfo{Blank: true, Color: p.color})
+		case SeparateOdd:
+			if (p.placed+len(seps))%2 == 1 {
+				seps = append(seps, ImageInfo{Blank: true})
+			}
+		}
+	}
+	if first {
+		p.group = img.Group
+	}
+	return seps
+}
+
+// place учитывает выведенную страницу: содержимое или разделитель
+func (p *separatorPlan) place(content bool) {
+	p.placed++
+	if content {
+		p.content++
+	}
+}
+
+// end возвращает разделители из манифеста после последнего из count изображений
+func (p *separatorPlan) end(count int) []ImageInfo {
+	var seps []ImageInfo
+	for _, after := range slices.Sorted(maps.Keys(p.manual)) {
+		if after >= count {
+			seps = append(seps, p.manual[after]...)
+		}
+	}
+	return seps
+}
+
+// apply вставляет разделители в список заранее, считая каждое изображение одной
+// страницей. Используется, когда порядок страниц нужен до вывода (брошюра).
+func (p *separatorPlan) apply(images []ImageInfo) []ImageInfo {
+	var result []ImageInfo
+	for i, img := range images {
+		for _, sep := range p.before(img, i+1, true) {
+			result = append(result, sep)
+			p.place(false)
+		}
+		result = append(result, img)
+		p.place(true)
+	}
+	return append(result, p.end(len(images))...)
+}
+
+// validateSeparators проверяет параметры разделителей
+func (o Options) validateSeparators() error {
+	if o.BlankEvery < 0 {
+		return fmt.Errorf("%w: negative blank page interval", ErrInvalidInput)
+	}
+	if !separateModes[o.Separate] {
+		return fmt.Errorf("%w: unknown separator mode %q", ErrInvalidInput, o.Separate)
+	}
+	_, err := parseColor(o.SeparatorColor)
+	return err
+}
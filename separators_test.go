@@ -0,0 +1,137 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+)
+
+// sequence описывает список страниц: имя изображения или "-" для разделителя
+func sequence(images []ImageInfo) string {
+	var names []string
+	for _, img := range images {
+		if img.Blank {
+			names = append(names, "-")
+		} else {
+			names = append(names, img.Path)
+		}
+	}
+	return strings.Join(names, " ")
+}
+
+func TestSeparatorPlan(t *testing.T) {
+	var images []ImageInfo
+	for _, name := range []string{"a1", "a2", "b1", "b2", "b3", "c1"} {
+		images = append(images, ImageInfo{Path: name, Group: name[:1]})
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*Options)
+		rules  []separatorRule
+		want   string
+	}{
+		{"none", func(o *Options) {}, nil, "a1 a2 b1 b2 b3 c1"},
+		{"page", func(o *Options) { o.Separate = SeparatePage }, nil, "a1 a2 - b1 b2 b3 - c1"},
+		// b начинается с третьей страницы, c без разделителя начался бы с шестой
+		{"odd", func(o *Options) { o.Separate = SeparateOdd }, nil, "a1 a2 b1 b2 b3 - c1"},
+		{"every", func(o *Options) { o.BlankEvery = 2 }, nil, "a1 a2 - b1 b2 - b3 c1"},
+		{"manual", func(o *Options) {}, []separatorRule{{After: 0}, {After: 3}, {After: 9}}, "- a1 a2 b1 - b2 b3 c1 -"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			tt.modify(&opts)
+			plan, err := NewConverterWithOptions(opts).separatorPlan(tt.rules)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := sequence(plan.apply(images)); got != tt.want {
+				t.Errorf("Pages = %q; want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvert_SeparateOdd(t *testing.T) {
+	tmpDir := t.TempDir()
+	var inputs []string
+	for _, doc := range []struct {
+		dir   string
+		pages int
+	}{{"letter", 2}, {"invoice", 2}, {"contract", 1}} {
+		dir := filepath.Join(tmpDir, doc.dir)
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < doc.pages; i++ {
+			if err := createTestImage(filepath.Join(dir, string(rune('a'+i))+".png"), 100, 150, "png"); err != nil {
+				t.Fatal(err)
+			}
+		}
+		inputs = append(inputs, dir)
+	}
+
+	manifest := filepath.Join(tmpDir, "manifest.json")
+	if err := os.WriteFile(manifest, []byte(`{"separators": [{"after": 0, "color": "#ff0000"}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := DefaultOptions()
+	opts.Separate = SeparateOdd
+	opts.Manifest = manifest
+
+	output := filepath.Join(tmpDir, "print.pdf")
+	converter := NewConverterWithOptions(opts)
+	if err := converter.Convert(strings.Join(inputs, ","), output, "seq"); err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+	if err := api.ValidateFile(output, nil); err != nil {
+		t.Fatalf("Invalid PDF: %v", err)
+	}
+
+	// Красный разделитель, письмо на 2-3, пустая 4-я, счёт на 5-6, договор на 7-й
+	var got []string
+	for _, p := range converter.Report().Pages {
+		if p.Blank {
+			got = append(got, p.Reason)
+		} else {
+			got = append(got, filepath.Base(filepath.Dir(p.Source)))
+		}
+	}
+	want := "separator #ff0000,letter,letter,blank page,invoice,invoice,contract"
+	if strings.Join(got, ",") != want {
+		t.Errorf("Pages = %v; want %s", got, want)
+	}
+	if n, err := countPDFPages(output); err != nil || n != 7 {
+		t.Errorf("Expected 7 pages, got %d (%v)", n, err)
+	}
+
+	// В режиме auto пустая страница повторяет размер предыдущей
+	dims, err := api.PageDimsFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dims[3].Width != 100 || dims[3].Height != 150 {
+		t.Errorf("Blank page is %.0fx%.0f; want 100x150", dims[3].Width, dims[3].Height)
+	}
+}
+
+func TestLoadManifest_InvalidSeparator(t *testing.T) {
+	tmpDir := t.TempDir()
+	for i, body := range []string{
+		`{"separators": [{"after": -1}]}`,
+		`{"separators": [{"after": 1, "color": "nope"}]}`,
+	} {
+		path := filepath.Join(tmpDir, "m"+string(rune('0'+i))+".json")
+		if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadManifest(path); !IsInvalidInput(err) {
+			t.Errorf("%s: expected invalid input error, got %v", body, err)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"image"
+	"math"
+	"slices"
+)
+
+// Режимы деления изображений (-split)
+const (
+	// SplitNone оставляет изображения целыми
+	SplitNone = "none"
+	// SplitSpread делит альбомные развороты на две страницы
+	SplitSpread = "spread"
+)
+
+var splitModes = map[string]bool{
+	SplitNone:   true,
+	SplitSpread: true,
+}
+
+const (
+	// gutterBand — доля ширины разворота по обе стороны от середины, где ищется корешок
+	gutterBand = 0.1
+	// gutterContrast — насколько яркость корешка должна отличаться от соседних столбцов
+	gutterContrast = 8
+	// gutterSmooth — полуширина окна сглаживания профиля яркости по столбцам
+	gutterSmooth = 2
+)
+
+// spreadPart — часть изображения, из которой получается отдельная страница
+type spreadPart struct {
+	Rect image.Rectangle
+	// Name — left или right для половин разворота
+	Name string
+}
+
+// splitSpread делит альбомный разворот на левую и правую страницы по корешку и возвращает
+// их в порядке чтения. Стороны определяются с учётом ориентации orient, координаты
+// частей остаются координатами img. Книжные изображения возвращаются целиком.
+func splitSpread(img image.Image, orient orientation, rtl bool) []spreadPart {
+	b := img.Bounds()
+	shown := orientGray(toGray(img), orient)
+	w, h := shown.Bounds().Dx(), shown.Bounds().Dy()
+	if w <= h {
+		return []spreadPart{{Rect: b}}
+	}
+
+	gutter := findGutter(shown)
+
+	// Левая страница — это разворот без правой части, и наоборот. Срезаемые стороны
+	// переводятся из показанного изображения в исходное так же, как при обрезке.
+	cut := func(side, amount int) image.Rectangle {
+		var sides [4]int
+		for i := range sides {
+			if orient.displaySide(i) == side {
+				sides[i] = amount
+			}
+		}
+		return image.Rect(b.Min.X+sides[0], b.Min.Y+sides[1], b.Max.X-sides[2], b.Max.Y-sides[3])
+	}
+
+	parts := []spreadPart{
+		{Rect: cut(2, w-gutter), Name: "left"},
+		{Rect: cut(0, gutter), Name: "right"},
+	}
+	if rtl {
+		slices.Reverse(parts)
+	}
+	return parts
+}
+
+// findGutter ищет корешок у середины разворота: столбец, яркость которого сильнее всего
+// отличается от типичной для центральной полосы (тень или светлая щель между страницами).
+// Если такого столбца нет, разворот делится ровно пополам.
+func findGutter(img *image.Gray) int {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	mid := w / 2
+	band := max(int(float64(w)*gutterBand), gutterSmooth+1)
+	from, to := max(mid-band, 0), min(mid+band, w-1)
+
+	// Средняя яркость столбцов центральной полосы
+	means := make([]float64, w)
+	for y := 0; y < h; y++ {
+		row := img.Pix[y*img.Stride : y*img.Stride+w]
+		for x := from; x <= to; x++ {
+			means[x] += float64(row[x])
+		}
+	}
+	for x := from; x <= to; x++ {
+		means[x] /= float64(h)
+	}
+
+	smoothed := make([]float64, 0, to-from+1)
+	for x := from; x <= to; x++ {
+		var sum float64
+		n := 0
+		for i := max(x-gutterSmooth, from); i <= min(x+gutterSmooth, to); i++ {
+			sum += means[i]
+			n++
+		}
+		smoothed = append(smoothed, sum/float64(n))
+	}
+
+	sorted := slices.Clone(smoothed)
+	slices.Sort(sorted)
+	median := sorted[len(sorted)/2]
+
+	diffs := make([]float64, len(smoothed))
+	for i, v := range smoothed {
+		diffs[i] = math.Abs(v - median)
+	}
+
+	best := -1
+	for i, d := range diffs {
+		// При равном отличии выбирается столбец ближе к середине
+		if d >= gutterContrast && (best < 0 || d > diffs[best] || (d == diffs[best] && abs(from+i-mid) < abs(from+best-mid))) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return mid
+	}
+
+	// Широкая тень даёт плато, корешок — его середина
+	left, right := best, best
+	for left > 0 && diffs[left-1] >= diffs[best]-0.5 {
+		left--
+	}
+	for right < len(diffs)-1 && diffs[right+1] >= diffs[best]-0.5 {
+		right++
+	}
+	return from + (left+right)/2
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
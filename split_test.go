@@ -0,0 +1,150 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"path/filepath"
+	"testing"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+)
+
+// newSpread рисует белый разворот w×h с тенью корешка в столбцах [from, to)
+func newSpread(w, h, from, to int) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(from, 0, to, h), image.NewUniform(color.Gray{Y: 120}), image.Point{}, draw.Src)
+	return img
+}
+
+func TestFindGutter(t *testing.T) {
+	tests := []struct {
+		name     string
+		img      *image.Gray
+		min, max int
+	}{
+		{"shadow", newSpread(400, 200, 215, 226), 218, 222},
+		{"no gutter", newSpread(400, 200, 0, 0), 200, 200},
+		// Тень далеко от середины не считается корешком
+		{"far shadow", newSpread(400, 200, 20, 30), 200, 200},
+	}
+	for _, tt := range tests {
+		if got := findGutter(tt.img); got < tt.min || got > tt.max {
+			t.Errorf("%s: findGutter = %d; want %d..%d", tt.name, got, tt.min, tt.max)
+		}
+	}
+}
+
+func TestSplitSpread(t *testing.T) {
+	spread := newSpread(400, 200, 0, 0)
+
+	parts := splitSpread(spread, orientation{}, false)
+	if len(parts) != 2 || parts[0].Name != "left" || parts[1].Name != "right" {
+		t.Fatalf("Expected left and right parts, got %+v", parts)
+	}
+	if parts[0].Rect != image.Rect(0, 0, 200, 200) || parts[1].Rect != image.Rect(200, 0, 400, 200) {
+		t.Errorf("Parts = %v, %v", parts[0].Rect, parts[1].Rect)
+	}
+
+	rtl := splitSpread(spread, orientation{}, true)
+	if rtl[0].Name != "right" || rtl[1].Name != "left" {
+		t.Errorf("RTL order = %s, %s; want right, left", rtl[0].Name, rtl[1].Name)
+	}
+
+	// Книжное изображение не делится
+	if parts := splitSpread(newSpread(200, 400, 0, 0), orientation{}, false); len(parts) != 1 {
+		t.Errorf("Portrait image split into %d parts", len(parts))
+	}
+
+	// Разворот, снятый боком: после поворота на 90° левая страница — низ исходного изображения
+	sideways := newSpread(200, 400, 0, 0)
+	parts = splitSpread(sideways, orientation{Rotate: 90}, false)
+	if len(parts) != 2 || parts[0].Rect != image.Rect(0, 200, 200, 400) || parts[1].Rect != image.Rect(0, 0, 200, 200) {
+		t.Errorf("Sideways parts = %+v", parts)
+	}
+}
+
+func TestConvert_SplitSpread(t *testing.T) {
+	tmpDir := t.TempDir()
+	jpg := filepath.Join(tmpDir, "spread.jpg")
+	if err := createTestJPG(jpg, 400, 200); err != nil {
+		t.Fatal(err)
+	}
+	portrait := filepath.Join(tmpDir, "cover.png")
+	if err := createTestImage(portrait, 200, 300, "png"); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := DefaultOptions()
+	opts.Split = SplitSpread
+
+	output := filepath.Join(tmpDir, "out.pdf")
+	converter := NewConverterWithOptions(opts)
+	if err := converter.Convert(portrait+","+jpg, output, "seq"); err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+	if err := api.ValidateFile(output, nil); err != nil {
+		t.Fatalf("Invalid PDF: %v", err)
+	}
+
+	pages := converter.Report().Pages
+	if len(pages) != 3 {
+		t.Fatalf("Expected 3 pages, got %d", len(pages))
+	}
+	if pages[0].Part != "" || pages[1].Part != "left" || pages[2].Part != "right" {
+		t.Errorf("Parts = %q, %q, %q", pages[0].Part, pages[1].Part, pages[2].Part)
+	}
+
+	// Обе половины JPEG ссылаются на одно изображение без перекодирования
+	for _, p := range pages[1:] {
+		if p.Encoding != EncodingPassthrough {
+			t.Errorf("%s half: encoding = %q; want %q", p.Part, p.Encoding, EncodingPassthrough)
+		}
+	}
+	if pages[1].Bytes == 0 || pages[2].Bytes != 0 {
+		t.Errorf("Bytes = %d, %d; the right half should reuse the left one's image", pages[1].Bytes, pages[2].Bytes)
+	}
+
+	ctx, err := api.ReadContextFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	boundaries, err := ctx.PageBoundaries(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, pb := range boundaries[1:] {
+		if crop := pb.CropBox(); crop.Width() != 200 || crop.Height() != 200 {
+			t.Errorf("Page %d: visible area %.0fx%.0f; want 200x200", i+2, crop.Width(), crop.Height())
+		}
+	}
+}
+
+func TestConvert_SplitSpreadRTL(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "spread.png")
+	if err := createTestImage(path, 400, 200, "png"); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := DefaultOptions()
+	opts.Split = SplitSpread
+	opts.RTL = true
+
+	output := filepath.Join(tmpDir, "out.pdf")
+	converter := NewConverterWithOptions(opts)
+	if err := converter.Convert(path, output, "seq"); err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+
+	pages := converter.Report().Pages
+	if len(pages) != 2 || pages[0].Part != "right" || pages[1].Part != "left" {
+		t.Fatalf("Expected right, left pages, got %+v", pages)
+	}
+	for _, img := range pdfImages(t, output) {
+		if img.Width != 200 || img.Height != 200 {
+			t.Errorf("Expected 200x200 halves, got %dx%d", img.Width, img.Height)
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pdfcpu/pdfcpu/pkg/font"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// Выравнивание колонтитулов (-stamp-align)
+const (
+	AlignLeft   = "left"
+	AlignCenter = "center"
+	AlignRight  = "right"
+)
+
+var stampAligns = map[string]bool{
+	AlignLeft:   true,
+	AlignCenter: true,
+	AlignRight:  true,
+}
+
+const (
+	// stampFontRes и stampGStateRes — имена ресурсов колонтитула на странице
+	stampFontRes   = "FS"
+	stampGStateRes = "GSS"
+	// stampDateLayout — формат {date} и {exif.date}
+	stampDateLayout = "2006-01-02 15:04"
+)
+
+// stampPlaceholder — подстановка вида {page} в шаблоне колонтитула
+var stampPlaceholder = regexp.MustCompile(`\{([a-z.]+)\}`)
+
+// stampFields — поля, доступные в шаблонах колонтитулов
+var stampFields = map[string]bool{
+	"page":      true,
+	"pages":     true,
+	"file":      true,
+	"date":      true,
+	"exif.date": true,
+}
+
+// stampValues — значения подстановок для одной страницы
+type stampValues struct {
+	page, pages, file, date, exifDate string
+}
+
+// hasStamps сообщает, что страницы нужно подписать колонтитулами
+func (o Options) hasStamps() bool {
+	return o.Header != "" || o.Footer != ""
+}
+
+// validateStamps проверяет шаблоны и оформление колонтитулов
+func (o Options) validateStamps() error {
+	for _, tmpl := range []string{o.Header, o.Footer} {
+		for _, m := range stampPlaceholder.FindAllStringSubmatch(tmpl, -1) {
+			if !stampFields[m[1]] {
+				return fmt.Errorf("%w: unknown placeholder {%s}, expected {page}, {pages}, {file}, {date} or {exif.date}", ErrInvalidInput, m[1])
+			}
+		}
+	}
+	// Symbol и ZapfDingbats не содержат букв WinAnsiEncoding
+	if _, ok := standardFontStyles[o.StampFont]; !ok {
+		return fmt.Errorf("%w: unknown stamp font %q, expected Helvetica, Times-Roman, Courier or their bold and italic variants", ErrInvalidInput, o.StampFont)
+	}
+	if o.StampSize < 1 || o.StampSize > 72 {
+		return fmt.Errorf("%w: stamp font size must be between 1 and 72", ErrInvalidInput)
+	}
+	if !stampAligns[o.StampAlign] {
+		return fmt.Errorf("%w: unknown stamp alignment %q", ErrInvalidInput, o.StampAlign)
+	}
+	if o.StampMargin < 0 {
+		return fmt.Errorf("%w: negative stamp margin", ErrInvalidInput)
+	}
+	if o.StampOpacity <= 0 || o.StampOpacity > 1 {
+		return fmt.Errorf("%w: stamp opacity must be greater than 0 and at most 1", ErrInvalidInput)
+	}
+	_, err := parseColor(o.StampColor)
+	return err
+}
+
+// expand подставляет значения в шаблон колонтитула
+func (v stampValues) expand(tmpl string) string {
+	return stampPlaceholder.ReplaceAllStringFunc(tmpl, func(s string) string {
+		switch s[1 : len(s)-1] {
+		case "page":
+			return v.page
+		case "pages":
+			return v.pages
+		case "file":
+			return v.file
+		case "date":
+			return v.date
+		case "exif.date":
+			return v.exifDate
+		}
+		return s
+	})
+}
+
+// stampPages дописывает колонтитулы на все страницы готового документа. Номер страницы
+// берётся из нумерации -labels, поэтому напечатанный номер совпадает с показанным.
+func (c *Converter) stampPages(w *pdfWriter) error {
+	if !c.opts.hasStamps() {
+		return nil
+	}
+	fontRef, err := w.standardFont(c.opts.StampFont)
+	if err != nil {
+		return err
+	}
+	col, err := parseColor(c.opts.StampColor)
+	if err != nil {
+		return err
+	}
+	var gstate *types.IndirectRef
+	if c.opts.StampOpacity < 1 {
+		ref, err := w.opacity(c.opts.StampOpacity)
+		if err != nil {
+			return err
+		}
+		gstate = &ref
+	}
+
+	// Источник страницы — первое изображение на ней
+	sources := map[int]PageReport{}
+	for _, p := range c.report.Pages {
+		if _, ok := sources[p.Page]; !ok && !p.Blank {
+			sources[p.Page] = p
+		}
+	}
+
+	now := time.Now().Format(stampDateLayout)
+	for n := 1; n <= w.ctx.PageCount; n++ {
+		v := stampValues{
+			page:  c.labels.label(n),
+			pages: strconv.Itoa(w.ctx.PageCount),
+			date:  now,
+		}
+		if src, ok := sources[n]; ok {
+			v.file = filepath.Base(src.Source)
+			v.exifDate = src.taken.Format(stampDateLayout)
+		}
+		if err := c.stampPage(w, n, v, col, fontRef, gstate); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stampPage добавляет странице n отдельный content stream с колонтитулами
+func (c *Converter) stampPage(w *pdfWriter, n int, v stampValues, col color.NRGBA, fontRef types.IndirectRef, gstate *types.IndirectRef) error {
+	d, _, _, err := w.ctx.PageDict(n, false)
+	if err != nil {
+		return err
+	}
+	box, err := visibleBox(d)
+	if err != nil {
+		return err
+	}
+	rotate := 0
+	if r := d.IntEntry("Rotate"); r != nil {
+		rotate = ((*r % 360) + 360) % 360
+	}
+
+	// Колонтитулы размещаются в координатах страницы так, как её показывает просмотрщик
+	width, height := box.W, box.H
+	if rotate == 90 || rotate == 270 {
+		width, height = height, width
+	}
+	var ops strings.Builder
+	ops.WriteString("q\n")
+	ops.WriteString(displayMatrix(box, rotate))
+	if gstate != nil {
+		fmt.Fprintf(&ops, "/%s gs\n", stampGStateRes)
+	}
+	fmt.Fprintf(&ops, "%.4f %.4f %.4f rg\n", float64(col.R)/255, float64(col.G)/255, float64(col.B)/255)
+
+	o := c.opts
+	lines := []struct {
+		tmpl string
+		y    float64
+	}{
+		{o.Header, height - o.StampMargin - font.Ascent(o.StampFont, o.StampSize)},
+		{o.Footer, o.StampMargin + font.Descent(o.StampFont, o.StampSize)},
+	}
+	for _, line := range lines {
+		if line.tmpl == "" {
+			continue
+		}
+		text := fitText(winAnsi(v.expand(line.tmpl)), o.StampFont, o.StampSize, width-2*o.StampMargin)
+		tw := font.TextWidth(text, o.StampFont, o.StampSize)
+		x := (width - tw) / 2
+		switch o.StampAlign {
+		case AlignLeft:
+			x = o.StampMargin
+		case AlignRight:
+			x = width - o.StampMargin - tw
+		}
+		ops.WriteString(textOp(stampFontRes, o.StampSize, x, line.y, text))
+	}
+	ops.WriteString("Q\n")
+
+	if err := w.addContent(d, []byte(ops.String()), false); err != nil {
+		return err
+	}
+	addResource(d, "Font", stampFontRes, fontRef)
+	if gstate != nil {
+		addResource(d, "ExtGState", stampGStateRes, *gstate)
+	}
+	return nil
+}
+
+// visibleBox возвращает видимую область страницы: CropBox, если он задан, иначе MediaBox
+func visibleBox(d types.Dict) (rect, error) {
+	arr := d.ArrayEntry("CropBox")
+	if arr == nil {
+		arr = d.ArrayEntry("MediaBox")
+	}
+	if len(arr) != 4 {
+		return rect{}, fmt.Errorf("page has no MediaBox")
+	}
+	var v [4]float64
+	for i, o := range arr {
+		switch n := o.(type) {
+		case types.Integer:
+			v[i] = float64(n)
+		case types.Float:
+			v[i] = float64(n)
+		default:
+			return rect{}, fmt.Errorf("invalid page box %v", arr)
+		}
+	}
+	return rect{X: v[0], Y: v[1], W: v[2] - v[0], H: v[3] - v[1]}, nil
+}
+
+// displayMatrix переводит координаты повёрнутой на rotate страницы, как её показывает
+// просмотрщик, в координаты области box
+func displayMatrix(box rect, rotate int) string {
+	a, b, c, d, e, f := 1.0, 0.0, 0.0, 1.0, box.X, box.Y
+	switch rotate {
+	case 90:
+		a, b, c, d, e, f = 0, 1, -1, 0, box.X+box.W, box.Y
+	case 180:
+		a, b, c, d, e, f = -1, 0, 0, -1, box.X+box.W, box.Y+box.H
+	case 270:
+		a, b, c, d, e, f = 0, -1, 1, 0, box.X, box.Y+box.H
+	}
+	return fmt.Sprintf("%.4f %.4f %.4f %.4f %.4f %.4f cm\n", a, b, c, d, e, f)
+}
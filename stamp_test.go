@@ -0,0 +1,130 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
+)
+
+func TestStampExpand(t *testing.T) {
+	v := stampValues{page: "iv", pages: "12", file: "scan.jpg", date: "2024-07-01 10:00", exifDate: "2024-06-01 09:30"}
+	got := v.expand("Page {page} of {pages} | {file} {exif.date} ({date}) {unknown}")
+	want := "Page iv of 12 | scan.jpg 2024-06-01 09:30 (2024-07-01 10:00) {unknown}"
+	if got != want {
+		t.Errorf("expand = %q; want %q", got, want)
+	}
+}
+
+func TestTextOp_Escapes(t *testing.T) {
+	got := textOp("F1", 9, 10, 20, `a(b)\c`)
+	want := "BT /F1 9 Tf 10.0000 20.0000 Td (a\\(b\\)\\\\c) Tj ET\n"
+	if got != want {
+		t.Errorf("textOp = %q; want %q", got, want)
+	}
+}
+
+func TestValidateStamps(t *testing.T) {
+	tests := []struct {
+		name string
+		set  func(*Options)
+	}{
+		{"placeholder", func(o *Options) { o.Footer = "Page {num}" }},
+		{"font", func(o *Options) { o.StampFont = "ZapfDingbats" }},
+		{"size", func(o *Options) { o.StampSize = 0 }},
+		{"align", func(o *Options) { o.StampAlign = "justify" }},
+		{"opacity", func(o *Options) { o.StampOpacity = 0 }},
+		{"color", func(o *Options) { o.StampColor = "nope" }},
+	}
+	for _, tt := range tests {
+		opts := DefaultOptions()
+		tt.set(&opts)
+		if err := opts.Validate(); !IsInvalidInput(err) {
+			t.Errorf("%s: expected ErrInvalidInput, got %v", tt.name, err)
+		}
+	}
+}
+
+// pageContent возвращает все content stream страницы n документа path
+func pageContent(t *testing.T, path string, n int) string {
+	t.Helper()
+	ctx, err := api.ReadContextFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, _, _, err := ctx.PageDict(n, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := ctx.PageContent(d, n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+func TestConvert_Stamps(t *testing.T) {
+	tmpDir := t.TempDir()
+	createTree(t, tmpDir, "a.jpg", "b.jpg", "c.jpg")
+
+	// Колонтитул не помещается на страницу размером с маленькое изображение
+	opts := DefaultOptions()
+	opts.PageSize = "A4"
+	opts.Header = "{file}"
+	opts.Footer = "Page {page} of {pages}"
+	opts.Labels = "1:r,2-:D"
+	opts.Rotate = "2=90"
+	opts.StampOpacity = 0.5
+	output := filepath.Join(tmpDir, "out.pdf")
+	if err := NewConverterWithOptions(opts).Convert(tmpDir, output, "nam"); err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+	if err := api.ValidateFile(output, nil); err != nil {
+		t.Fatalf("Output is not a valid PDF: %v", err)
+	}
+
+	// Номер страницы совпадает с нумерацией -labels
+	for n, want := range map[int][]string{
+		1: {"(Page i of 3) Tj", "(a.jpg) Tj", "/GSS gs"},
+		2: {"(Page 1 of 3) Tj", "(b.jpg) Tj", "0.0000 1.0000 -1.0000 0.0000"},
+		3: {"(Page 2 of 3) Tj", "(c.jpg) Tj"},
+	} {
+		content := pageContent(t, output, n)
+		for _, s := range want {
+			if !strings.Contains(content, s) {
+				t.Errorf("Page %d content does not contain %q:\n%s", n, s, content)
+			}
+		}
+	}
+}
+
+func TestConvert_StandardFontsStrict(t *testing.T) {
+	tmpDir := t.TempDir()
+	createTree(t, tmpDir, "a.jpg", "b.jpg", "c.jpg")
+
+	// Подписи сетки, колонтитулы брошюры и текстовый водяной знак выводятся
+	// стандартными шрифтами, словари которых проходят строгую проверку
+	tests := []struct {
+		name string
+		set  func(o *Options)
+	}{
+		{"nup caption", func(o *Options) { o.Nup = "2x1"; o.Caption = "name" }},
+		{"booklet header", func(o *Options) { o.Booklet = true; o.Header = "{page}"; o.StampFont = "Times-Italic" }},
+		{"watermark", func(o *Options) { o.WatermarkText = "DRAFT" }},
+	}
+	conf := model.NewDefaultConfiguration()
+	conf.ValidationMode = model.ValidationStrict
+	for _, tt := range tests {
+		opts := DefaultOptions()
+		tt.set(&opts)
+		output := filepath.Join(tmpDir, strings.ReplaceAll(tt.name, " ", "-")+".pdf")
+		if err := NewConverterWithOptions(opts).Convert(tmpDir, output, "nam"); err != nil {
+			t.Fatalf("%s: Convert failed: %v", tt.name, err)
+		}
+		if err := api.ValidateFile(output, conf); err != nil {
+			t.Errorf("%s: strict validation failed: %v", tt.name, err)
+		}
+	}
+}
@@ -0,0 +1,1209 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pdfcpu/pdfcpu/pkg/font"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+const (
+	svgNamespace   = "http://www.w3.org/2000/svg"
+	xlinkNamespace = "http://www.w3.org/1999/xlink"
+	// svgPointsPerPixel — пиксель CSS равен 1/96 дюйма, то есть 0,75 пункта
+	svgPointsPerPixel = 0.75
+	// svgMaxDepth ограничивает вложенность use, чтобы циклические ссылки не зациклили отрисовку
+	svgMaxDepth = 16
+)
+
+// isSVG сообщает, что файл — векторный рисунок SVG
+func isSVG(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".svg")
+}
+
+// svgNode — элемент SVG. У текста внутри text и tspan пустое имя.
+type svgNode struct {
+	name  string
+	attrs map[string]string
+	text  string
+	kids  []*svgNode
+}
+
+// svgDocument — разобранный рисунок SVG
+type svgDocument struct {
+	root *svgNode
+	ids  map[string]*svgNode
+	// width, height — размер рисунка в пунктах
+	width, height float64
+	// view переводит координаты viewBox в пиксели CSS
+	view svgMatrix
+	// vw, vh — размер области просмотра в пользовательских единицах, база для процентов
+	vw, vh float64
+}
+
+// parseSVG разбирает SVG и определяет размер рисунка. Без width и height размер
+// берётся из viewBox, без viewBox — 300×150, как у браузеров.
+func parseSVG(raw []byte) (*svgDocument, error) {
+	root, sheets, err := parseSVGTree(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &svgDocument{root: root, ids: map[string]*svgNode{}}
+	rules := parseStyleSheet(strings.Join(sheets, "\n"))
+	var walk func(n *svgNode)
+	walk = func(n *svgNode) {
+		if n.name == "" {
+			return
+		}
+		applyStyles(n, rules)
+		if id := n.attrs["id"]; id != "" {
+			if _, dup := doc.ids[id]; !dup {
+				doc.ids[id] = n
+			}
+		}
+		for _, k := range n.kids {
+			walk(k)
+		}
+	}
+	walk(root)
+
+	vb, hasViewBox := parseViewBox(root.attrs["viewBox"])
+	w, hasW := svgLength(root.attrs["width"], 0, 16)
+	h, hasH := svgLength(root.attrs["height"], 0, 16)
+	if hasViewBox {
+		switch {
+		case !hasW && hasH:
+			w, hasW = h*vb[2]/vb[3], true
+		case !hasW:
+			w, hasW = vb[2], true
+		}
+		if !hasH {
+			h, hasH = w*vb[3]/vb[2], true
+		}
+	}
+	if !hasW {
+		w = 300
+	}
+	if !hasH {
+		h = 150
+	}
+	if w <= 0 || h <= 0 {
+		return nil, errors.New("svg drawing has an empty size")
+	}
+	if !hasViewBox {
+		vb = [4]float64{0, 0, w, h}
+	}
+
+	doc.width, doc.height = w*svgPointsPerPixel, h*svgPointsPerPixel
+	doc.view = viewBoxMatrix(vb, w, h, root.attrs["preserveAspectRatio"])
+	doc.vw, doc.vh = vb[2], vb[3]
+	return doc, nil
+}
+
+// parseSVGTree читает дерево элементов SVG и тексты таблиц стилей. Элементы чужих
+// пространств имён, например метаданные редакторов, пропускаются вместе с содержимым.
+func parseSVGTree(raw []byte) (*svgNode, []string, error) {
+	d := xml.NewDecoder(bytes.NewReader(raw))
+	d.Strict = false
+	d.Entity = xml.HTMLEntity
+
+	var root *svgNode
+	var stack []*svgNode
+	var sheets []string
+	foreign := 0
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid svg: %v", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if foreign > 0 || (t.Name.Space != "" && t.Name.Space != svgNamespace) {
+				foreign++
+				continue
+			}
+			n := &svgNode{name: t.Name.Local, attrs: make(map[string]string, len(t.Attr))}
+			for _, a := range t.Attr {
+				if a.Name.Space == "" || a.Name.Space == xlinkNamespace || a.Name.Space == "xlink" {
+					n.attrs[a.Name.Local] = a.Value
+				}
+			}
+			if len(stack) == 0 {
+				if root != nil {
+					return nil, nil, errors.New("invalid svg: several root elements")
+				}
+				root = n
+			} else {
+				parent := stack[len(stack)-1]
+				parent.kids = append(parent.kids, n)
+			}
+			stack = append(stack, n)
+
+		case xml.EndElement:
+			if foreign > 0 {
+				foreign--
+			} else if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+
+		case xml.CharData:
+			if foreign > 0 || len(stack) == 0 {
+				continue
+			}
+			switch top := stack[len(stack)-1]; top.name {
+			case "style":
+				sheets = append(sheets, string(t))
+			case "text", "tspan", "a":
+				top.kids = append(top.kids, &svgNode{text: string(t)})
+			}
+		}
+	}
+	if root == nil || root.name != "svg" {
+		return nil, nil, errors.New("not an svg document")
+	}
+	return root, sheets, nil
+}
+
+// cssRule — правило таблицы стилей с простым селектором: элемент, класс, id или *
+type cssRule struct {
+	tag, id     string
+	classes     []string
+	specificity int
+	decls       [][2]string
+}
+
+// matches сообщает, что правило относится к элементу
+func (r cssRule) matches(n *svgNode) bool {
+	if r.tag != "" && r.tag != "*" && r.tag != n.name {
+		return false
+	}
+	if r.id != "" && r.id != n.attrs["id"] {
+		return false
+	}
+	classes := strings.Fields(n.attrs["class"])
+	for _, c := range r.classes {
+		if !slices.Contains(classes, c) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseStyleSheet разбирает таблицу стилей. Правила с составными селекторами
+// и @-правила пропускаются. Правила упорядочены по специфичности.
+func parseStyleSheet(css string) []cssRule {
+	for {
+		start := strings.Index(css, "/*")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(css[start+2:], "*/")
+		if end < 0 {
+			css = css[:start]
+			break
+		}
+		css = css[:start] + css[start+2+end+2:]
+	}
+
+	var rules []cssRule
+	for _, block := range strings.Split(css, "}") {
+		open := strings.LastIndexByte(block, '{')
+		if open < 0 {
+			continue
+		}
+		selectors, body := block[:open], block[open+1:]
+		if strings.ContainsAny(selectors, "{@") {
+			continue
+		}
+		decls := parseDeclarations(body)
+		for _, sel := range strings.Split(selectors, ",") {
+			sel = strings.TrimSpace(sel)
+			if sel == "" || strings.ContainsAny(sel, " >+~:[") {
+				continue
+			}
+			rule := cssRule{decls: decls}
+			for i := 0; i < len(sel); {
+				j := i + 1
+				for j < len(sel) && sel[j] != '.' && sel[j] != '#' {
+					j++
+				}
+				switch sel[i] {
+				case '.':
+					rule.classes = append(rule.classes, sel[i+1:j])
+					rule.specificity += 10
+				case '#':
+					rule.id = sel[i+1 : j]
+					rule.specificity += 100
+				default:
+					rule.tag = sel[i:j]
+					rule.specificity++
+				}
+				i = j
+			}
+			rules = append(rules, rule)
+		}
+	}
+	slices.SortStableFunc(rules, func(a, b cssRule) int { return a.specificity - b.specificity })
+	return rules
+}
+
+// parseDeclarations разбирает объявления CSS вида "fill: red; stroke: none"
+func parseDeclarations(s string) [][2]string {
+	var decls [][2]string
+	for _, d := range strings.Split(s, ";") {
+		name, value, ok := strings.Cut(d, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(value), "!important"))
+		decls = append(decls, [2]string{strings.ToLower(strings.TrimSpace(name)), value})
+	}
+	return decls
+}
+
+// applyStyles переносит в атрибуты элемента свойства из таблицы стилей и атрибута
+// style. Они сильнее атрибутов оформления, а style сильнее таблицы.
+func applyStyles(n *svgNode, rules []cssRule) {
+	for _, r := range rules {
+		if r.matches(n) {
+			for _, d := range r.decls {
+				n.attrs[d[0]] = d[1]
+			}
+		}
+	}
+	for _, d := range parseDeclarations(n.attrs["style"]) {
+		n.attrs[d[0]] = d[1]
+	}
+}
+
+// parseViewBox разбирает атрибут viewBox
+func parseViewBox(s string) ([4]float64, bool) {
+	v := svgNumberList(s)
+	if len(v) != 4 || v[2] <= 0 || v[3] <= 0 {
+		return [4]float64{}, false
+	}
+	return [4]float64{v[0], v[1], v[2], v[3]}, true
+}
+
+// viewBoxMatrix вписывает viewBox в область w×h по правилу preserveAspectRatio
+func viewBoxMatrix(vb [4]float64, w, h float64, aspect string) svgMatrix {
+	sx, sy := w/vb[2], h/vb[3]
+	fields := strings.Fields(aspect)
+	if len(fields) > 0 && fields[0] == "defer" {
+		fields = fields[1:]
+	}
+	align := "xMidYMid"
+	if len(fields) > 0 {
+		align = fields[0]
+	}
+	if align != "none" {
+		s := math.Min(sx, sy)
+		if len(fields) > 1 && fields[1] == "slice" {
+			s = math.Max(sx, sy)
+		}
+		sx, sy = s, s
+	}
+
+	tx, ty := -vb[0]*sx, -vb[1]*sy
+	switch {
+	case strings.Contains(align, "xMid"):
+		tx += (w - vb[2]*sx) / 2
+	case strings.Contains(align, "xMax"):
+		tx += w - vb[2]*sx
+	}
+	switch {
+	case strings.Contains(align, "YMid"):
+		ty += (h - vb[3]*sy) / 2
+	case strings.Contains(align, "YMax"):
+		ty += h - vb[3]*sy
+	}
+	return svgMatrix{sx, 0, 0, sy, tx, ty}
+}
+
+// svgLength переводит длину с единицами CSS в пиксели. ref — база для процентов,
+// em — размер шрифта.
+func svgLength(s string, ref, em float64) (float64, bool) {
+	s = strings.TrimSpace(s)
+	units := []struct {
+		unit string
+		px   float64
+	}{
+		{"px", 1}, {"pt", 96.0 / 72}, {"pc", 16}, {"mm", 96 / 25.4}, {"cm", 96 / 2.54}, {"in", 96},
+		{"em", em}, {"ex", em / 2}, {"%", ref / 100},
+	}
+	scale := 1.0
+	for _, u := range units {
+		if v, ok := strings.CutSuffix(s, u.unit); ok {
+			if u.unit == "%" && ref == 0 {
+				return 0, false
+			}
+			s, scale = v, u.px
+			break
+		}
+	}
+	v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, false
+	}
+	return v * scale, true
+}
+
+// svgPaint — заливка или обводка: цвет, отсутствие или currentColor
+type svgPaint struct {
+	none    bool
+	current bool
+	c       color.NRGBA
+}
+
+// resolve возвращает цвет с подстановкой currentColor
+func (p svgPaint) resolve(current color.NRGBA) color.NRGBA {
+	if p.current {
+		return current
+	}
+	return p.c
+}
+
+// paint разбирает значение fill или stroke. Градиенты и узоры не поддерживаются:
+// вместо градиента берётся цвет первой опорной точки.
+func (d *svgDocument) paint(v string) (svgPaint, bool) {
+	switch strings.ToLower(v) {
+	case "none":
+		return svgPaint{none: true}, true
+	case "currentcolor":
+		return svgPaint{current: true}, true
+	}
+	if rest, ok := strings.CutPrefix(v, "url("); ok {
+		ref, fallback, _ := strings.Cut(rest, ")")
+		ref = strings.Trim(strings.TrimSpace(ref), `"'`)
+		if c, ok := d.gradientColor(strings.TrimPrefix(ref, "#"), 0); ok {
+			return svgPaint{c: c}, true
+		}
+		if fallback = strings.TrimSpace(fallback); fallback != "" {
+			return d.paint(fallback)
+		}
+		return svgPaint{none: true}, true
+	}
+	c, ok := parseSVGColor(v)
+	return svgPaint{c: c}, ok
+}
+
+// gradientColor возвращает цвет первой опорной точки градиента id. Опорные точки
+// могут быть унаследованы от другого градиента через href.
+func (d *svgDocument) gradientColor(id string, depth int) (color.NRGBA, bool) {
+	g := d.ids[id]
+	if g == nil || depth > svgMaxDepth || (g.name != "linearGradient" && g.name != "radialGradient") {
+		return color.NRGBA{}, false
+	}
+	for _, k := range g.kids {
+		if k.name != "stop" {
+			continue
+		}
+		c := color.NRGBA{A: 0xff}
+		if v, ok := parseSVGColor(k.attrs["stop-color"]); ok {
+			c = v
+		}
+		if v, ok := svgOpacity(k.attrs["stop-opacity"]); ok {
+			c.A = uint8(math.Round(float64(c.A) * v))
+		}
+		return c, true
+	}
+	return d.gradientColor(strings.TrimPrefix(g.attrs["href"], "#"), depth+1)
+}
+
+// svgOpacity разбирает прозрачность: долю от 0 до 1 или проценты
+func svgOpacity(s string) (float64, bool) {
+	s = strings.TrimSpace(s)
+	scale := 1.0
+	if v, ok := strings.CutSuffix(s, "%"); ok {
+		s, scale = v, 0.01
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, false
+	}
+	return math.Max(0, math.Min(v*scale, 1)), true
+}
+
+// svgStyle — вычисленное оформление элемента
+type svgStyle struct {
+	fill, stroke  svgPaint
+	fillOpacity   float64
+	strokeOpacity float64
+	// alpha — произведение opacity элемента и его предков. Прозрачность группы
+	// переносится на каждый элемент, поэтому перекрытия внутри группы просвечивают.
+	alpha       float64
+	strokeWidth float64
+	lineCap     int
+	lineJoin    int
+	miterLimit  float64
+	dash        []float64
+	dashOffset  float64
+	evenOdd     bool
+	color       color.NRGBA
+	fontFamily  string
+	fontSize    float64
+	bold        bool
+	italic      bool
+	anchor      string
+	hidden      bool
+}
+
+func defaultSVGStyle() svgStyle {
+	black := color.NRGBA{A: 0xff}
+	return svgStyle{
+		fill:          svgPaint{c: black},
+		stroke:        svgPaint{none: true},
+		fillOpacity:   1,
+		strokeOpacity: 1,
+		alpha:         1,
+		strokeWidth:   1,
+		miterLimit:    4,
+		color:         black,
+		fontSize:      16,
+	}
+}
+
+// svgRenderer переводит дерево SVG в операторы content stream
+type svgRenderer struct {
+	doc *svgDocument
+	out bytes.Buffer
+	// fonts — имена ресурсов шрифтов по базовому шрифту
+	fonts map[string]string
+	// states — имена ресурсов ExtGState по прозрачности заливки и обводки
+	states map[[2]float64]string
+	// diag — база для процентов у длин без направления, например stroke-width
+	diag float64
+
+	gray, bw bool
+	level    uint8
+	// flatten — подложка, с которой смешиваются полупрозрачные цвета, если
+	// прозрачность в документе запрещена
+	flatten *color.NRGBA
+	// transparent — в рисунке встретились полупрозрачные цвета
+	transparent bool
+	// skipped — неподдерживаемые элементы и свойства для предупреждения
+	skipped map[string]bool
+	depth   int
+}
+
+// newSVGRenderer готовит отрисовку рисунка с учётом цветового режима и политики прозрачности
+func (o Options) newSVGRenderer(doc *svgDocument) (*svgRenderer, error) {
+	r := &svgRenderer{
+		doc:     doc,
+		fonts:   map[string]string{},
+		states:  map[[2]float64]string{},
+		diag:    math.Sqrt((doc.vw*doc.vw + doc.vh*doc.vh) / 2),
+		gray:    o.Color == ColorGray,
+		bw:      o.Color == ColorBW,
+		level:   uint8(o.Threshold),
+		skipped: map[string]bool{},
+	}
+	if r.bw && r.level == 0 {
+		// Порог Оцу считается по пикселям, у векторного рисунка берётся середина
+		r.level = 128
+	}
+	bg, flatten, err := o.alphaBackground()
+	if err != nil {
+		return nil, err
+	}
+	if flatten {
+		r.flatten = &bg
+	}
+	return r, nil
+}
+
+// draw отрисовывает рисунок в пунктах с началом в левом нижнем углу. Ось y
+// в SVG направлена вниз, а всё, что выходит за область рисунка, обрезается.
+func (r *svgRenderer) draw() {
+	d := r.doc
+	r.out.WriteString(svgNums(0, 0, d.width, d.height) + " re W n\n")
+	r.out.WriteString(svgMatrix{1, 0, 0, -1, 0, d.height}.op())
+	r.out.WriteString(svgMatrix{svgPointsPerPixel, 0, 0, svgPointsPerPixel, 0, 0}.mul(d.view).op())
+	if d.root.attrs["display"] != "none" {
+		r.children(d.root, r.style(d.root, defaultSVGStyle()))
+	}
+}
+
+func (r *svgRenderer) children(n *svgNode, st svgStyle) {
+	for _, k := range n.kids {
+		r.node(k, st)
+		if n.name == "switch" && k.name != "" {
+			// switch показывает первый подходящий элемент, здесь — просто первый
+			return
+		}
+	}
+}
+
+func (r *svgRenderer) node(n *svgNode, parent svgStyle) {
+	if n.name == "" || n.attrs["display"] == "none" {
+		return
+	}
+	st := r.style(n, parent)
+	for _, prop := range [][2]string{
+		{"clip-path", "clip-path"}, {"mask", "mask"}, {"filter", "filter"},
+		{"marker-start", "marker"}, {"marker-mid", "marker"}, {"marker-end", "marker"},
+	} {
+		if v := n.attrs[prop[0]]; v != "" && v != "none" {
+			r.skipped[prop[1]] = true
+		}
+	}
+
+	switch n.name {
+	case "g", "a", "switch":
+		r.group(n, svgIdentity, func() { r.children(n, st) })
+	case "svg":
+		r.group(n, r.viewport(n, st), func() { r.children(n, st) })
+	case "use":
+		r.use(n, st)
+	case "path", "rect", "circle", "ellipse", "line", "polyline", "polygon":
+		if p := r.shape(n, st); !p.empty() {
+			r.group(n, svgIdentity, func() { r.paint(p, st, n.name != "line") })
+		}
+	case "text":
+		r.group(n, svgIdentity, func() { r.text(n, st) })
+	case "defs", "symbol", "style", "title", "desc", "metadata", "script",
+		"linearGradient", "radialGradient", "stop", "clipPath", "mask", "marker", "pattern", "filter":
+		// Определения рисуются только по ссылкам
+	default:
+		r.skipped[n.name] = true
+	}
+}
+
+// group выводит содержимое элемента в собственном графическом состоянии
+// с его преобразованием transform и дополнительным extra
+func (r *svgRenderer) group(n *svgNode, extra svgMatrix, body func()) {
+	r.out.WriteString("q\n")
+	if m, ok := parseTransform(n.attrs["transform"]); ok && m != svgIdentity {
+		r.out.WriteString(m.op())
+	}
+	if extra != svgIdentity {
+		r.out.WriteString(extra.op())
+	}
+	body()
+	r.out.WriteString("Q\n")
+}
+
+// viewport возвращает преобразование вложенного svg: сдвиг в x, y и viewBox
+func (r *svgRenderer) viewport(n *svgNode, st svgStyle) svgMatrix {
+	x := r.length(n.attrs["x"], r.doc.vw, st)
+	y := r.length(n.attrs["y"], r.doc.vh, st)
+	m := svgMatrix{1, 0, 0, 1, x, y}
+	if vb, ok := parseViewBox(n.attrs["viewBox"]); ok {
+		w, h := r.doc.vw, r.doc.vh
+		if v, ok := svgLength(n.attrs["width"], r.doc.vw, st.fontSize); ok {
+			w = v
+		}
+		if v, ok := svgLength(n.attrs["height"], r.doc.vh, st.fontSize); ok {
+			h = v
+		}
+		m = m.mul(viewBoxMatrix(vb, w, h, n.attrs["preserveAspectRatio"]))
+	}
+	return m
+}
+
+// use рисует элемент, на который ссылается href, со сдвигом x, y
+func (r *svgRenderer) use(n *svgNode, st svgStyle) {
+	href := n.attrs["href"]
+	target := r.doc.ids[strings.TrimPrefix(href, "#")]
+	if !strings.HasPrefix(href, "#") || target == nil || r.depth >= svgMaxDepth {
+		return
+	}
+	r.depth++
+	defer func() { r.depth-- }()
+
+	x := r.length(n.attrs["x"], r.doc.vw, st)
+	y := r.length(n.attrs["y"], r.doc.vh, st)
+	r.group(n, svgMatrix{1, 0, 0, 1, x, y}, func() {
+		if target.name != "symbol" {
+			r.node(target, st)
+			return
+		}
+		if vb, ok := parseViewBox(target.attrs["viewBox"]); ok {
+			w, h := r.doc.vw, r.doc.vh
+			if v, ok := svgLength(n.attrs["width"], r.doc.vw, st.fontSize); ok {
+				w = v
+			}
+			if v, ok := svgLength(n.attrs["height"], r.doc.vh, st.fontSize); ok {
+				h = v
+			}
+			r.out.WriteString(viewBoxMatrix(vb, w, h, target.attrs["preserveAspectRatio"]).op())
+		}
+		r.children(target, r.style(target, st))
+	})
+}
+
+// length переводит атрибут длины в пользовательские единицы, ошибка даёт 0
+func (r *svgRenderer) length(s string, ref float64, st svgStyle) float64 {
+	v, _ := svgLength(s, ref, st.fontSize)
+	return v
+}
+
+// shape строит контур фигуры
+func (r *svgRenderer) shape(n *svgNode, st svgStyle) *svgPath {
+	a := n.attrs
+	vw, vh := r.doc.vw, r.doc.vh
+	p := &svgPath{}
+	switch n.name {
+	case "path":
+		return parsePathData(a["d"])
+
+	case "rect":
+		x, y := r.length(a["x"], vw, st), r.length(a["y"], vh, st)
+		w, h := r.length(a["width"], vw, st), r.length(a["height"], vh, st)
+		if w <= 0 || h <= 0 {
+			return p
+		}
+		rx, hasRX := svgLength(a["rx"], vw, st.fontSize)
+		ry, hasRY := svgLength(a["ry"], vh, st.fontSize)
+		// Если задан один радиус, второй равен ему
+		if !hasRX {
+			rx = ry
+		}
+		if !hasRY {
+			ry = rx
+		}
+		p.roundRect(x, y, w, h, math.Min(rx, w/2), math.Min(ry, h/2))
+
+	case "circle", "ellipse":
+		cx, cy := r.length(a["cx"], vw, st), r.length(a["cy"], vh, st)
+		rx, ry := r.length(a["rx"], vw, st), r.length(a["ry"], vh, st)
+		if n.name == "circle" {
+			rx = r.length(a["r"], r.diag, st)
+			ry = rx
+		}
+		if rx > 0 && ry > 0 {
+			p.ellipse(cx, cy, rx, ry)
+		}
+
+	case "line":
+		p.moveTo(svgPoint{r.length(a["x1"], vw, st), r.length(a["y1"], vh, st)})
+		p.lineTo(svgPoint{r.length(a["x2"], vw, st), r.length(a["y2"], vh, st)})
+
+	case "polyline", "polygon":
+		p.polyline(svgNumberList(a["points"]), n.name == "polygon")
+	}
+	return p
+}
+
+// style вычисляет оформление элемента поверх унаследованного parent
+func (r *svgRenderer) style(n *svgNode, parent svgStyle) svgStyle {
+	s := parent
+	get := func(name string) (string, bool) {
+		v := strings.TrimSpace(n.attrs[name])
+		return v, v != "" && v != "inherit"
+	}
+	number := func(name string) (float64, bool) {
+		v, ok := get(name)
+		if !ok {
+			return 0, false
+		}
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	}
+
+	// Размер шрифта нужен раньше остальных длин, заданных в em
+	if v, ok := get("font-size"); ok {
+		if size, ok := svgLength(v, parent.fontSize*100, parent.fontSize); ok && size >= 0 {
+			s.fontSize = size
+		}
+	}
+	if v, ok := get("color"); ok {
+		if c, ok := parseSVGColor(v); ok {
+			s.color = c
+		}
+	}
+	if v, ok := get("fill"); ok {
+		if p, ok := r.doc.paint(v); ok {
+			s.fill = p
+		}
+	}
+	if v, ok := get("stroke"); ok {
+		if p, ok := r.doc.paint(v); ok {
+			s.stroke = p
+		}
+	}
+	if v, ok := get("opacity"); ok {
+		if a, ok := svgOpacity(v); ok {
+			s.alpha *= a
+		}
+	}
+	if v, ok := get("fill-opacity"); ok {
+		if a, ok := svgOpacity(v); ok {
+			s.fillOpacity = a
+		}
+	}
+	if v, ok := get("stroke-opacity"); ok {
+		if a, ok := svgOpacity(v); ok {
+			s.strokeOpacity = a
+		}
+	}
+	if v, ok := get("stroke-width"); ok {
+		if w, ok := svgLength(v, r.diag, s.fontSize); ok && w >= 0 {
+			s.strokeWidth = w
+		}
+	}
+	if v, ok := get("stroke-linecap"); ok {
+		s.lineCap = map[string]int{"round": 1, "square": 2}[v]
+	}
+	if v, ok := get("stroke-linejoin"); ok {
+		s.lineJoin = map[string]int{"round": 1, "bevel": 2}[v]
+	}
+	if v, ok := number("stroke-miterlimit"); ok && v >= 1 {
+		s.miterLimit = v
+	}
+	if v, ok := get("stroke-dasharray"); ok {
+		s.dash = r.dashArray(v, s)
+	}
+	if v, ok := get("stroke-dashoffset"); ok {
+		s.dashOffset, _ = svgLength(v, r.diag, s.fontSize)
+	}
+	if v, ok := get("fill-rule"); ok {
+		s.evenOdd = v == "evenodd"
+	}
+	if v, ok := get("font-family"); ok {
+		s.fontFamily = v
+	}
+	if v, ok := get("font-weight"); ok {
+		w, err := strconv.Atoi(v)
+		s.bold = v == "bold" || v == "bolder" || (err == nil && w >= 600)
+	}
+	if v, ok := get("font-style"); ok {
+		s.italic = v == "italic" || v == "oblique"
+	}
+	if v, ok := get("text-anchor"); ok {
+		s.anchor = v
+	}
+	if v, ok := get("visibility"); ok {
+		s.hidden = v == "hidden" || v == "collapse"
+	}
+	return s
+}
+
+// dashArray разбирает stroke-dasharray. Список нечётной длины повторяется дважды,
+// отрицательные и нулевые штрихи отключают пунктир.
+func (r *svgRenderer) dashArray(v string, st svgStyle) []float64 {
+	if v == "none" {
+		return nil
+	}
+	var dash []float64
+	sum := 0.0
+	for _, f := range strings.FieldsFunc(v, func(c rune) bool { return c == ',' || c == ' ' }) {
+		d, ok := svgLength(f, r.diag, st.fontSize)
+		if !ok || d < 0 {
+			return nil
+		}
+		dash = append(dash, d)
+		sum += d
+	}
+	if sum == 0 {
+		return nil
+	}
+	if len(dash)%2 != 0 {
+		dash = append(dash, dash...)
+	}
+	return dash
+}
+
+// colorOp возвращает оператор цвета заливки или обводки и оставшуюся прозрачность.
+// Если прозрачность запрещена, цвет заранее смешивается с подложкой.
+func (r *svgRenderer) colorOp(c color.NRGBA, alpha float64, stroke bool) (string, float64) {
+	rgb := [3]float64{float64(c.R) / 255, float64(c.G) / 255, float64(c.B) / 255}
+	if alpha < 1 {
+		r.transparent = true
+		if r.flatten != nil {
+			bg := [3]uint8{r.flatten.R, r.flatten.G, r.flatten.B}
+			for i := range rgb {
+				rgb[i] = rgb[i]*alpha + float64(bg[i])/255*(1-alpha)
+			}
+			alpha = 1
+		}
+	}
+
+	op := "rg"
+	if stroke {
+		op = "RG"
+	}
+	if r.gray || r.bw {
+		op = "g"
+		if stroke {
+			op = "G"
+		}
+		// Те же веса, что у color.GrayModel
+		l := 0.299*rgb[0] + 0.587*rgb[1] + 0.114*rgb[2]
+		if r.bw {
+			l = 0
+			if 0.299*rgb[0]+0.587*rgb[1]+0.114*rgb[2] >= float64(r.level)/255 {
+				l = 1
+			}
+		}
+		return svgNum(l) + " " + op + "\n", alpha
+	}
+	return svgNums(rgb[:]...) + " " + op + "\n", alpha
+}
+
+// alphaOp возвращает оператор gs для прозрачности заливки и обводки
+func (r *svgRenderer) alphaOp(fill, stroke float64) string {
+	key := [2]float64{math.Round(fill*1000) / 1000, math.Round(stroke*1000) / 1000}
+	if key == [2]float64{1, 1} {
+		return ""
+	}
+	name, ok := r.states[key]
+	if !ok {
+		name = fmt.Sprintf("GS%d", len(r.states)+1)
+		r.states[key] = name
+	}
+	return "/" + name + " gs\n"
+}
+
+// paintOps возвращает операторы цвета, прозрачности и параметров линии для заливки
+// и обводки. Пустая строка означает, что рисовать нечего.
+func (r *svgRenderer) paintOps(st svgStyle, fill, stroke bool) string {
+	var b strings.Builder
+	fa, sa := 1.0, 1.0
+	if fill {
+		c := st.fill.resolve(st.color)
+		op, a := r.colorOp(c, st.alpha*st.fillOpacity*float64(c.A)/255, false)
+		b.WriteString(op)
+		fa = a
+	}
+	if stroke {
+		c := st.stroke.resolve(st.color)
+		op, a := r.colorOp(c, st.alpha*st.strokeOpacity*float64(c.A)/255, true)
+		b.WriteString(op)
+		sa = a
+		b.WriteString(fmt.Sprintf("%s w %d J %d j %s M\n", svgNum(st.strokeWidth), st.lineCap, st.lineJoin, svgNum(st.miterLimit)))
+		if len(st.dash) > 0 {
+			b.WriteString("[" + svgNums(st.dash...) + "] " + svgNum(st.dashOffset) + " d\n")
+		}
+	}
+	b.WriteString(r.alphaOp(fa, sa))
+	return b.String()
+}
+
+// paint заливает и обводит контур. У line заливки нет.
+func (r *svgRenderer) paint(p *svgPath, st svgStyle, fillable bool) {
+	fill := fillable && !st.fill.none
+	stroke := !st.stroke.none && st.strokeWidth > 0
+	if st.hidden || (!fill && !stroke) {
+		return
+	}
+	r.out.WriteString(r.paintOps(st, fill, stroke))
+	r.out.WriteString(p.b.String())
+
+	op := "S"
+	switch {
+	case fill && stroke:
+		op = "B"
+	case fill:
+		op = "f"
+	}
+	if fill && st.evenOdd {
+		op += "*"
+	}
+	r.out.WriteString(op + "\n")
+}
+
+// svgTextRun — отрезок текста с оформлением. Отрезок с абсолютной координатой
+// начинает новый блок, который выравнивается по text-anchor.
+type svgTextRun struct {
+	text       string
+	st         svgStyle
+	x, y       float64
+	absX, absY bool
+	dx, dy     float64
+}
+
+// textRuns раскладывает text и вложенные tspan на отрезки. Позиция элемента
+// записывается отдельным пустым отрезком перед его текстом.
+func (r *svgRenderer) textRuns(n *svgNode, st svgStyle, runs []svgTextRun) []svgTextRun {
+	pos := svgTextRun{st: st}
+	if l, ok := svgLength(firstField(n.attrs["x"]), r.doc.vw, st.fontSize); ok {
+		pos.x, pos.absX = l, true
+	}
+	if l, ok := svgLength(firstField(n.attrs["y"]), r.doc.vh, st.fontSize); ok {
+		pos.y, pos.absY = l, true
+	}
+	pos.dx, _ = svgLength(firstField(n.attrs["dx"]), r.doc.vw, st.fontSize)
+	pos.dy, _ = svgLength(firstField(n.attrs["dy"]), r.doc.vh, st.fontSize)
+	if pos.absX || pos.absY || pos.dx != 0 || pos.dy != 0 {
+		runs = append(runs, pos)
+	}
+
+	for _, k := range n.kids {
+		switch {
+		case k.name == "":
+			runs = append(runs, svgTextRun{text: k.text, st: st})
+		case k.name == "tspan" || k.name == "a":
+			if k.attrs["display"] != "none" {
+				runs = r.textRuns(k, r.style(k, st), runs)
+			}
+		}
+	}
+	return runs
+}
+
+// firstField возвращает первое значение списка координат
+func firstField(s string) string {
+	f := strings.FieldsFunc(s, func(c rune) bool { return c == ',' || c == ' ' })
+	if len(f) == 0 {
+		return ""
+	}
+	return f[0]
+}
+
+// text выводит текст стандартным шрифтом PDF, подобранным по font-family
+func (r *svgRenderer) text(n *svgNode, st svgStyle) {
+	runs := r.textRuns(n, st, nil)
+
+	// Пробелы и переводы строк схлопываются, как в браузерах при xml:space="default"
+	space := true
+	last := -1
+	for i := range runs {
+		var b strings.Builder
+		for _, c := range runs[i].text {
+			if strings.ContainsRune(" \t\r\n", c) {
+				if space {
+					continue
+				}
+				c, space = ' ', true
+			} else {
+				space = false
+			}
+			b.WriteRune(c)
+		}
+		runs[i].text = winAnsi(b.String())
+		if b.Len() > 0 {
+			last = i
+		}
+	}
+	if last >= 0 {
+		runs[last].text = strings.TrimRight(runs[last].text, " ")
+	}
+
+	var x, y float64
+	for start := 0; start < len(runs); {
+		end := start + 1
+		for end < len(runs) && !runs[end].absX && !runs[end].absY {
+			end++
+		}
+		chunk := runs[start:end]
+		start = end
+
+		if chunk[0].absX {
+			x = chunk[0].x
+		}
+		if chunk[0].absY {
+			y = chunk[0].y
+		}
+		width := 0.0
+		for _, run := range chunk {
+			width += run.dx + r.textWidth(run)
+		}
+		switch chunk[0].st.anchor {
+		case "middle":
+			x -= width / 2
+		case "end":
+			x -= width
+		}
+		for _, run := range chunk {
+			x += run.dx
+			y += run.dy
+			r.textRun(run, x, y)
+			x += r.textWidth(run)
+		}
+	}
+}
+
+// textWidth возвращает ширину отрезка в пользовательских единицах
+func (r *svgRenderer) textWidth(run svgTextRun) float64 {
+	if run.text == "" {
+		return 0
+	}
+	return font.TextWidth(run.text, svgFont(run.st), 1000) / 1000 * run.st.fontSize
+}
+
+// textRun выводит отрезок с базовой линией в x, y. Матрица текста переворачивает
+// буквы обратно, потому что ось y рисунка направлена вниз.
+func (r *svgRenderer) textRun(run svgTextRun, x, y float64) {
+	st := run.st
+	fill := !st.fill.none
+	stroke := !st.stroke.none && st.strokeWidth > 0
+	if strings.TrimSpace(run.text) == "" || st.hidden || st.fontSize <= 0 || (!fill && !stroke) {
+		return
+	}
+	base := svgFont(st)
+	name, ok := r.fonts[base]
+	if !ok {
+		name = fmt.Sprintf("F%d", len(r.fonts)+1)
+		r.fonts[base] = name
+	}
+
+	mode := 0
+	switch {
+	case fill && stroke:
+		mode = 2
+	case stroke:
+		mode = 1
+	}
+	r.out.WriteString("q\n" + r.paintOps(st, fill, stroke))
+	r.out.WriteString(fmt.Sprintf("BT /%s %s Tf %d Tr 1 0 0 -1 %s Tm (%s) Tj ET\nQ\n",
+		name, svgNum(st.fontSize), mode, svgNums(x, y), escapeText(run.text)))
+}
+
+// svgFontVariants — стандартные шрифты PDF: обычный, жирный, курсив, жирный курсив
+var svgFontVariants = map[string][4]string{
+	"Helvetica": {"Helvetica", "Helvetica-Bold", "Helvetica-Oblique", "Helvetica-BoldOblique"},
+	"Times":     {"Times-Roman", "Times-Bold", "Times-Italic", "Times-BoldItalic"},
+	"Courier":   {"Courier", "Courier-Bold", "Courier-Oblique", "Courier-BoldOblique"},
+}
+
+// svgFont подбирает стандартный шрифт PDF: шрифты из SVG не встраиваются, поэтому
+// моноширинные семейства заменяются на Courier, с засечками — на Times,
+// остальные — на Helvetica
+func svgFont(st svgStyle) string {
+	family := "Helvetica"
+	for _, f := range strings.Split(st.fontFamily, ",") {
+		f = strings.ToLower(strings.Trim(strings.TrimSpace(f), `"'`))
+		switch {
+		case strings.Contains(f, "mono") || strings.Contains(f, "courier") || f == "consolas" || f == "menlo":
+			family = "Courier"
+		case strings.Contains(f, "sans") || strings.Contains(f, "helvetica") || f == "arial" || f == "verdana" || f == "tahoma":
+			family = "Helvetica"
+		case strings.Contains(f, "serif") || strings.Contains(f, "times") || f == "georgia" || f == "garamond" || f == "cambria":
+			family = "Times"
+		default:
+			continue
+		}
+		break
+	}
+	variant := 0
+	if st.bold {
+		variant |= 1
+	}
+	if st.italic {
+		variant |= 2
+	}
+	return svgFontVariants[family][variant]
+}
+
+// vectorBounds заменяет пиксели для страницы SVG: раскладке нужен только размер
+// рисунка, один пиксель соответствует пункту
+type vectorBounds image.Rectangle
+
+func (b vectorBounds) ColorModel() color.Model { return color.NRGBAModel }
+func (b vectorBounds) Bounds() image.Rectangle { return image.Rectangle(b) }
+func (b vectorBounds) At(x, y int) color.Color { return color.Transparent }
+
+// vectorParts готовит страницу из SVG. Пикселей у рисунка нет, поэтому выравнивание
+// наклона, обрезка и деление разворота к нему не применяются, а поворот возможен
+// только на угол, кратный 90°.
+func (c *Converter) vectorParts(info ImageInfo, index int) ([]preparedPart, error) {
+	raw, err := os.ReadFile(info.Path)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := parseSVG(raw)
+	if err != nil {
+		return nil, &ImageError{Path: info.source(), Reason: err.Error()}
+	}
+
+	orient, angle, auto, err := pageOrientation(orientation{}, ruleFor(c.rules, info.Path, index))
+	if err != nil {
+		return nil, &ImageError{Path: info.source(), Reason: err.Error()}
+	}
+	if angle != 0 {
+		return nil, &ImageError{Path: info.source(), Reason: "svg drawings can only be rotated by multiples of 90 degrees"}
+	}
+
+	bounds := image.Rect(0, 0, max(int(math.Round(doc.width)), 1), max(int(math.Round(doc.height)), 1))
+	ps := &pageSource{
+		info:   info,
+		index:  index,
+		src:    &sourceImage{Format: "svg", Raw: raw},
+		base:   vectorBounds(bounds),
+		orient: orient,
+		auto:   auto,
+		taken:  info.ModTime,
+		vector: doc,
+	}
+	c.noteTaken(ps.taken)
+	return []preparedPart{{pageSource: ps, part: spreadPart{Rect: bounds}, crop: bounds, orient: orient}}, nil
+}
+
+// renderVector добавляет рисунок SVG в документ как Form XObject
+func (c *Converter) renderVector(w *pdfWriter, pp *preparedPart) (*renderedImage, error) {
+	r, err := c.opts.newSVGRenderer(pp.vector)
+	if err != nil {
+		return nil, err
+	}
+	r.draw()
+	if len(r.fonts) > 0 && c.opts.PDFA != "" {
+		return nil, &ImageError{Path: pp.info.source(), Reason: "svg text uses standard fonts, which PDF/A forbids as they are not embedded; convert the text to paths"}
+	}
+	if len(r.skipped) > 0 {
+		skipped := make([]string, 0, len(r.skipped))
+		for name := range r.skipped {
+			skipped = append(skipped, name)
+		}
+		slices.Sort(skipped)
+		fmt.Printf("Warning: %s: unsupported SVG features ignored: %s\n", pp.info.source(), strings.Join(skipped, ", "))
+	}
+
+	resources := types.NewDict()
+	if len(r.fonts) > 0 {
+		fonts := types.NewDict()
+		for base, name := range r.fonts {
+			ref, err := w.standardFont(base)
+			if err != nil {
+				return nil, err
+			}
+			fonts.Insert(name, ref)
+		}
+		resources.Insert("Font", fonts)
+	}
+	if len(r.states) > 0 {
+		states := types.NewDict()
+		for key, name := range r.states {
+			states.Insert(name, types.Dict(map[string]types.Object{
+				"Type": types.Name("ExtGState"),
+				"ca":   types.Float(key[0]),
+				"CA":   types.Float(key[1]),
+			}))
+		}
+		resources.Insert("ExtGState", states)
+	}
+
+	ref, err := w.addForm(r.out.Bytes(), pp.vector.width, pp.vector.height, resources)
+	if err != nil {
+		return nil, &ImageError{Path: pp.info.source(), Reason: err.Error()}
+	}
+
+	alpha := ""
+	if r.transparent && r.flatten != nil {
+		alpha = "flattened onto " + hexColor(*r.flatten)
+	}
+	b := pp.base.Bounds()
+	return &renderedImage{
+		ref:      ref,
+		encoded:  &pdfImage{Width: b.Dx(), Height: b.Dy()},
+		decision: encodingDecision{Encoding: EncodingVector, Reason: "svg drawing"},
+		alpha:    alpha,
+		size:     w.imageSize(ref),
+	}, nil
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"image/color"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// testDrawing — схема с фигурами, контуром, группой с преобразованием и текстом
+const testDrawing = `<?xml version="1.0" encoding="UTF-8"?>
+<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink"
+     xmlns:inkscape="http://www.inkscape.org/namespaces/inkscape" width="400" height="200" viewBox="0 0 200 100">
+  <style>.box { fill: #3366cc; stroke: black } #label { font-weight: bold }</style>
+  <inkscape:grid spacing="10"/>
+  <defs>
+    <linearGradient id="sky"><stop offset="0" stop-color="skyblue"/><stop offset="1" stop-color="white"/></linearGradient>
+    <circle id="dot" r="3" fill="red"/>
+  </defs>
+  <rect class="box" x="10" y="10" width="60" height="30" rx="5" stroke-width="2"/>
+  <g transform="translate(100 20) rotate(45)" opacity="0.5">
+    <path d="M0 0 l20 0 q10 10 0 20 a10 10 0 0 1 -20 0 z" fill="url(#sky)" stroke-dasharray="4 2" stroke="green"/>
+  </g>
+  <polyline points="10,90 40,60 70,90" fill="none" stroke="rgb(255, 0, 0)"/>
+  <use xlink:href="#dot" x="150" y="80"/>
+  <text id="label" x="100" y="60" font-family="'Times New Roman', serif" font-size="12" text-anchor="middle">Scheme <tspan fill="red">A</tspan></text>
+  <image href="photo.png" width="10" height="10"/>
+</svg>`
+
+// svgForm возвращает content stream и ресурсы формы Im0 на странице n
+func svgForm(t *testing.T, path string, n int) (string, types.Dict) {
+	t.Helper()
+	ctx, err := api.ReadContextFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, _, _, err := ctx.PageDict(n, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref := d.DictEntry("Resources").DictEntry("XObject").IndirectRefEntry("Im0")
+	if ref == nil {
+		t.Fatalf("Page %d has no XObject Im0", n)
+	}
+	sd, _, err := ctx.DereferenceStreamDict(*ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sub := sd.Subtype(); sub == nil || *sub != "Form" {
+		t.Fatalf("Page %d: Im0 subtype %v; want Form", n, sub)
+	}
+	if err := sd.Decode(); err != nil {
+		t.Fatal(err)
+	}
+	resources, err := ctx.DereferenceDict(sd.Dict["Resources"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(sd.Content), resources
+}
+
+func TestParsePathData(t *testing.T) {
+	tests := []struct {
+		d, want string
+	}{
+		{"M10 20L30 40", "10 20 m\n30 40 l\n"},
+		// После moveto пары координат продолжают контур как lineto
+		{"m10 10 20 0 0 20z", "10 10 m\n30 10 l\n30 30 l\nh\n"},
+		{"M0 0H10V5h-5v-5", "0 0 m\n10 0 l\n10 5 l\n5 5 l\n5 0 l\n"},
+		{"M1-2.5.5 1", "1 -2.5 m\n0.5 1 l\n"},
+		{"M0 0Q3 3 6 0T12 0", "0 0 m\n2 2 4 2 6 0 c\n8 -2 10 -2 12 0 c\n"},
+		{"M0 0C0 1 1 2 2 2S4 3 4 4", "0 0 m\n0 1 1 2 2 2 c\n3 2 4 3 4 4 c\n"},
+		// Ошибка обрывает контур на последней целой команде
+		{"M0 0L5 5L6", "0 0 m\n5 5 l\n"},
+		{"L5 5", ""},
+	}
+	for _, tt := range tests {
+		if got := parsePathData(tt.d).b.String(); got != tt.want {
+			t.Errorf("parsePathData(%q) = %q; want %q", tt.d, got, tt.want)
+		}
+	}
+
+	// Полуокружность с флагами без разделителей — две кривые, конец точно в 10 0
+	arc := parsePathData("M0 0A5 5 0 0110 0").b.String()
+	if strings.Count(arc, " c\n") != 2 || !strings.HasSuffix(arc, " 10 0 c\n") || !strings.Contains(arc, " 5 -5 c") {
+		t.Errorf("Arc = %q", arc)
+	}
+}
+
+func TestParseTransform(t *testing.T) {
+	apply := func(m svgMatrix, x, y float64) svgPoint {
+		return svgPoint{m[0]*x + m[2]*y + m[4], m[1]*x + m[3]*y + m[5]}
+	}
+	tests := []struct {
+		s          string
+		x, y       float64
+		want       svgPoint
+		wantFailed bool
+	}{
+		{"translate(10 20) scale(2)", 1, 1, svgPoint{12, 22}, false},
+		{"scale(2),translate(10,20)", 1, 1, svgPoint{22, 42}, false},
+		{"rotate(90 10 10)", 10, 0, svgPoint{20, 10}, false},
+		{"skewX(45)", 0, 10, svgPoint{10, 10}, false},
+		{"matrix(1 0 0 -1 0 100)", 5, 10, svgPoint{5, 90}, false},
+		{"spin(3)", 0, 0, svgPoint{}, true},
+	}
+	for _, tt := range tests {
+		m, ok := parseTransform(tt.s)
+		if ok == tt.wantFailed {
+			t.Errorf("parseTransform(%q): ok = %v", tt.s, ok)
+			continue
+		}
+		if got := apply(m, tt.x, tt.y); !tt.wantFailed && (math.Abs(got.X-tt.want.X) > 1e-9 || math.Abs(got.Y-tt.want.Y) > 1e-9) {
+			t.Errorf("parseTransform(%q) maps (%v, %v) to %v; want %v", tt.s, tt.x, tt.y, got, tt.want)
+		}
+	}
+}
+
+func TestParseSVGColor(t *testing.T) {
+	tests := []struct {
+		s    string
+		want color.NRGBA
+		ok   bool
+	}{
+		{"#f80", color.NRGBA{0xff, 0x88, 0x00, 0xff}, true},
+		{"#336699", color.NRGBA{0x33, 0x66, 0x99, 0xff}, true},
+		{"#33669980", color.NRGBA{0x33, 0x66, 0x99, 0x80}, true},
+		{"rgb(255, 0, 128)", color.NRGBA{255, 0, 128, 255}, true},
+		{"rgba(100%,0%,0%,0.5)", color.NRGBA{255, 0, 0, 128}, true},
+		{"SteelBlue", color.NRGBA{0x46, 0x82, 0xb4, 0xff}, true},
+		{"transparent", color.NRGBA{}, true},
+		{"#12", color.NRGBA{}, false},
+		{"bluish", color.NRGBA{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseSVGColor(tt.s)
+		if ok != tt.ok || (ok && got != tt.want) {
+			t.Errorf("parseSVGColor(%q) = %v, %v; want %v, %v", tt.s, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestParseSVG_Size(t *testing.T) {
+	tests := []struct {
+		attrs  string
+		w, h   float64
+		errors bool
+	}{
+		{`width="2in" height="1in"`, 144, 72, false},
+		{`viewBox="0 0 200 100"`, 150, 75, false},
+		{`height="50" viewBox="0 0 200 100"`, 75, 37.5, false},
+		{`width="100%" height="100%" viewBox="0 0 40 20"`, 30, 15, false},
+		{``, 225, 112.5, false},
+		{`width="0" height="10"`, 0, 0, true},
+	}
+	for _, tt := range tests {
+		doc, err := parseSVG([]byte(`<svg xmlns="http://www.w3.org/2000/svg" ` + tt.attrs + `/>`))
+		if tt.errors {
+			if err == nil {
+				t.Errorf("%s: expected an error", tt.attrs)
+			}
+			continue
+		}
+		if err != nil || doc.width != tt.w || doc.height != tt.h {
+			t.Errorf("%s: size %v×%v, %v; want %v×%v", tt.attrs, doc.width, doc.height, err, tt.w, tt.h)
+		}
+	}
+	if _, err := parseSVG([]byte(`<html><body/></html>`)); err == nil {
+		t.Error("Expected an error for a non-SVG document")
+	}
+}
+
+func TestConvert_SVG(t *testing.T) {
+	tmpDir := t.TempDir()
+	svgPath := filepath.Join(tmpDir, "1.svg")
+	if err := os.WriteFile(svgPath, []byte(testDrawing), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := createTestImage(filepath.Join(tmpDir, "2.png"), 40, 30, "png"); err != nil {
+		t.Fatal(err)
+	}
+
+	output := filepath.Join(tmpDir, "out.pdf")
+	c := NewConverterWithOptions(DefaultOptions())
+	if err := c.Convert(tmpDir, output, "nam"); err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+	if err := api.ValidateFile(output, nil); err != nil {
+		t.Fatalf("Invalid PDF: %v", err)
+	}
+	pages := c.Report().Pages
+	if len(pages) != 2 {
+		t.Fatalf("Pages = %d; want 2", len(pages))
+	}
+	if p := pages[0]; p.Encoding != EncodingVector || p.Width != 300 || p.Height != 150 || p.Resampled {
+		t.Errorf("SVG page report: %+v", p)
+	}
+	if pages[1].Encoding == EncodingVector {
+		t.Errorf("PNG page encoded as %s", pages[1].Encoding)
+	}
+
+	// Без -page-size страница повторяет размер рисунка: 400×200 px — 300×150 pt
+	_, dims := pageRotations(t, output)
+	if dims[0].Width != 300 || dims[0].Height != 150 {
+		t.Errorf("SVG page size = %v; want 300×150", dims[0])
+	}
+
+	content, resources := svgForm(t, output, 1)
+	for _, op := range []string{
+		"0.2 0.4 0.8 rg",          // заливка из таблицы стилей
+		" re W n",                 // обрезка по области рисунка
+		" c\n",                    // скругления, кривые и дуги
+		"[4 2] 0 d",               // пунктир
+		"/GS1 gs",                 // прозрачность группы
+		"0.5294 0.8078 0.9216 rg", // первая опорная точка градиента
+		"150 80 cm",               // сдвиг use
+		"(Scheme ) Tj",
+		"(A) Tj",
+	} {
+		if !strings.Contains(content, op) {
+			t.Errorf("Form content has no %q:\n%s", op, content)
+		}
+	}
+	fonts := resources.DictEntry("Font")
+	if fonts == nil || len(fonts) != 1 {
+		t.Fatalf("Form fonts = %v", fonts)
+	}
+	ctx, err := api.ReadContextFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ref := range fonts {
+		f, err := ctx.DereferenceDict(ref)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Times New Roman жирным заменяется на стандартный Times-Bold
+		if base := f.NameEntry("BaseFont"); base == nil || *base != "Times-Bold" {
+			t.Errorf("Form font = %v; want Times-Bold", base)
+		}
+	}
+
+	// Рисунок вписывается в страницу так же, как изображение, и красится в сером режиме
+	opts := DefaultOptions()
+	opts.PageSize = "A4"
+	opts.Rotate = "1=90"
+	opts.Color = ColorGray
+	c = NewConverterWithOptions(opts)
+	if err := c.Convert(svgPath, output, "seq"); err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+	rotations, dims := pageRotations(t, output)
+	if rotations[0] != 90 || dims[0].Width != 595 || dims[0].Height != 842 {
+		t.Errorf("A4 page: rotation %d, size %v", rotations[0], dims[0])
+	}
+	content, _ = svgForm(t, output, 1)
+	if strings.Contains(content, " rg\n") || !strings.Contains(content, " g\n") {
+		t.Errorf("Gray mode left RGB colors:\n%s", content)
+	}
+	if strings.Contains(content, " gs\n") {
+		t.Errorf("Gray mode kept transparency instead of flattening:\n%s", content)
+	}
+	if page := pageContent(t, output, 1); !strings.Contains(page, "/Im0 Do") {
+		t.Errorf("Page does not draw the form: %q", page)
+	}
+
+	opts = DefaultOptions()
+	opts.Rotate = "1=10"
+	if err := NewConverterWithOptions(opts).Convert(svgPath, output, "seq"); err == nil {
+		t.Error("Expected an error for an arbitrary rotation of an SVG page")
+	}
+}
@@ -0,0 +1,473 @@
+package main
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// svgKappa — доля радиуса для контрольных точек кривой Безье, приближающей
+// четверть окружности
+const svgKappa = 0.5522847498
+
+// svgPoint — точка в пользовательских координатах SVG
+type svgPoint struct{ X, Y float64 }
+
+// svgMatrix — аффинное преобразование [a b c d e f], как у оператора cm
+type svgMatrix [6]float64
+
+var svgIdentity = svgMatrix{1, 0, 0, 1, 0, 0}
+
+// mul возвращает преобразование, которое сначала применяет n, а затем m
+func (m svgMatrix) mul(n svgMatrix) svgMatrix {
+	return svgMatrix{
+		m[0]*n[0] + m[2]*n[1],
+		m[1]*n[0] + m[3]*n[1],
+		m[0]*n[2] + m[2]*n[3],
+		m[1]*n[2] + m[3]*n[3],
+		m[0]*n[4] + m[2]*n[5] + m[4],
+		m[1]*n[4] + m[3]*n[5] + m[5],
+	}
+}
+
+// op возвращает оператор cm для преобразования
+func (m svgMatrix) op() string {
+	return svgNums(m[:]...) + " cm\n"
+}
+
+// svgNum записывает число для content stream без лишних нулей
+func svgNum(v float64) string {
+	v = math.Round(v*1e4) / 1e4
+	if v == 0 {
+		return "0"
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// svgNums записывает числа через пробел
+func svgNums(vs ...float64) string {
+	parts := make([]string, len(vs))
+	for i, v := range vs {
+		parts[i] = svgNum(v)
+	}
+	return strings.Join(parts, " ")
+}
+
+// svgScanner читает числа из атрибутов SVG, где разделителями служат пробелы и запятые,
+// а иногда и сам знак или точка следующего числа: "1-2.5.5"
+type svgScanner struct {
+	s string
+	i int
+}
+
+func (sc *svgScanner) skipSeparators() {
+	for sc.i < len(sc.s) && strings.IndexByte(" \t\r\n,", sc.s[sc.i]) >= 0 {
+		sc.i++
+	}
+}
+
+func (sc *svgScanner) done() bool {
+	sc.skipSeparators()
+	return sc.i >= len(sc.s)
+}
+
+// command возвращает букву команды, если она идёт следующей
+func (sc *svgScanner) command() (byte, bool) {
+	if sc.done() {
+		return 0, false
+	}
+	c := sc.s[sc.i]
+	if c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' {
+		sc.i++
+		return c, true
+	}
+	return 0, false
+}
+
+func (sc *svgScanner) number() (float64, bool) {
+	sc.skipSeparators()
+	start, i := sc.i, sc.i
+	digits := func() int {
+		from := i
+		for i < len(sc.s) && sc.s[i] >= '0' && sc.s[i] <= '9' {
+			i++
+		}
+		return i - from
+	}
+	if i < len(sc.s) && (sc.s[i] == '+' || sc.s[i] == '-') {
+		i++
+	}
+	n := digits()
+	if i < len(sc.s) && sc.s[i] == '.' {
+		i++
+		n += digits()
+	}
+	if n == 0 {
+		return 0, false
+	}
+	if i < len(sc.s) && (sc.s[i] == 'e' || sc.s[i] == 'E') {
+		mark := i
+		i++
+		if i < len(sc.s) && (sc.s[i] == '+' || sc.s[i] == '-') {
+			i++
+		}
+		if digits() == 0 {
+			i = mark
+		}
+	}
+	v, err := strconv.ParseFloat(sc.s[start:i], 64)
+	if err != nil {
+		return 0, false
+	}
+	sc.i = i
+	return v, true
+}
+
+// flag читает флаг дуги, который может стоять вплотную к следующему числу
+func (sc *svgScanner) flag() (bool, bool) {
+	sc.skipSeparators()
+	if sc.i < len(sc.s) && (sc.s[sc.i] == '0' || sc.s[sc.i] == '1') {
+		sc.i++
+		return sc.s[sc.i-1] == '1', true
+	}
+	return false, false
+}
+
+// numbers читает n чисел подряд
+func (sc *svgScanner) numbers(n int) ([]float64, bool) {
+	vs := make([]float64, n)
+	for i := range vs {
+		v, ok := sc.number()
+		if !ok {
+			return nil, false
+		}
+		vs[i] = v
+	}
+	return vs, true
+}
+
+// svgNumberList разбирает список чисел, например points или stroke-dasharray
+func svgNumberList(s string) []float64 {
+	sc := &svgScanner{s: s}
+	var vs []float64
+	for {
+		v, ok := sc.number()
+		if !ok {
+			return vs
+		}
+		vs = append(vs, v)
+	}
+}
+
+// parseTransform разбирает атрибут transform. Функции применяются справа налево.
+func parseTransform(s string) (svgMatrix, bool) {
+	m := svgIdentity
+	sc := &svgScanner{s: s}
+	for !sc.done() {
+		start := sc.i
+		for sc.i < len(sc.s) && sc.s[sc.i] != '(' {
+			sc.i++
+		}
+		name := strings.TrimSpace(sc.s[start:sc.i])
+		end := strings.IndexByte(sc.s[sc.i:], ')')
+		if sc.i >= len(sc.s) || end < 0 {
+			return svgIdentity, false
+		}
+		args := svgNumberList(sc.s[sc.i+1 : sc.i+end])
+		sc.i += end + 1
+
+		arg := func(i int, def float64) float64 {
+			if i < len(args) {
+				return args[i]
+			}
+			return def
+		}
+		var t svgMatrix
+		switch {
+		case name == "matrix" && len(args) == 6:
+			copy(t[:], args)
+		case name == "translate" && len(args) >= 1:
+			t = svgMatrix{1, 0, 0, 1, args[0], arg(1, 0)}
+		case name == "scale" && len(args) >= 1:
+			t = svgMatrix{args[0], 0, 0, arg(1, args[0]), 0, 0}
+		case name == "rotate" && len(args) >= 1:
+			sin, cos := math.Sincos(args[0] * math.Pi / 180)
+			cx, cy := arg(1, 0), arg(2, 0)
+			t = svgMatrix{1, 0, 0, 1, cx, cy}.mul(svgMatrix{cos, sin, -sin, cos, 0, 0}).mul(svgMatrix{1, 0, 0, 1, -cx, -cy})
+		case name == "skewX" && len(args) == 1:
+			t = svgMatrix{1, 0, math.Tan(args[0] * math.Pi / 180), 1, 0, 0}
+		case name == "skewY" && len(args) == 1:
+			t = svgMatrix{1, math.Tan(args[0] * math.Pi / 180), 0, 1, 0, 0}
+		default:
+			return svgIdentity, false
+		}
+		m = m.mul(t)
+	}
+	return m, true
+}
+
+// svgPath накапливает операторы построения контура PDF
+type svgPath struct {
+	b strings.Builder
+	// cur — текущая точка, start — начало подконтура
+	cur, start svgPoint
+}
+
+func (p *svgPath) empty() bool {
+	return p.b.Len() == 0
+}
+
+func (p *svgPath) moveTo(pt svgPoint) {
+	p.b.WriteString(svgNums(pt.X, pt.Y) + " m\n")
+	p.cur, p.start = pt, pt
+}
+
+func (p *svgPath) lineTo(pt svgPoint) {
+	p.b.WriteString(svgNums(pt.X, pt.Y) + " l\n")
+	p.cur = pt
+}
+
+func (p *svgPath) curveTo(c1, c2, pt svgPoint) {
+	p.b.WriteString(svgNums(c1.X, c1.Y, c2.X, c2.Y, pt.X, pt.Y) + " c\n")
+	p.cur = pt
+}
+
+// quadTo переводит квадратичную кривую в кубическую, которой только и умеет PDF
+func (p *svgPath) quadTo(c, pt svgPoint) {
+	c1 := svgPoint{p.cur.X + 2*(c.X-p.cur.X)/3, p.cur.Y + 2*(c.Y-p.cur.Y)/3}
+	c2 := svgPoint{pt.X + 2*(c.X-pt.X)/3, pt.Y + 2*(c.Y-pt.Y)/3}
+	p.curveTo(c1, c2, pt)
+}
+
+func (p *svgPath) close() {
+	p.b.WriteString("h\n")
+	p.cur = p.start
+}
+
+// arcTo строит эллиптическую дугу SVG кривыми Безье не больше четверти оборота.
+// Переход от концов дуги к центру — по приложению B.2.4 спецификации SVG.
+func (p *svgPath) arcTo(rx, ry, phi float64, large, sweep bool, end svgPoint) {
+	start := p.cur
+	if start == end {
+		return
+	}
+	rx, ry = math.Abs(rx), math.Abs(ry)
+	if rx == 0 || ry == 0 {
+		p.lineTo(end)
+		return
+	}
+
+	sin, cos := math.Sincos(phi * math.Pi / 180)
+	dx, dy := (start.X-end.X)/2, (start.Y-end.Y)/2
+	x1, y1 := cos*dx+sin*dy, -sin*dx+cos*dy
+	// Слишком маленькие радиусы увеличиваются, пока дуга не дотянется до конца
+	if l := x1*x1/(rx*rx) + y1*y1/(ry*ry); l > 1 {
+		rx, ry = rx*math.Sqrt(l), ry*math.Sqrt(l)
+	}
+	num := rx*rx*ry*ry - rx*rx*y1*y1 - ry*ry*x1*x1
+	den := rx*rx*y1*y1 + ry*ry*x1*x1
+	k := math.Sqrt(math.Max(0, num/den))
+	if large == sweep {
+		k = -k
+	}
+	cx1, cy1 := k*rx*y1/ry, -k*ry*x1/rx
+	cx := cos*cx1 - sin*cy1 + (start.X+end.X)/2
+	cy := sin*cx1 + cos*cy1 + (start.Y+end.Y)/2
+
+	theta := math.Atan2((y1-cy1)/ry, (x1-cx1)/rx)
+	delta := math.Atan2((-y1-cy1)/ry, (-x1-cx1)/rx) - theta
+	if sweep && delta < 0 {
+		delta += 2 * math.Pi
+	} else if !sweep && delta > 0 {
+		delta -= 2 * math.Pi
+	}
+
+	n := max(int(math.Ceil(math.Abs(delta)/(math.Pi/2)-1e-9)), 1)
+	step := delta / float64(n)
+	t := 4.0 / 3 * math.Tan(step/4)
+	// at возвращает точку эллипса под углом a и касательную в ней
+	at := func(a float64) (svgPoint, svgPoint) {
+		s, c := math.Sincos(a)
+		ex, ey := rx*c, ry*s
+		tx, ty := -rx*s, ry*c
+		return svgPoint{cx + cos*ex - sin*ey, cy + sin*ex + cos*ey}, svgPoint{cos*tx - sin*ty, sin*tx + cos*ty}
+	}
+	for i := range n {
+		p1, d1 := at(theta + float64(i)*step)
+		p2, d2 := at(theta + float64(i+1)*step)
+		if i == n-1 {
+			p2 = end
+		}
+		p.curveTo(svgPoint{p1.X + t*d1.X, p1.Y + t*d1.Y}, svgPoint{p2.X - t*d2.X, p2.Y - t*d2.Y}, p2)
+	}
+}
+
+// ellipse добавляет замкнутый эллипс из четырёх кривых
+func (p *svgPath) ellipse(cx, cy, rx, ry float64) {
+	kx, ky := rx*svgKappa, ry*svgKappa
+	p.moveTo(svgPoint{cx + rx, cy})
+	p.curveTo(svgPoint{cx + rx, cy + ky}, svgPoint{cx + kx, cy + ry}, svgPoint{cx, cy + ry})
+	p.curveTo(svgPoint{cx - kx, cy + ry}, svgPoint{cx - rx, cy + ky}, svgPoint{cx - rx, cy})
+	p.curveTo(svgPoint{cx - rx, cy - ky}, svgPoint{cx - kx, cy - ry}, svgPoint{cx, cy - ry})
+	p.curveTo(svgPoint{cx + kx, cy - ry}, svgPoint{cx + rx, cy - ky}, svgPoint{cx + rx, cy})
+	p.close()
+}
+
+// roundRect добавляет прямоугольник со скруглёнными углами радиусов rx, ry
+func (p *svgPath) roundRect(x, y, w, h, rx, ry float64) {
+	if rx <= 0 || ry <= 0 {
+		p.b.WriteString(svgNums(x, y, w, h) + " re\n")
+		p.cur, p.start = svgPoint{x, y}, svgPoint{x, y}
+		return
+	}
+	kx, ky := rx*(1-svgKappa), ry*(1-svgKappa)
+	r, b := x+w, y+h
+	p.moveTo(svgPoint{x + rx, y})
+	p.lineTo(svgPoint{r - rx, y})
+	p.curveTo(svgPoint{r - kx, y}, svgPoint{r, y + ky}, svgPoint{r, y + ry})
+	p.lineTo(svgPoint{r, b - ry})
+	p.curveTo(svgPoint{r, b - ky}, svgPoint{r - kx, b}, svgPoint{r - rx, b})
+	p.lineTo(svgPoint{x + rx, b})
+	p.curveTo(svgPoint{x + kx, b}, svgPoint{x, b - ky}, svgPoint{x, b - ry})
+	p.lineTo(svgPoint{x, y + ry})
+	p.curveTo(svgPoint{x, y + ky}, svgPoint{x + kx, y}, svgPoint{x + rx, y})
+	p.close()
+}
+
+// polyline добавляет ломаную по списку координат points, замкнутую для polygon
+func (p *svgPath) polyline(points []float64, closed bool) {
+	if len(points) < 4 {
+		return
+	}
+	p.moveTo(svgPoint{points[0], points[1]})
+	for i := 2; i+1 < len(points); i += 2 {
+		p.lineTo(svgPoint{points[i], points[i+1]})
+	}
+	if closed {
+		p.close()
+	}
+}
+
+// parsePathData переводит атрибут d в контур. Как и браузеры, при ошибке в данных
+// контур обрывается на последней разобранной команде.
+func parsePathData(d string) *svgPath {
+	p := &svgPath{}
+	sc := &svgScanner{s: d}
+	var cmd, prev byte
+	// ctrl — последняя контрольная точка для сглаженных кривых S и T
+	var ctrl svgPoint
+
+	for !sc.done() {
+		if c, ok := sc.command(); ok {
+			cmd = c
+		} else if cmd == 0 || cmd == 'Z' || cmd == 'z' {
+			return p
+		}
+		rel := cmd >= 'a'
+		abs := func(x, y float64) svgPoint {
+			if rel {
+				return svgPoint{p.cur.X + x, p.cur.Y + y}
+			}
+			return svgPoint{x, y}
+		}
+		upper := cmd &^ 0x20
+		if upper != 'M' && upper != 'Z' && p.empty() {
+			// Контур обязан начинаться с moveto
+			return p
+		}
+
+		switch upper {
+		case 'M':
+			v, ok := sc.numbers(2)
+			if !ok {
+				return p
+			}
+			p.moveTo(abs(v[0], v[1]))
+			// Следующие пары координат после moveto — это lineto
+			cmd = 'L' | cmd&0x20
+		case 'Z':
+			p.close()
+		case 'L':
+			v, ok := sc.numbers(2)
+			if !ok {
+				return p
+			}
+			p.lineTo(abs(v[0], v[1]))
+		case 'H':
+			v, ok := sc.number()
+			if !ok {
+				return p
+			}
+			pt := svgPoint{v, p.cur.Y}
+			if rel {
+				pt.X += p.cur.X
+			}
+			p.lineTo(pt)
+		case 'V':
+			v, ok := sc.number()
+			if !ok {
+				return p
+			}
+			pt := svgPoint{p.cur.X, v}
+			if rel {
+				pt.Y += p.cur.Y
+			}
+			p.lineTo(pt)
+		case 'C':
+			v, ok := sc.numbers(6)
+			if !ok {
+				return p
+			}
+			c1, c2, pt := abs(v[0], v[1]), abs(v[2], v[3]), abs(v[4], v[5])
+			p.curveTo(c1, c2, pt)
+			ctrl = c2
+		case 'S':
+			v, ok := sc.numbers(4)
+			if !ok {
+				return p
+			}
+			c1 := p.cur
+			if prev == 'C' || prev == 'S' {
+				c1 = svgPoint{2*p.cur.X - ctrl.X, 2*p.cur.Y - ctrl.Y}
+			}
+			c2, pt := abs(v[0], v[1]), abs(v[2], v[3])
+			p.curveTo(c1, c2, pt)
+			ctrl = c2
+		case 'Q':
+			v, ok := sc.numbers(4)
+			if !ok {
+				return p
+			}
+			c, pt := abs(v[0], v[1]), abs(v[2], v[3])
+			p.quadTo(c, pt)
+			ctrl = c
+		case 'T':
+			v, ok := sc.numbers(2)
+			if !ok {
+				return p
+			}
+			c := p.cur
+			if prev == 'Q' || prev == 'T' {
+				c = svgPoint{2*p.cur.X - ctrl.X, 2*p.cur.Y - ctrl.Y}
+			}
+			p.quadTo(c, abs(v[0], v[1]))
+			ctrl = c
+		case 'A':
+			r, ok := sc.numbers(3)
+			if !ok {
+				return p
+			}
+			large, ok1 := sc.flag()
+			sweep, ok2 := sc.flag()
+			v, ok3 := sc.numbers(2)
+			if !ok1 || !ok2 || !ok3 {
+				return p
+			}
+			p.arcTo(r[0], r[1], r[2], large, sweep, abs(v[0], v[1]))
+		default:
+			return p
+		}
+		prev = upper
+	}
+	return p
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pdfcpu/pdfcpu/pkg/font"
+)
+
+// winAnsi переводит строку в кодировку WinAnsiEncoding стандартных шрифтов PDF.
+// Символы, которых в ней нет, заменяются на '?'.
+func winAnsi(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r < 0x20:
+			b.WriteByte(' ')
+		case r < 0x7f || (r >= 0xa0 && r <= 0xff):
+			b.WriteByte(byte(r))
+		default:
+			if c, ok := winAnsiExtra[r]; ok {
+				b.WriteByte(c)
+			} else {
+				b.WriteByte('?')
+			}
+		}
+	}
+	return b.String()
+}
+
+// winAnsiExtra — символы WinAnsiEncoding из диапазона 0x80–0x9f
+var winAnsiExtra = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87,
+	'ˆ': 0x88, '‰': 0x89, 'Š': 0x8a, '‹': 0x8b, 'Œ': 0x8c, 'Ž': 0x8e,
+	'‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97,
+	'˜': 0x98, '™': 0x99, 'š': 0x9a, '›': 0x9b, 'œ': 0x9c, 'ž': 0x9e, 'Ÿ': 0x9f,
+}
+
+// fitText укорачивает строку в WinAnsiEncoding многоточием, чтобы она помещалась в width пунктов
+func fitText(s, fontName string, size int, width float64) string {
+	if font.TextWidth(s, fontName, size) <= width {
+		return s
+	}
+	const ellipsis = "\x85"
+	for len(s) > 0 {
+		s = s[:len(s)-1]
+		if font.TextWidth(s+ellipsis, fontName, size) <= width {
+			return s + ellipsis
+		}
+	}
+	return ""
+}
+
+// textOp возвращает оператор content stream, выводящий строку s шрифтом fontRes
+// с базовой линией в точке x, y. Строка уже должна быть в WinAnsiEncoding.
+func textOp(fontRes string, size int, x, y float64, s string) string {
+	return fmt.Sprintf("BT /%s %d Tf %.4f %.4f Td (%s) Tj ET\n", fontRes, size, x, y, escapeText(s))
+}
+
+// textEscaper экранирует символы, особые внутри строки PDF в круглых скобках
+var textEscaper = strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`)
+
+// escapeText готовит строку s для оператора Tj
+func escapeText(s string) string {
+	return textEscaper.Replace(s)
+}
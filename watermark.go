@@ -0,0 +1,314 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/pdfcpu/pdfcpu/pkg/font"
+	"github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
+)
+
+// Слой водяного знака (-watermark-layer)
+const (
+	// WatermarkOver рисует знак поверх изображения
+	WatermarkOver = "over"
+	// WatermarkUnder рисует знак под изображением, он виден только на полях
+	// и сквозь прозрачные области
+	WatermarkUnder = "under"
+)
+
+var watermarkLayers = map[string]bool{
+	WatermarkOver:  true,
+	WatermarkUnder: true,
+}
+
+// Положение водяного знака на странице (-watermark-position)
+var watermarkPositions = map[string][2]int{
+	"top-left":     {-1, 1},
+	"top":          {0, 1},
+	"top-right":    {1, 1},
+	"left":         {-1, 0},
+	"center":       {0, 0},
+	"right":        {1, 0},
+	"bottom-left":  {-1, -1},
+	"bottom":       {0, -1},
+	"bottom-right": {1, -1},
+}
+
+const (
+	watermarkFont = "Helvetica-Bold"
+	// Имена ресурсов водяного знака на странице
+	watermarkFontRes   = "FW"
+	watermarkImageRes  = "WM"
+	watermarkGStateRes = "GSW"
+	// watermarkMargin — отступ знака от края в долях меньшей стороны страницы
+	watermarkMargin = 0.05
+	// watermarkTileGap — промежуток между плитками в долях размера знака
+	watermarkTileGap = 0.5
+)
+
+// hasWatermark сообщает, что на страницы нужно нанести водяной знак
+func (o Options) hasWatermark() bool {
+	return o.WatermarkText != "" || o.WatermarkImage != ""
+}
+
+// validateWatermark проверяет параметры водяного знака
+func (o Options) validateWatermark() error {
+	if o.WatermarkOpacity <= 0 || o.WatermarkOpacity > 1 {
+		return fmt.Errorf("%w: watermark opacity must be greater than 0 and at most 1", ErrInvalidInput)
+	}
+	if o.WatermarkScale <= 0 || o.WatermarkScale > 10 {
+		return fmt.Errorf("%w: watermark scale must be greater than 0 and at most 10", ErrInvalidInput)
+	}
+	if _, ok := watermarkPositions[o.WatermarkPosition]; !ok {
+		return fmt.Errorf("%w: unknown watermark position %q", ErrInvalidInput, o.WatermarkPosition)
+	}
+	if !watermarkLayers[o.WatermarkLayer] {
+		return fmt.Errorf("%w: unknown watermark layer %q", ErrInvalidInput, o.WatermarkLayer)
+	}
+	if _, err := parsePageSet(o.WatermarkPages); err != nil {
+		return err
+	}
+	_, err := parseColor(o.WatermarkColor)
+	return err
+}
+
+// parsePageSet разбирает список страниц через запятую: "1-3,5,8-". Пустой список
+// выбирает все страницы.
+func parsePageSet(spec string) (func(int) bool, error) {
+	type pageSpan struct{ first, last int }
+	var spans []pageSpan
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		var s pageSpan
+		var err error
+		if open, ok := strings.CutSuffix(entry, "-"); ok {
+			s.first, _, err = parsePageRange(open)
+			s.last = math.MaxInt
+		} else {
+			s.first, s.last, err = parsePageRange(entry)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+		}
+		spans = append(spans, s)
+	}
+	return func(page int) bool {
+		if len(spans) == 0 {
+			return true
+		}
+		for _, s := range spans {
+			if page >= s.first && page <= s.last {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+// watermark — подготовленный водяной знак: ресурсы документа и размеры знака
+// относительно ширины страницы
+type watermark struct {
+	font, image, gstate types.IndirectRef
+	hasImage            bool
+	// aspect — отношение высоты изображения к ширине
+	aspect float64
+	text   string
+	// textWidth и textAscent — ширина строки и высота букв при размере шрифта 1
+	textWidth, textAscent float64
+}
+
+// newWatermark добавляет в документ шрифт, изображение и прозрачность водяного знака
+func (c *Converter) newWatermark(w *pdfWriter) (*watermark, error) {
+	o := c.opts
+	wm := &watermark{}
+	var err error
+	if wm.gstate, err = w.opacity(o.WatermarkOpacity); err != nil {
+		return nil, err
+	}
+	if o.WatermarkText != "" {
+		if wm.font, err = w.standardFont(watermarkFont); err != nil {
+			return nil, err
+		}
+		wm.text = winAnsi(o.WatermarkText)
+		wm.textWidth = font.TextWidth(wm.text, watermarkFont, 1000) / 1000
+		wm.textAscent = font.Ascent(watermarkFont, 1000) / 1000
+	}
+	if o.WatermarkImage != "" {
+		src, err := loadImage(o.WatermarkImage)
+		if err != nil {
+			return nil, err
+		}
+		var img *pdfImage
+		if bg, flatten, _ := o.alphaBackground(); flatten && o.PDFA != "" && !isOpaque(src.Image) {
+			// PDF/A запрещает прозрачность, прозрачные области логотипа заливаются подложкой
+			img = encodeFlate(flattenAlpha(src.Image, bg))
+		} else if src.Format == "jpeg" {
+			img, err = jpegPassthrough(src.Raw)
+		} else {
+			img = encodeFlate(src.Image)
+		}
+		if err != nil {
+			return nil, &ImageError{Path: o.WatermarkImage, Reason: err.Error()}
+		}
+		img.ICC = src.ICC
+		if wm.image, err = w.addImage(img); err != nil {
+			return nil, err
+		}
+		wm.hasImage = true
+		b := src.Image.Bounds()
+		wm.aspect = float64(b.Dy()) / float64(b.Dx())
+	}
+	return wm, nil
+}
+
+// opacity добавляет в документ графическое состояние с прозрачностью alpha
+func (w *pdfWriter) opacity(alpha float64) (types.IndirectRef, error) {
+	ref, err := w.ctx.IndRefForNewObject(types.Dict(map[string]types.Object{
+		"Type": types.Name("ExtGState"),
+		"ca":   types.Float(alpha),
+		"CA":   types.Float(alpha),
+	}))
+	if err != nil {
+		return types.IndirectRef{}, err
+	}
+	return *ref, nil
+}
+
+// watermarkPages наносит водяной знак на выбранные страницы готового документа
+func (c *Converter) watermarkPages(w *pdfWriter) error {
+	if !c.opts.hasWatermark() {
+		return nil
+	}
+	selected, err := parsePageSet(c.opts.WatermarkPages)
+	if err != nil {
+		return err
+	}
+	wm, err := c.newWatermark(w)
+	if err != nil {
+		return err
+	}
+	for n := 1; n <= w.ctx.PageCount; n++ {
+		if !selected(n) {
+			continue
+		}
+		if err := c.watermarkPage(w, n, wm); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// watermarkPage добавляет водяной знак на страницу n
+func (c *Converter) watermarkPage(w *pdfWriter, n int, wm *watermark) error {
+	o := c.opts
+	d, _, _, err := w.ctx.PageDict(n, false)
+	if err != nil {
+		return err
+	}
+	box, err := visibleBox(d)
+	if err != nil {
+		return err
+	}
+	rotate := 0
+	if r := d.IntEntry("Rotate"); r != nil {
+		rotate = ((*r % 360) + 360) % 360
+	}
+	width, height := box.W, box.H
+	if rotate == 90 || rotate == 270 {
+		width, height = height, width
+	}
+
+	// Знак строится в своих координатах с центром в начале, затем поворачивается
+	// и переносится в выбранные точки страницы
+	col, err := parseColor(o.WatermarkColor)
+	if err != nil {
+		return err
+	}
+	var mark strings.Builder
+	markW := o.WatermarkScale * width
+	markH := 0.0
+	if wm.hasImage {
+		markH = markW * wm.aspect
+		fmt.Fprintf(&mark, "q %.4f 0 0 %.4f %.4f %.4f cm /%s Do Q\n", markW, markH, -markW/2, -markH/2, watermarkImageRes)
+	}
+	if wm.text != "" && wm.textWidth > 0 {
+		size := markW / wm.textWidth
+		ascent := size * wm.textAscent
+		markH = math.Max(markH, ascent)
+		fmt.Fprintf(&mark, "%.4f %.4f %.4f rg BT /%s %.4f Tf %.4f %.4f Td (%s) Tj ET\n",
+			float64(col.R)/255, float64(col.G)/255, float64(col.B)/255,
+			watermarkFontRes, size, -markW/2, -ascent/2, escapeText(wm.text))
+	}
+
+	// Размеры повёрнутого знака
+	angle := o.WatermarkRotate * math.Pi / 180
+	cos, sin := math.Cos(angle), math.Sin(angle)
+	bw := math.Abs(markW*cos) + math.Abs(markH*sin)
+	bh := math.Abs(markW*sin) + math.Abs(markH*cos)
+
+	var ops strings.Builder
+	ops.WriteString("q\n")
+	ops.WriteString(displayMatrix(box, rotate))
+	fmt.Fprintf(&ops, "/%s gs\n", watermarkGStateRes)
+	for _, p := range c.watermarkCenters(width, height, bw, bh) {
+		fmt.Fprintf(&ops, "q %.4f %.4f %.4f %.4f %.4f %.4f cm\n%sQ\n", cos, sin, -sin, cos, p[0], p[1], mark.String())
+	}
+	ops.WriteString("Q\n")
+
+	if err := w.addContent(d, []byte(ops.String()), o.WatermarkLayer == WatermarkUnder); err != nil {
+		return err
+	}
+	addResource(d, "ExtGState", watermarkGStateRes, wm.gstate)
+	if wm.text != "" {
+		addResource(d, "Font", watermarkFontRes, wm.font)
+	}
+	if wm.hasImage {
+		addResource(d, "XObject", watermarkImageRes, wm.image)
+	}
+	return nil
+}
+
+// watermarkCenters возвращает центры копий знака с габаритами bw×bh на странице
+// width×height: одну точку по -watermark-position или сетку плиток с -watermark-tile
+func (c *Converter) watermarkCenters(width, height, bw, bh float64) [][2]float64 {
+	if !c.opts.WatermarkTile {
+		pos := watermarkPositions[c.opts.WatermarkPosition]
+		margin := watermarkMargin * math.Min(width, height)
+		place := func(side, size float64, dir int) float64 {
+			switch dir {
+			case -1:
+				return margin + size/2
+			case 1:
+				return side - margin - size/2
+			}
+			return side / 2
+		}
+		return [][2]float64{{place(width, bw, pos[0]), place(height, bh, pos[1])}}
+	}
+
+	// Плитки идут от центра страницы, чтобы узор был симметричным
+	stepX, stepY := bw*(1+watermarkTileGap), bh*(1+watermarkTileGap)
+	if stepX <= 0 || stepY <= 0 {
+		return nil
+	}
+	nx := int(math.Ceil(width/2/stepX)) + 1
+	ny := int(math.Ceil(height/2/stepY)) + 1
+	var centers [][2]float64
+	for iy := -ny; iy <= ny; iy++ {
+		for ix := -nx; ix <= nx; ix++ {
+			// Соседние ряды сдвинуты на полшага, как кирпичная кладка
+			x := width/2 + (float64(ix)+0.5*float64(iy&1))*stepX
+			y := height/2 + float64(iy)*stepY
+			if x+bw/2 < 0 || x-bw/2 > width || y+bh/2 < 0 || y-bh/2 > height {
+				continue
+			}
+			centers = append(centers, [2]float64{x, y})
+		}
+	}
+	return centers
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/pdfcpu/pdfcpu/pkg/api"
+)
+
+func TestParsePageSet(t *testing.T) {
+	selected, err := parsePageSet("1-3, 5,8-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []int
+	for page := 1; page <= 10; page++ {
+		if selected(page) {
+			got = append(got, page)
+		}
+	}
+	if want := []int{1, 2, 3, 5, 8, 9, 10}; !slices.Equal(got, want) {
+		t.Errorf("Selected pages = %v; want %v", got, want)
+	}
+
+	if all, _ := parsePageSet(""); !all(42) {
+		t.Error("Empty page set should select every page")
+	}
+	for _, spec := range []string{"0", "3-1", "a-b", "-2"} {
+		if _, err := parsePageSet(spec); !IsInvalidInput(err) {
+			t.Errorf("%q: expected ErrInvalidInput, got %v", spec, err)
+		}
+	}
+}
+
+func TestWatermarkCenters(t *testing.T) {
+	opts := DefaultOptions()
+	opts.WatermarkPosition = "top-left"
+	c := NewConverterWithOptions(opts)
+	centers := c.watermarkCenters(200, 100, 40, 20)
+	// Отступ 5% меньшей стороны
+	if len(centers) != 1 || centers[0] != [2]float64{25, 85} {
+		t.Errorf("top-left centers = %v; want [[25 85]]", centers)
+	}
+
+	opts.WatermarkTile = true
+	c = NewConverterWithOptions(opts)
+	centers = c.watermarkCenters(200, 100, 40, 20)
+	if len(centers) < 9 {
+		t.Fatalf("Tiles = %d; want the page covered", len(centers))
+	}
+	for _, p := range centers {
+		if p[0] < -20 || p[0] > 220 || p[1] < -10 || p[1] > 110 {
+			t.Errorf("Tile at %v is outside the page", p)
+		}
+	}
+}
+
+func TestConvert_Watermark(t *testing.T) {
+	tmpDir := t.TempDir()
+	createTree(t, tmpDir, "1.jpg", "2.jpg", "3.jpg")
+	logo := filepath.Join(tmpDir, "logo.png")
+	if err := createTestImageWithAlpha(logo, 40, 20, "png", 128); err != nil {
+		t.Fatal(err)
+	}
+	var inputs []string
+	for _, name := range []string{"1.jpg", "2.jpg", "3.jpg"} {
+		inputs = append(inputs, filepath.Join(tmpDir, name))
+	}
+
+	opts := DefaultOptions()
+	opts.PageSize = "A4"
+	opts.WatermarkText = "DRAFT"
+	opts.WatermarkImage = logo
+	opts.WatermarkPages = "2-"
+	opts.WatermarkLayer = WatermarkUnder
+	output := filepath.Join(tmpDir, "out.pdf")
+	if err := NewConverterWithOptions(opts).Convert(strings.Join(inputs, ","), output, "seq"); err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+	if err := api.ValidateFile(output, nil); err != nil {
+		t.Fatalf("Output is not a valid PDF: %v", err)
+	}
+
+	if content := pageContent(t, output, 1); strings.Contains(content, "DRAFT") {
+		t.Errorf("Page 1 is not selected but has the watermark:\n%s", content)
+	}
+	for _, n := range []int{2, 3} {
+		content := pageContent(t, output, n)
+		for _, s := range []string{"/GSW gs", "(DRAFT) Tj", "/WM Do"} {
+			if !strings.Contains(content, s) {
+				t.Errorf("Page %d content does not contain %q:\n%s", n, s, content)
+			}
+		}
+		// Знак под изображением идёт раньше самой страницы
+		if strings.Index(content, "/WM Do") > strings.Index(content, "/Im0 Do") {
+			t.Errorf("Page %d: watermark is drawn over the image:\n%s", n, content)
+		}
+	}
+
+	opts.WatermarkImage = filepath.Join(tmpDir, "missing.png")
+	err := NewConverterWithOptions(opts).Convert(strings.Join(inputs, ","), output, "seq")
+	if !IsConversionError(err) {
+		t.Errorf("Missing watermark image: expected conversion error, got %v", err)
+	}
+}
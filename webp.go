@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/draw"
+
+	"golang.org/x/image/webp"
+)
+
+// Флаги чанка VP8X расширенного формата WebP
+const (
+	webpFlagAnimation = 1 << 1
+	webpFlagAlpha     = 1 << 4
+)
+
+// Флаги кадра ANMF
+const (
+	// webpDispose — после показа область кадра очищается
+	webpDispose = 1 << 0
+	// webpNoBlend — кадр заменяет пиксели холста, а не накладывается на них
+	webpNoBlend = 1 << 1
+)
+
+// webpChunks передаёт fn идентификаторы и содержимое чанков WebP. Обход прекращается,
+// когда fn возвращает false.
+func webpChunks(raw []byte, fn func(id string, data []byte) bool) {
+	if len(raw) < 12 || string(raw[:4]) != "RIFF" || string(raw[8:12]) != "WEBP" {
+		return
+	}
+	riffChunks(raw[12:], fn)
+}
+
+// riffChunks обходит последовательность чанков RIFF, выровненных по двум байтам
+func riffChunks(data []byte, fn func(id string, data []byte) bool) {
+	for i := 0; i+8 <= len(data); {
+		size := int(binary.LittleEndian.Uint32(data[i+4:]))
+		end := i + 8 + size
+		if size < 0 || end > len(data) {
+			return
+		}
+		if !fn(string(data[i:i+4]), data[i+8:end]) {
+			return
+		}
+		i = end + size%2
+	}
+}
+
+// appendChunk дописывает к dst чанк RIFF с выравниванием
+func appendChunk(dst []byte, id string, data []byte) []byte {
+	dst = binary.LittleEndian.AppendUint32(append(dst, id...), uint32(len(data)))
+	dst = append(dst, data...)
+	if len(data)%2 != 0 {
+		dst = append(dst, 0)
+	}
+	return dst
+}
+
+// webpAnimated сообщает, что WebP содержит анимацию. Чанк VP8X всегда идёт первым.
+func webpAnimated(raw []byte) bool {
+	animated := false
+	webpChunks(raw, func(id string, data []byte) bool {
+		animated = id == "VP8X" && len(data) >= 10 && data[0]&webpFlagAnimation != 0
+		return false
+	})
+	return animated
+}
+
+// webpAnimFrame — кадр анимации WebP: область на холсте, способ наложения и
+// самостоятельный файл WebP с изображением кадра
+type webpAnimFrame struct {
+	rect    image.Rectangle
+	noBlend bool
+	dispose bool
+	file    []byte
+}
+
+// webpAnimation разбирает анимированный WebP на холст и кадры ANMF
+func webpAnimation(raw []byte) (image.Rectangle, []webpAnimFrame) {
+	var canvas image.Rectangle
+	var frames []webpAnimFrame
+	webpChunks(raw, func(id string, data []byte) bool {
+		switch {
+		case id == "VP8X" && len(data) >= 10:
+			canvas = image.Rect(0, 0, uint24(data[4:])+1, uint24(data[7:])+1)
+		case id == "ANMF" && len(data) >= 16:
+			// Смещение хранится в половинах пикселя, размеры — за вычетом единицы
+			x, y := 2*uint24(data), 2*uint24(data[3:])
+			w, h := uint24(data[6:])+1, uint24(data[9:])+1
+			frames = append(frames, webpAnimFrame{
+				rect:    image.Rect(x, y, x+w, y+h),
+				noBlend: data[15]&webpNoBlend != 0,
+				dispose: data[15]&webpDispose != 0,
+				file:    webpFrameFile(data[16:], w, h),
+			})
+		}
+		return true
+	})
+	return canvas, frames
+}
+
+// webpFrameFile собирает из данных кадра ANMF отдельный файл WebP. Кадру с каналом
+// ALPH нужен заголовок VP8X с флагом прозрачности.
+func webpFrameFile(data []byte, w, h int) []byte {
+	var body []byte
+	alpha := false
+	riffChunks(data, func(id string, chunk []byte) bool {
+		switch id {
+		case "ALPH":
+			alpha = true
+			body = appendChunk(body, id, chunk)
+		case "VP8 ", "VP8L":
+			body = appendChunk(body, id, chunk)
+		}
+		return true
+	})
+
+	file := []byte("RIFF\x00\x00\x00\x00WEBP")
+	if alpha {
+		vp8x := make([]byte, 10)
+		vp8x[0] = webpFlagAlpha
+		putUint24(vp8x[4:], w-1)
+		putUint24(vp8x[7:], h-1)
+		file = appendChunk(file, "VP8X", vp8x)
+	}
+	file = append(file, body...)
+	binary.LittleEndian.PutUint32(file[4:], uint32(len(file)-8))
+	return file
+}
+
+// webpFrame собирает кадр frame (от 1) анимированного WebP. Как и в GIF, кадр может
+// занимать часть холста и накладываться на предыдущие. Очищенная область становится
+// прозрачной: цвет фона из ANIM по спецификации лишь подсказка для просмотрщика.
+func webpFrame(raw []byte, frame int) (image.Image, error) {
+	rect, frames := webpAnimation(raw)
+	if frame < 1 || frame > len(frames) {
+		return nil, fmt.Errorf("frame %d not found, the file has %d", frame, len(frames))
+	}
+
+	canvas := image.NewNRGBA(rect)
+	for i, f := range frames[:frame] {
+		img, err := webp.Decode(bytes.NewReader(f.file))
+		if err != nil {
+			return nil, fmt.Errorf("frame %d: %v", i+1, err)
+		}
+		op := draw.Over
+		if f.noBlend {
+			op = draw.Src
+		}
+		draw.Draw(canvas, f.rect, img, img.Bounds().Min, op)
+		if f.dispose && i < frame-1 {
+			draw.Draw(canvas, f.rect, image.Transparent, image.Point{}, draw.Src)
+		}
+	}
+	return canvas, nil
+}
+
+// uint24 читает 24-битное число WebP (little-endian)
+func uint24(b []byte) int {
+	return int(b[0]) | int(b[1])<<8 | int(b[2])<<16
+}
+
+// putUint24 записывает 24-битное число WebP (little-endian)
+func putUint24(b []byte, v int) {
+	b[0], b[1], b[2] = byte(v), byte(v>>8), byte(v>>16)
+}
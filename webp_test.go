@@ -0,0 +1,317 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// Образцы WebP 1×1, которые кодировщик тестов не умеет создавать: с потерями
+// (серый пиксель) и с потерями и отдельным каналом ALPH (прозрачный пиксель)
+const (
+	webpLossySample      = "UklGRiIAAABXRUJQVlA4IBYAAAAwAQCdASoBAAEADsD+JaQAA3AAAAAA"
+	webpLossyAlphaSample = "UklGRkoAAABXRUJQVlA4WAoAAAAQAAAAAAAAAAAAQUxQSAwAAAARBxAR/Q9ERP8DAABWUDggGAAAABQBAJ0BKgEAAQAAAP4AAA3AAP7mtQAAAA=="
+)
+
+// vp8lWriter пишет биты VP8L начиная с младшего
+type vp8lWriter struct {
+	buf []byte
+	acc uint64
+	n   uint
+}
+
+func (w *vp8lWriter) put(v uint32, n uint) {
+	w.acc |= uint64(v) << w.n
+	w.n += n
+	for w.n >= 8 {
+		w.buf = append(w.buf, byte(w.acc))
+		w.acc >>= 8
+		w.n -= 8
+	}
+}
+
+// code пишет восьмибитный код Хаффмана: коды читаются со старшего бита
+func (w *vp8lWriter) code(v uint8) {
+	for i := 7; i >= 0; i-- {
+		w.put(uint32(v>>i)&1, 1)
+	}
+}
+
+func (w *vp8lWriter) bytes() []byte {
+	if w.n > 0 {
+		w.put(0, 8-w.n%8)
+	}
+	return w.buf
+}
+
+// encodeVP8L кодирует изображение в VP8L без преобразований: у всех литералов
+// код длиной 8 бит, равный значению канала, поэтому таблицы Хаффмана минимальны
+func encodeVP8L(img *image.NRGBA) []byte {
+	b := img.Bounds()
+	var w vp8lWriter
+	w.put(0x2f, 8)
+	w.put(uint32(b.Dx()-1), 14)
+	w.put(uint32(b.Dy()-1), 14)
+	w.put(1, 1) // есть альфа
+	w.put(0, 3) // версия
+	w.put(0, 1) // без преобразований
+	w.put(0, 1) // без кэша цветов
+	w.put(0, 1) // одна группа кодов
+
+	// Длины кодов передаются кодом длин из символов 0 и 8 в порядке
+	// 17, 18, 0, 1, 2, 3, 4, 5, 16, 6, 7, 8. У зелёного алфавита за 256
+	// литералами идут 24 префикса длины повтора с нулевой длиной.
+	fixed := func(alphabet int) {
+		w.put(0, 1)
+		w.put(12-4, 4)
+		for i := range 12 {
+			switch {
+			case i == 11, i == 2 && alphabet > 256:
+				w.put(1, 3)
+			default:
+				w.put(0, 3)
+			}
+		}
+		w.put(0, 1)
+		if alphabet > 256 {
+			for s := range alphabet {
+				w.put(uint32(min(1, 256-min(s, 256))), 1)
+			}
+		}
+	}
+	fixed(280)
+	fixed(256)
+	fixed(256)
+	fixed(256)
+	// Расстояния не используются: простой код из одного символа
+	w.put(0b0001, 4)
+
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := img.NRGBAAt(x, y)
+			w.code(c.G)
+			w.code(c.R)
+			w.code(c.B)
+			w.code(c.A)
+		}
+	}
+	return w.bytes()
+}
+
+// riffWebP оборачивает чанки в контейнер WebP
+func riffWebP(chunks []byte) []byte {
+	file := append([]byte("RIFF\x00\x00\x00\x00WEBP"), chunks...)
+	binary.LittleEndian.PutUint32(file[4:], uint32(len(file)-8))
+	return file
+}
+
+// encodeWebP сохраняет изображение в WebP без потерь
+func encodeWebP(img *image.NRGBA) []byte {
+	return riffWebP(appendChunk(nil, "VP8L", encodeVP8L(img)))
+}
+
+// webpSample декодирует образец и возвращает его чанки изображения без VP8X
+func webpSample(t *testing.T, sample string) ([]byte, []byte) {
+	raw, err := base64.StdEncoding.DecodeString(sample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var chunks []byte
+	webpChunks(raw, func(id string, data []byte) bool {
+		if id != "VP8X" {
+			chunks = appendChunk(chunks, id, data)
+		}
+		return true
+	})
+	return raw, chunks
+}
+
+// solidWebP возвращает чанк VP8L прямоугольника одного цвета
+func solidWebP(w, h int, c color.NRGBA) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = c.R, c.G, c.B, c.A
+	}
+	return appendChunk(nil, "VP8L", encodeVP8L(img))
+}
+
+// webpTestFrame — кадр тестовой анимации: чанки изображения, положение и флаги ANMF
+type webpTestFrame struct {
+	chunks     []byte
+	x, y, w, h int
+	flags      byte
+}
+
+// animatedWebP собирает анимированный WebP с холстом w×h
+func animatedWebP(w, h int, frames []webpTestFrame) []byte {
+	vp8x := make([]byte, 10)
+	vp8x[0] = webpFlagAnimation | webpFlagAlpha
+	putUint24(vp8x[4:], w-1)
+	putUint24(vp8x[7:], h-1)
+	body := appendChunk(nil, "VP8X", vp8x)
+	body = appendChunk(body, "ANIM", make([]byte, 6))
+	for _, f := range frames {
+		anmf := make([]byte, 16)
+		putUint24(anmf, f.x/2)
+		putUint24(anmf[3:], f.y/2)
+		putUint24(anmf[6:], f.w-1)
+		putUint24(anmf[9:], f.h-1)
+		putUint24(anmf[12:], 100)
+		anmf[15] = f.flags
+		body = appendChunk(body, "ANMF", append(anmf, f.chunks...))
+	}
+	return riffWebP(body)
+}
+
+var (
+	webpRed   = color.NRGBA{255, 0, 0, 255}
+	webpBlue  = color.NRGBA{0, 0, 255, 255}
+	webpGreen = color.NRGBA{0, 255, 0, 255}
+)
+
+// testAnimation — анимация 20×20: красный фон, синий угол, который стирается после
+// показа, зелёный квадрат без наложения и прозрачный пиксель с потерями поверх него
+func testAnimation(t *testing.T) []byte {
+	_, lossyAlpha := webpSample(t, webpLossyAlphaSample)
+	return animatedWebP(20, 20, []webpTestFrame{
+		{chunks: solidWebP(20, 20, webpRed), w: 20, h: 20},
+		{chunks: solidWebP(6, 6, webpBlue), w: 6, h: 6, flags: webpDispose},
+		{chunks: solidWebP(10, 10, color.NRGBA{0, 255, 0, 128}), x: 10, y: 10, w: 10, h: 10, flags: webpNoBlend},
+		{chunks: lossyAlpha, x: 12, y: 12, w: 1, h: 1},
+	})
+}
+
+func TestLoadImage_WebPVariants(t *testing.T) {
+	tmpDir := t.TempDir()
+	gradient := image.NewNRGBA(image.Rect(0, 0, 7, 5))
+	for i := 0; i < len(gradient.Pix); i += 4 {
+		gradient.Pix[i], gradient.Pix[i+1], gradient.Pix[i+2], gradient.Pix[i+3] = uint8(i), uint8(3*i), 200, 255
+	}
+	translucent := image.NewNRGBA(gradient.Rect)
+	copy(translucent.Pix, gradient.Pix)
+	for i := 3; i < len(translucent.Pix); i += 4 {
+		translucent.Pix[i] = uint8(i)
+	}
+	lossy, _ := webpSample(t, webpLossySample)
+	lossyAlpha, _ := webpSample(t, webpLossyAlphaSample)
+
+	tests := []struct {
+		name   string
+		data   []byte
+		want   *image.NRGBA
+		bounds image.Rectangle
+		opaque bool
+	}{
+		{"lossy", lossy, nil, image.Rect(0, 0, 1, 1), true},
+		{"lossy with alpha", lossyAlpha, nil, image.Rect(0, 0, 1, 1), false},
+		{"lossless", encodeWebP(gradient), gradient, gradient.Rect, true},
+		{"lossless with alpha", encodeWebP(translucent), translucent, translucent.Rect, false},
+		{"animated", testAnimation(t), nil, image.Rect(0, 0, 20, 20), true},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(tmpDir, "image.webp")
+		if err := os.WriteFile(path, tt.data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		src, err := loadImage(path)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if src.Format != "webp" || src.Image.Bounds() != tt.bounds {
+			t.Errorf("%s: format %s, bounds %v; want webp, %v", tt.name, src.Format, src.Image.Bounds(), tt.bounds)
+		}
+		if isOpaque(src.Image) != tt.opaque {
+			t.Errorf("%s: opaque = %v; want %v", tt.name, !tt.opaque, tt.opaque)
+		}
+		if tt.want != nil && !slices.Equal(toNRGBA(src.Image).Pix, tt.want.Pix) {
+			t.Errorf("%s: pixels differ from the encoded image", tt.name)
+		}
+	}
+}
+
+func TestWebPFrame(t *testing.T) {
+	raw := testAnimation(t)
+	transparent := color.NRGBA{}
+	halfGreen := color.NRGBA{0, 255, 0, 128}
+	tests := []struct {
+		frame              int
+		corner, mid, inner color.NRGBA
+	}{
+		{1, webpRed, webpRed, webpRed},
+		{2, webpBlue, webpRed, webpRed},
+		// Синий угол стёрт до прозрачного, зелёный кадр заменил красный вместе с альфой
+		{3, transparent, webpRed, halfGreen},
+		// Прозрачный пиксель наложен и ничего не изменил
+		{4, transparent, webpRed, halfGreen},
+	}
+	for _, tt := range tests {
+		img, err := webpFrame(raw, tt.frame)
+		if err != nil {
+			t.Fatal(err)
+		}
+		at := func(x, y int) color.NRGBA { return color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA) }
+		if at(1, 1) != tt.corner || at(8, 8) != tt.mid || at(12, 12) != tt.inner {
+			t.Errorf("Frame %d: %v %v %v; want %v %v %v", tt.frame, at(1, 1), at(8, 8), at(12, 12), tt.corner, tt.mid, tt.inner)
+		}
+	}
+	if _, err := webpFrame(raw, 5); err == nil {
+		t.Error("Expected an error for a missing frame")
+	}
+}
+
+func TestConvert_WebP(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := createTestImageWithAlpha(filepath.Join(tmpDir, "1.webp"), 20, 20, "webp", 128); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "2.webp"), testAnimation(t), 0644); err != nil {
+		t.Fatal(err)
+	}
+	lossy, _ := webpSample(t, webpLossySample)
+	if err := os.WriteFile(filepath.Join(tmpDir, "3.webp"), lossy, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	output := filepath.Join(tmpDir, "out.pdf")
+	c := NewConverterWithOptions(DefaultOptions())
+	if err := c.Convert(tmpDir, output, "nam"); err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+	pages := c.Report().Pages
+	if len(pages) != 3 {
+		t.Fatalf("Pages = %d; want 3", len(pages))
+	}
+	// Прозрачность WebP сохраняется так же, как у PNG
+	if pages[0].Alpha != "smask" || pages[1].Alpha != "" || pages[2].Alpha != "" {
+		t.Errorf("Alpha = %q, %q, %q; want smask for the translucent image only", pages[0].Alpha, pages[1].Alpha, pages[2].Alpha)
+	}
+	if pages[1].Width != 20 || strings.Contains(pages[1].Source, "#") {
+		t.Errorf("Animated WebP page: %s, %dpx", pages[1].Source, pages[1].Width)
+	}
+
+	opts := DefaultOptions()
+	opts.Animation = AnimationAll
+	opts.Alpha = AlphaWhite
+	c = NewConverterWithOptions(opts)
+	if err := c.Convert(tmpDir, output, "nam"); err != nil {
+		t.Fatalf("Convert failed: %v", err)
+	}
+	var sources []string
+	for _, p := range c.Report().Pages {
+		sources = append(sources, filepath.Base(p.Source))
+		if p.Alpha == "smask" {
+			t.Errorf("Page %d keeps transparency with -alpha white", p.Page)
+		}
+	}
+	want := []string{"1.webp", "2.webp#1", "2.webp#2", "2.webp#3", "2.webp#4", "3.webp"}
+	if !slices.Equal(sources, want) {
+		t.Errorf("Pages %v; want %v", sources, want)
+	}
+}